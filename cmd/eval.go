@@ -0,0 +1,145 @@
+// cmd/eval.go
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/eval"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/prompt"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/reviewer"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Eval command flags
+	fixturesDir      string
+	ollamaHost       string
+	evalModel        string
+	compareModel     string
+	comparePromptDir string
+	lineTolerance    int
+	resultsFile      string
+)
+
+// NewEvalCommand creates the eval command
+func NewEvalCommand() *cobra.Command {
+	evalCmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Evaluate review quality against a labeled fixture corpus",
+		Long: `Review every fixture in a directory and score the results against the expected findings.
+Each fixture is a directory holding diff.patch, expected.json, and optionally pr.json and the
+head/ and base/ file trees. No GitHub access is needed.`,
+		Example: `  # Evaluate the configured model
+  mountain-hawk eval --fixtures testdata/eval
+
+  # Compare two models side by side
+  mountain-hawk eval --fixtures testdata/eval --model qwen2.5-coder:14b --compare-model gpt-oss:20b
+
+  # Compare prompt template changes
+  mountain-hawk eval --fixtures testdata/eval --compare-prompt-dir ./prompts`,
+		RunE: runEval,
+	}
+
+	defaultModel := os.Getenv("OLLAMA_MODEL")
+	if defaultModel == "" {
+		defaultModel = "gpt-oss:20b"
+	}
+
+	evalCmd.Flags().StringVar(&fixturesDir, "fixtures", "", "Directory of fixtures (required)")
+	evalCmd.Flags().StringVar(&ollamaHost, "ollama-host", os.Getenv("OLLAMA_HOST"), "Ollama URL")
+	evalCmd.Flags().StringVar(&evalModel, "model", defaultModel, "Model to evaluate")
+	evalCmd.Flags().StringVar(&compareModel, "compare-model", "", "Model to compare against")
+	evalCmd.Flags().StringVar(&comparePromptDir, "compare-prompt-dir", "", "Prompt template directory to compare against")
+	evalCmd.Flags().IntVar(&lineTolerance, "line-tolerance", eval.DefaultLineTolerance, "Lines a comment may be from an expected finding and still match")
+	evalCmd.Flags().StringVar(&resultsFile, "json", "", "Write per-fixture results as JSON to this file")
+	evalCmd.MarkFlagRequired("fixtures")
+
+	return evalCmd
+}
+
+func runEval(cmd *cobra.Command, args []string) error {
+	if ollamaHost == "" {
+		return fmt.Errorf("--ollama-host or OLLAMA_HOST is required")
+	}
+
+	fixtures, err := eval.LoadFixtures(fixturesDir)
+	if err != nil {
+		return err
+	}
+
+	dir := promptDir
+	if dir == "" {
+		dir = os.Getenv("PROMPT_DIR")
+	}
+
+	configs := []eval.Config{evalConfig(evalModel, dir)}
+	if compareModel != "" || comparePromptDir != "" {
+		model, otherDir := evalModel, dir
+		if compareModel != "" {
+			model = compareModel
+		}
+		if comparePromptDir != "" {
+			otherDir = comparePromptDir
+		}
+		configs = append(configs, evalConfig(model, otherDir))
+	}
+
+	var results []*eval.Result
+	for _, cfg := range configs {
+		if GetVerbose() {
+			fmt.Printf("Evaluating %s on %d fixtures...\n", cfg.Name, len(fixtures))
+		}
+		results = append(results, eval.Run(context.Background(), cfg, fixtures))
+	}
+
+	out := cmd.OutOrStdout()
+	for _, result := range results {
+		if err := eval.WriteReport(out, result); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+	}
+	if len(results) == 2 {
+		if err := eval.WriteComparison(out, results[0], results[1]); err != nil {
+			return err
+		}
+	}
+
+	if resultsFile != "" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode results: %w", err)
+		}
+		if err := os.WriteFile(resultsFile, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write results: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// evalConfig creates an evaluation configuration for a model and prompt directory
+func evalConfig(model, dir string) eval.Config {
+	client := llm.NewOllamaClient(ollamaHost, model)
+	service := reviewer.NewService(nil, client)
+	service.SetPrompts(prompt.NewLoader(dir))
+
+	name := model
+	if dir != "" {
+		name += " + " + dir
+	}
+
+	return eval.Config{
+		Name:   name,
+		Client: client,
+		Review: func(ctx context.Context, fixture *eval.Fixture) (*types.ReviewResponse, error) {
+			return service.ReviewLocal(ctx, fixture.Name, fixture.PR, fixture.Files, fixture.Head, fixture.Base)
+		},
+		LineTolerance: lineTolerance,
+	}
+}
@@ -2,12 +2,15 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/config"
-	"github.com/lehigh-university-libraries/mountain-hawk/internal/github"
-	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitea"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitlab"
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/reviewer"
 	"github.com/spf13/cobra"
 )
@@ -22,31 +25,59 @@ var (
 // NewReviewCommand creates the review command
 func NewReviewCommand() *cobra.Command {
 	reviewCmd := &cobra.Command{
-		Use:   "review",
+		Use:   "review [PR URL]",
 		Short: "Review a specific pull request",
-		Long: `Review a specific pull request by providing the repository owner, name, and PR number.
+		Long: `Review a specific pull request by providing its URL, or the repository owner, name, and PR number.
+The URL may point at github.com, the GitHub Enterprise Server configured with GITHUB_API_URL,
+a merge request on the GitLab instance configured with GITLAB_URL, or a pull request on the
+Gitea or Forgejo instance configured with GITEA_URL.
 This will fetch the PR data via MCP, analyze it with AI, and provide structured feedback.`,
 		Example: `  # Review a specific PR
-  mountain-hawk review --owner=microsoft --repo=vscode --pr=123456
-  
-  # Review with verbose output
+  mountain-hawk review https://github.com/microsoft/vscode/pull/123456
+
+  # Review a PR on GitHub Enterprise Server
+  GITHUB_API_URL=https://github.example.edu/api/v3 mountain-hawk review https://github.example.edu/libraries/catalog/pull/42
+
+  # Review a GitLab merge request
+  mountain-hawk review https://gitlab.com/group/project/-/merge_requests/12
+
+  # Review a Forgejo pull request
+  mountain-hawk review https://codeberg.org/owner/project/pulls/7
+
+  # Review with flags and verbose output
   mountain-hawk review --owner=facebook --repo=react --pr=5678 --verbose`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: runReview,
 	}
 
 	// Review command flags
-	reviewCmd.Flags().StringVarP(&owner, "owner", "o", "", "Repository owner (required)")
-	reviewCmd.Flags().StringVarP(&repo, "repo", "r", "", "Repository name (required)")
-	reviewCmd.Flags().IntVarP(&pr, "pr", "n", 0, "Pull request number (required)")
-	reviewCmd.MarkFlagRequired("owner")
-	reviewCmd.MarkFlagRequired("repo")
-	reviewCmd.MarkFlagRequired("pr")
+	reviewCmd.Flags().StringVarP(&owner, "owner", "o", "", "Repository owner")
+	reviewCmd.Flags().StringVarP(&repo, "repo", "r", "", "Repository name")
+	reviewCmd.Flags().IntVarP(&pr, "pr", "n", 0, "Pull request number")
+	reviewCmd.MarkFlagsRequiredTogether("owner", "repo", "pr")
 
 	return reviewCmd
 }
 
 func runReview(cmd *cobra.Command, args []string) error {
 	cfg := config.MustLoad()
+	applyPromptDir(cfg)
+
+	forgeName := forge.GitHub
+	if len(args) == 1 {
+		if owner != "" || repo != "" || pr != 0 {
+			return fmt.Errorf("pass either a PR URL or --owner, --repo and --pr, not both")
+		}
+
+		var err error
+		forgeName, err = parseChangeRequestURL(cfg, args[0])
+		if err != nil {
+			return err
+		}
+	} else if owner == "" || repo == "" || pr == 0 {
+		return fmt.Errorf("a PR URL or --owner, --repo and --pr are required")
+	}
+
 	verbose := GetVerbose()
 	if verbose {
 		fmt.Printf("Reviewing PR #%d in %s/%s...\n", pr, owner, repo)
@@ -54,16 +85,17 @@ func runReview(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize services
-	githubClient := github.NewClient(cfg.GitHubToken)
-	llmClient := llm.NewOllamaClient(cfg.OllamaURL, cfg.OllamaModel)
-	reviewService := reviewer.NewService(githubClient, llmClient)
+	reviewService, forgeClient, err := reviewer.NewServiceFromConfig(cfg, forgeName)
+	if err != nil {
+		return err
+	}
 
 	if verbose {
 		fmt.Println("Fetching PR details...")
 	}
 
 	// Get PR details
-	prData, repository, err := githubClient.GetPullRequest(context.Background(), owner, repo, pr)
+	prData, repository, err := forgeClient.GetChangeRequest(cmd.Context(), owner, repo, pr)
 	if err != nil {
 		return fmt.Errorf("failed to get PR: %w", err)
 	}
@@ -77,10 +109,31 @@ func runReview(cmd *cobra.Command, args []string) error {
 	}
 
 	// Review the PR
-	err = reviewService.ReviewPR(prData, repository)
+	err = reviewService.ReviewPR(cmd.Context(), prData, repository)
 	if err != nil {
 		return fmt.Errorf("failed to review PR: %w", err)
 	}
 
 	return nil
 }
+
+// parseChangeRequestURL sets the owner, repo and number from a pull or merge
+// request URL and returns the forge its host belongs to
+func parseChangeRequestURL(cfg *config.Config, raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid PR URL: %w", err)
+	}
+
+	switch {
+	case cfg.GitLabToken != "" && strings.EqualFold(u.Host, cfg.GitLabHost()):
+		owner, repo, pr, err = gitlab.ParseMergeRequestURL(raw, cfg.GitLabHost())
+		return forge.GitLab, err
+	case cfg.GiteaToken != "" && strings.EqualFold(u.Host, cfg.GiteaHost()):
+		owner, repo, pr, err = gitea.ParsePullRequestURL(raw, cfg.GiteaHost())
+		return forge.Gitea, err
+	default:
+		owner, repo, pr, err = gh.ParsePullRequestURL(raw, cfg.GitHubHost())
+		return forge.GitHub, err
+	}
+}
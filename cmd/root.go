@@ -2,7 +2,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/config"
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/server"
@@ -11,9 +15,10 @@ import (
 
 var (
 	// Global flags
-	daemon  bool
-	port    string
-	verbose bool
+	daemon    bool
+	port      string
+	verbose   bool
+	promptDir string
 )
 
 // NewRootCommand creates the root command
@@ -25,7 +30,7 @@ func NewRootCommand() *cobra.Command {
 to interact with GitHub and leverages AI models to provide intelligent code reviews.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if daemon {
-				return runDaemon()
+				return runDaemon(cmd.Context())
 			}
 			return cmd.Help()
 		},
@@ -35,23 +40,40 @@ to interact with GitHub and leverages AI models to provide intelligent code revi
 	rootCmd.PersistentFlags().BoolVarP(&daemon, "daemon", "d", false, "Run as webhook server daemon")
 	rootCmd.PersistentFlags().StringVarP(&port, "port", "p", "", "Port for daemon mode")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().StringVar(&promptDir, "prompt-dir", "", "Directory of prompt templates overriding the built-in ones")
 
 	rootCmd.AddCommand(NewReviewCommand())
+	rootCmd.AddCommand(NewEvalCommand())
 
 	return rootCmd
 }
 
-func runDaemon() error {
+func runDaemon(ctx context.Context) error {
 	cfg := config.MustLoad()
 
 	// Override port if specified
 	if port != "" {
 		cfg.Port = port
 	}
+	applyPromptDir(cfg)
 
 	fmt.Printf("Starting MCP webhook server on port %s...\n", cfg.Port)
-	srv := server.New(cfg)
-	return srv.ListenAndServe()
+	srv, err := server.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Stop serving and polling for feedback on interrupt
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return srv.ListenAndServe(ctx)
+}
+
+// applyPromptDir overrides the prompt template directory if the flag is set
+func applyPromptDir(cfg *config.Config) {
+	if promptDir != "" {
+		cfg.PromptDir = promptDir
+	}
 }
 
 // GetVerbose returns the verbose flag value for use in subcommands
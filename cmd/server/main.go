@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/config"
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/server"
@@ -10,8 +14,16 @@ import (
 func main() {
 	cfg := config.MustLoad()
 
-	srv := server.New(cfg)
+	srv, err := server.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	log.Printf("Starting server on port %s", cfg.Port)
-	log.Fatal(srv.ListenAndServe())
+	if err := srv.ListenAndServe(ctx); err != nil {
+		log.Fatal(err)
+	}
 }
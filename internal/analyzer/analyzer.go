@@ -0,0 +1,161 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// toolTimeout bounds how long a single analyzer may run
+const toolTimeout = 2 * time.Minute
+
+// ErrToolMissing is returned when an analyzer's executable is not installed
+var ErrToolMissing = errors.New("tool not installed")
+
+// Finding is a diagnostic reported by a static analyzer
+type Finding struct {
+	Tool     string
+	Path     string
+	Line     int
+	Rule     string
+	Message  string
+	Severity types.Severity
+	Type     types.CommentType
+}
+
+// Analyzer runs a static analysis tool against a checked-out repository
+type Analyzer interface {
+	// Name returns the name used to configure the analyzer
+	Name() string
+
+	// Run analyzes the given repository-relative paths under root. Findings
+	// may refer to any line; the runner keeps those on changed lines.
+	Run(ctx context.Context, root string, paths []string) ([]Finding, error)
+}
+
+// Report holds the findings on changed lines and notes about skipped tools
+type Report struct {
+	Findings []Finding
+	Notes    []string
+}
+
+// Runner runs a set of analyzers and filters their findings to changed lines
+type Runner struct {
+	analyzers []Analyzer
+}
+
+// NewRunner creates a runner for the named analyzers
+func NewRunner(names []string) (*Runner, error) {
+	builtin := Builtin()
+
+	var analyzers []Analyzer
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		a, ok := builtin[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown analyzer: %s", name)
+		}
+		analyzers = append(analyzers, a)
+	}
+
+	return &Runner{analyzers: analyzers}, nil
+}
+
+// Run runs every analyzer over the changed files under root. changedLines
+// maps each changed path to the line numbers the pull request adds.
+func (r *Runner) Run(ctx context.Context, root string, changedLines map[string][]int) *Report {
+	report := &Report{}
+
+	paths := make([]string, 0, len(changedLines))
+	changed := make(map[string]map[int]bool, len(changedLines))
+	for path, lines := range changedLines {
+		paths = append(paths, path)
+		changed[path] = make(map[int]bool, len(lines))
+		for _, line := range lines {
+			changed[path][line] = true
+		}
+	}
+	sort.Strings(paths)
+
+	for _, a := range r.analyzers {
+		toolCtx, cancel := context.WithTimeout(ctx, toolTimeout)
+		findings, err := a.Run(toolCtx, root, paths)
+		cancel()
+
+		if errors.Is(err, ErrToolMissing) {
+			report.Notes = append(report.Notes, fmt.Sprintf("%s was skipped because it is not installed", a.Name()))
+			continue
+		}
+		if err != nil {
+			log.Printf("Analyzer %s failed: %v", a.Name(), err)
+			report.Notes = append(report.Notes, fmt.Sprintf("%s failed to run", a.Name()))
+			continue
+		}
+
+		for _, finding := range findings {
+			// File-level findings anchor to the first changed line
+			if finding.Line == 0 && len(changedLines[finding.Path]) > 0 {
+				finding.Line = changedLines[finding.Path][0]
+			}
+			if changed[finding.Path][finding.Line] {
+				report.Findings = append(report.Findings, finding)
+			}
+		}
+	}
+
+	return report
+}
+
+// Context formats the report for inclusion in the LLM context
+func (r *Report) Context() string {
+	if len(r.Findings) == 0 && len(r.Notes) == 0 {
+		return ""
+	}
+
+	var context strings.Builder
+	context.WriteString("Static analysis findings on changed lines:\n")
+	if len(r.Findings) == 0 {
+		context.WriteString("(none)\n")
+	}
+	for _, finding := range r.Findings {
+		context.WriteString(fmt.Sprintf("- %s:%d [%s] %s\n", finding.Path, finding.Line, finding.label(), finding.Message))
+	}
+	for _, note := range r.Notes {
+		context.WriteString(fmt.Sprintf("Note: %s\n", note))
+	}
+	context.WriteString("\n")
+
+	return context.String()
+}
+
+// FileComments converts the findings into review comments posted as-is
+func (r *Report) FileComments() []types.FileComment {
+	comments := make([]types.FileComment, 0, len(r.Findings))
+	for _, finding := range r.Findings {
+		comments = append(comments, types.FileComment{
+			Path:     finding.Path,
+			Line:     finding.Line,
+			Body:     fmt.Sprintf("`%s`: %s", finding.label(), finding.Message),
+			Severity: finding.Severity,
+			Type:     finding.Type,
+		})
+	}
+	return comments
+}
+
+// label returns the tool name with the rule, if any
+func (f Finding) label() string {
+	if f.Rule != "" {
+		return f.Tool + " " + f.Rule
+	}
+	return f.Tool
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fixedAnalyzer returns the same findings or error for every run
+type fixedAnalyzer struct {
+	name     string
+	findings []Finding
+	err      error
+}
+
+func (a *fixedAnalyzer) Name() string {
+	return a.name
+}
+
+func (a *fixedAnalyzer) Run(ctx context.Context, root string, paths []string) ([]Finding, error) {
+	return a.findings, a.err
+}
+
+func TestRunKeepsChangedLines(t *testing.T) {
+	runner := &Runner{analyzers: []Analyzer{
+		&fixedAnalyzer{name: "lint", findings: []Finding{
+			{Tool: "lint", Path: "main.go", Line: 3, Message: "on a changed line"},
+			{Tool: "lint", Path: "main.go", Line: 4, Message: "on an unchanged line"},
+			{Tool: "lint", Path: "other.go", Line: 3, Message: "in an unchanged file"},
+			{Tool: "lint", Path: "main.go", Message: "about the whole file"},
+			{Tool: "lint", Path: "other.go", Message: "about an unchanged file"},
+		}},
+		&fixedAnalyzer{name: "missing", err: ErrToolMissing},
+		&fixedAnalyzer{name: "broken", err: fmt.Errorf("exit status 2")},
+	}}
+
+	report := runner.Run(context.Background(), t.TempDir(), map[string][]int{
+		"main.go": {3, 7},
+		"new.go":  {1},
+	})
+
+	// File-level findings on line 0 anchor to the file's first changed line
+	want := []Finding{
+		{Tool: "lint", Path: "main.go", Line: 3, Message: "on a changed line"},
+		{Tool: "lint", Path: "main.go", Line: 3, Message: "about the whole file"},
+	}
+	if !reflect.DeepEqual(report.Findings, want) {
+		t.Errorf("got findings %+v\nwant %+v", report.Findings, want)
+	}
+
+	wantNotes := []string{"missing was skipped because it is not installed", "broken failed to run"}
+	if !reflect.DeepEqual(report.Notes, wantNotes) {
+		t.Errorf("got notes %q, want %q", report.Notes, wantNotes)
+	}
+}
@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// linePattern matches the "path:line[:column]: message" format most tools emit
+var linePattern = regexp.MustCompile(`^(.+?):(\d+)(?::\d+)?:\s*(.*)$`)
+
+// parseLines parses line-format output, letting classify fill in the rule,
+// severity and type of each finding
+func parseLines(output []byte, tool string, classify func(f *Finding)) []Finding {
+	var findings []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		match := linePattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		line, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+
+		finding := Finding{
+			Tool:    tool,
+			Path:    normalizePath(match[1]),
+			Line:    line,
+			Message: match[3],
+		}
+		classify(&finding)
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// parseGofmt turns the file list printed by gofmt -l into file-level findings
+func parseGofmt(output []byte) []Finding {
+	var findings []Finding
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		findings = append(findings, Finding{
+			Tool:     "gofmt",
+			Path:     normalizePath(line),
+			Message:  "file is not gofmt-formatted",
+			Severity: types.SeverityInfo,
+			Type:     types.TypeStyle,
+		})
+	}
+	return findings
+}
+
+// sarifLog is the subset of a SARIF 2.1 log used to read findings
+type sarifLog struct {
+	Runs []struct {
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Level   string `json:"level"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// parseSARIF parses a SARIF log into findings
+func parseSARIF(output []byte, tool string) []Finding {
+	var log sarifLog
+	if err := json.Unmarshal(output, &log); err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			if len(result.Locations) == 0 {
+				continue
+			}
+			location := result.Locations[0].PhysicalLocation
+
+			finding := Finding{
+				Tool:    tool,
+				Path:    normalizePath(location.ArtifactLocation.URI),
+				Line:    location.Region.StartLine,
+				Rule:    result.RuleID,
+				Message: result.Message.Text,
+			}
+			// SARIF uses "note" where line formats use "info"
+			finding.Severity, finding.Type = levelCategory(result.Level)
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// splitTrailingRule separates a trailing rule ID such as "(SA1000)" or
+// "[SC2086]" from a message
+func splitTrailingRule(message, open, close string) (string, string) {
+	message = strings.TrimSpace(message)
+	if !strings.HasSuffix(message, close) {
+		return message, ""
+	}
+
+	i := strings.LastIndex(message, open)
+	if i < 0 {
+		return message, ""
+	}
+	return strings.TrimSpace(message[:i]), message[i+len(open) : len(message)-len(close)]
+}
+
+// normalizePath converts tool output paths to repository-relative slash paths
+func normalizePath(p string) string {
+	p = strings.TrimPrefix(p, "file://")
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(p)), "./")
+}
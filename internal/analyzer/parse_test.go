@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// parse runs a built-in analyzer's parser over recorded output
+func parse(t *testing.T, name string, output string) []Finding {
+	t.Helper()
+	a, ok := Builtin()[name].(*commandAnalyzer)
+	if !ok {
+		t.Fatalf("no built-in analyzer %s", name)
+	}
+	return a.parse([]byte(output))
+}
+
+func TestParseToolOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		tool   string
+		output string
+		want   []Finding
+	}{
+		{
+			name: "go vet",
+			tool: "govet",
+			output: "# example.com/app/cmd\n" +
+				"cmd/main.go:12:2: fmt.Printf format %d has arg name of wrong type string\n" +
+				"./internal/db.go:40:14: unreachable code\n",
+			want: []Finding{
+				{Tool: "go vet", Path: "cmd/main.go", Line: 12, Message: "fmt.Printf format %d has arg name of wrong type string", Severity: types.SeverityWarning, Type: types.TypeBug},
+				{Tool: "go vet", Path: "internal/db.go", Line: 40, Message: "unreachable code", Severity: types.SeverityWarning, Type: types.TypeBug},
+			},
+		},
+		{
+			name: "staticcheck",
+			tool: "staticcheck",
+			output: "main.go:5:2: this value of err is never used (SA4006)\n" +
+				"main.go:9:6: func unused is unused (U1000)\n" +
+				"main.go:14:2: should use for range instead of for { select {} } (S1000)\n" +
+				"main.go:20:1: comment on exported function Run should be of the form \"Run ...\" (ST1020)\n",
+			want: []Finding{
+				{Tool: "staticcheck", Path: "main.go", Line: 5, Rule: "SA4006", Message: "this value of err is never used", Severity: types.SeverityWarning, Type: types.TypeBug},
+				{Tool: "staticcheck", Path: "main.go", Line: 9, Rule: "U1000", Message: "func unused is unused", Severity: types.SeverityInfo, Type: types.TypeMaintainability},
+				{Tool: "staticcheck", Path: "main.go", Line: 14, Rule: "S1000", Message: "should use for range instead of for { select {} }", Severity: types.SeverityInfo, Type: types.TypeMaintainability},
+				{Tool: "staticcheck", Path: "main.go", Line: 20, Rule: "ST1020", Message: "comment on exported function Run should be of the form \"Run ...\"", Severity: types.SeverityInfo, Type: types.TypeStyle},
+			},
+		},
+		{
+			name: "shellcheck",
+			tool: "shellcheck",
+			output: "scripts/deploy.sh:3:6: warning: Quote this to prevent word splitting. [SC2046]\n" +
+				"scripts/deploy.sh:7:1: note: Not following: ./env.sh was not specified as input (see shellcheck -x). [SC1091]\n" +
+				"scripts/deploy.sh:9:10: error: Couldn't parse this test expression. [SC1073]\n",
+			want: []Finding{
+				{Tool: "shellcheck", Path: "scripts/deploy.sh", Line: 3, Rule: "SC2046", Message: "Quote this to prevent word splitting.", Severity: types.SeverityWarning, Type: types.TypeBug},
+				{Tool: "shellcheck", Path: "scripts/deploy.sh", Line: 7, Rule: "SC1091", Message: "Not following: ./env.sh was not specified as input (see shellcheck -x).", Severity: types.SeverityInfo, Type: types.TypeMaintainability},
+				{Tool: "shellcheck", Path: "scripts/deploy.sh", Line: 9, Rule: "SC1073", Message: "Couldn't parse this test expression.", Severity: types.SeverityError, Type: types.TypeBug},
+			},
+		},
+		{
+			name: "hadolint",
+			tool: "hadolint",
+			output: `{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"Hadolint"}},"results":[` +
+				`{"ruleId":"DL3007","level":"warning","message":{"text":"Using latest is prone to errors"},` +
+				`"locations":[{"physicalLocation":{"artifactLocation":{"uri":"file://./Dockerfile"},"region":{"startLine":1,"startColumn":1}}}]},` +
+				`{"ruleId":"DL3059","level":"note","message":{"text":"Multiple consecutive RUN instructions"},` +
+				`"locations":[{"physicalLocation":{"artifactLocation":{"uri":"build/app.dockerfile"},"region":{"startLine":6}}}]},` +
+				`{"ruleId":"DL1000","level":"error","message":{"text":"unexpected end of input"},"locations":[]}` +
+				`]}]}`,
+			want: []Finding{
+				{Tool: "hadolint", Path: "Dockerfile", Line: 1, Rule: "DL3007", Message: "Using latest is prone to errors", Severity: types.SeverityWarning, Type: types.TypeBug},
+				{Tool: "hadolint", Path: "build/app.dockerfile", Line: 6, Rule: "DL3059", Message: "Multiple consecutive RUN instructions", Severity: types.SeverityInfo, Type: types.TypeMaintainability},
+			},
+		},
+		{
+			name:   "hadolint without SARIF",
+			tool:   "hadolint",
+			output: "hadolint: Dockerfile: openBinaryFile: does not exist\n",
+		},
+		{
+			name:   "gofmt",
+			tool:   "gofmt",
+			output: "cmd/main.go\n./internal/db.go\n\n",
+			want: []Finding{
+				{Tool: "gofmt", Path: "cmd/main.go", Message: "file is not gofmt-formatted", Severity: types.SeverityInfo, Type: types.TypeStyle},
+				{Tool: "gofmt", Path: "internal/db.go", Message: "file is not gofmt-formatted", Severity: types.SeverityInfo, Type: types.TypeStyle},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parse(t, tt.tool, tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTrailingRule(t *testing.T) {
+	tests := []struct {
+		message     string
+		open, close string
+		want        string
+		wantRule    string
+	}{
+		{"this value of err is never used (SA4006)", "(", ")", "this value of err is never used", "SA4006"},
+		{"Double quote to prevent globbing. [SC2086] ", "[", "]", "Double quote to prevent globbing.", "SC2086"},
+		{"call (f) twice (SA5000)", "(", ")", "call (f) twice", "SA5000"},
+		{"no rule here", "(", ")", "no rule here", ""},
+		{"unbalanced)", "(", ")", "unbalanced)", ""},
+	}
+
+	for _, tt := range tests {
+		message, rule := splitTrailingRule(tt.message, tt.open, tt.close)
+		if message != tt.want || rule != tt.wantRule {
+			t.Errorf("splitTrailingRule(%q) = %q, %q, want %q, %q", tt.message, message, rule, tt.want, tt.wantRule)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// commandAnalyzer runs an external tool and parses its output
+type commandAnalyzer struct {
+	name    string
+	command string
+
+	// match selects the changed paths the tool analyzes
+	match func(path string) bool
+	// args builds the tool's arguments from the matched paths
+	args func(paths []string) []string
+	// parse converts the tool's combined output into findings
+	parse func(output []byte) []Finding
+}
+
+// Builtin returns the built-in analyzers keyed by name
+func Builtin() map[string]Analyzer {
+	analyzers := []*commandAnalyzer{
+		{
+			name:    "govet",
+			command: "go",
+			match:   isGoFile,
+			args: func(paths []string) []string {
+				return append([]string{"vet"}, packageDirs(paths)...)
+			},
+			parse: func(output []byte) []Finding {
+				return parseLines(output, "go vet", func(f *Finding) {
+					f.Severity = types.SeverityWarning
+					f.Type = types.TypeBug
+				})
+			},
+		},
+		{
+			name:    "staticcheck",
+			command: "staticcheck",
+			match:   isGoFile,
+			args: func(paths []string) []string {
+				return append([]string{"-f", "text"}, packageDirs(paths)...)
+			},
+			parse: func(output []byte) []Finding {
+				return parseLines(output, "staticcheck", func(f *Finding) {
+					f.Message, f.Rule = splitTrailingRule(f.Message, "(", ")")
+					f.Severity, f.Type = staticcheckCategory(f.Rule)
+				})
+			},
+		},
+		{
+			name:    "gofmt",
+			command: "gofmt",
+			match:   isGoFile,
+			args: func(paths []string) []string {
+				return append([]string{"-l"}, paths...)
+			},
+			parse: parseGofmt,
+		},
+		{
+			name:    "shellcheck",
+			command: "shellcheck",
+			match: func(p string) bool {
+				return strings.HasSuffix(p, ".sh") || strings.HasSuffix(p, ".bash")
+			},
+			args: func(paths []string) []string {
+				return append([]string{"-f", "gcc"}, paths...)
+			},
+			parse: func(output []byte) []Finding {
+				return parseLines(output, "shellcheck", func(f *Finding) {
+					level, message, _ := strings.Cut(f.Message, ": ")
+					f.Message, f.Rule = splitTrailingRule(message, "[", "]")
+					f.Severity, f.Type = levelCategory(level)
+				})
+			},
+		},
+		{
+			name:    "hadolint",
+			command: "hadolint",
+			match: func(p string) bool {
+				base := strings.ToLower(path.Base(p))
+				return strings.HasPrefix(base, "dockerfile") || strings.HasSuffix(base, ".dockerfile")
+			},
+			args: func(paths []string) []string {
+				return append([]string{"--no-fail", "-f", "sarif"}, paths...)
+			},
+			parse: func(output []byte) []Finding {
+				return parseSARIF(output, "hadolint")
+			},
+		},
+	}
+
+	builtin := make(map[string]Analyzer, len(analyzers))
+	for _, a := range analyzers {
+		builtin[a.name] = a
+	}
+	return builtin
+}
+
+// Name returns the name used to configure the analyzer
+func (a *commandAnalyzer) Name() string {
+	return a.name
+}
+
+// Run runs the tool in root over the changed paths it applies to
+func (a *commandAnalyzer) Run(ctx context.Context, root string, paths []string) ([]Finding, error) {
+	var targets []string
+	for _, p := range paths {
+		if a.match(p) {
+			targets = append(targets, p)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	bin, err := exec.LookPath(a.command)
+	if err != nil {
+		return nil, ErrToolMissing
+	}
+
+	cmd := exec.CommandContext(ctx, bin, a.args(targets)...)
+	cmd.Dir = root
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// Linters exit non-zero when they report findings
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+
+	return a.parse(output), nil
+}
+
+// isGoFile reports whether a path is a Go source file
+func isGoFile(p string) bool {
+	return strings.HasSuffix(p, ".go")
+}
+
+// packageDirs returns the unique package patterns for a set of Go files
+func packageDirs(paths []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, p := range paths {
+		dir := "./" + path.Dir(p)
+		if path.Dir(p) == "." {
+			dir = "."
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// staticcheckCategory maps a staticcheck check ID to a severity and comment
+// type. SA checks find bugs and ST checks style; simplifications (S),
+// quick fixes (QF) and unused code (U) are maintainability.
+func staticcheckCategory(rule string) (types.Severity, types.CommentType) {
+	switch {
+	case strings.HasPrefix(rule, "SA"):
+		return types.SeverityWarning, types.TypeBug
+	case strings.HasPrefix(rule, "ST"):
+		return types.SeverityInfo, types.TypeStyle
+	default:
+		return types.SeverityInfo, types.TypeMaintainability
+	}
+}
+
+// levelCategory maps a linter level to a severity and comment type
+func levelCategory(level string) (types.Severity, types.CommentType) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "error":
+		return types.SeverityError, types.TypeBug
+	case "warning":
+		return types.SeverityWarning, types.TypeBug
+	case "style":
+		return types.SeverityInfo, types.TypeStyle
+	default:
+		return types.SeverityInfo, types.TypeMaintainability
+	}
+}
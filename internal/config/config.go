@@ -3,7 +3,11 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration
@@ -15,23 +19,178 @@ type Config struct {
 	GitHubToken   string
 	WebhookSecret string
 
+	// GitHub Enterprise Server configuration
+	GitHubAPIURL    string
+	GitHubUploadURL string
+	GitHubCABundle  string
+
+	// GitLab configuration
+	GitLabURL           string
+	GitLabToken         string
+	GitLabWebhookSecret string
+
+	// Gitea and Forgejo configuration
+	GiteaURL           string
+	GiteaToken         string
+	GiteaWebhookSecret string
+
 	// LLM configuration
 	OllamaURL   string
 	OllamaModel string
+
+	// Blob cache configuration
+	BlobCacheDir   string
+	BlobCacheBytes int
+
+	// Repository snapshot configuration
+	SnapshotMode       string
+	SnapshotDir        string
+	SnapshotMaxBytes   int64
+	SnapshotMaxEntries int
+
+	// Static analyzer configuration
+	Analyzers            []string
+	PostAnalyzerFindings bool
+
+	// Redaction configuration
+	RedactPatterns    []string
+	RedactionAuditLog string
+
+	// ReviewTimeout is the deadline of a single review
+	ReviewTimeout time.Duration
+
+	// Prompt template override directory
+	PromptDir string
+
+	// Feedback directory, and how often reactions on findings are polled
+	FeedbackDir          string
+	FeedbackPollInterval time.Duration
+
+	// LLM record/replay directories
+	LLMRecordDir string
+	LLMReplayDir string
 }
 
+// Snapshot modes
+const (
+	SnapshotOff     = "off"
+	SnapshotTarball = "tarball"
+)
+
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
 	cfg := &Config{
 		Port:        getEnvOrDefault("PORT", "8080"),
 		OllamaModel: getEnvOrDefault("OLLAMA_MODEL", "gpt-oss:20b"),
+
+		GitHubAPIURL:    os.Getenv("GITHUB_API_URL"),
+		GitHubUploadURL: os.Getenv("GITHUB_UPLOAD_URL"),
+		GitHubCABundle:  os.Getenv("GITHUB_CA_BUNDLE"),
+
+		GitLabURL:           getEnvOrDefault("GITLAB_URL", "https://gitlab.com"),
+		GitLabToken:         os.Getenv("GITLAB_TOKEN"),
+		GitLabWebhookSecret: os.Getenv("GITLAB_WEBHOOK_SECRET"),
+
+		GiteaURL:           os.Getenv("GITEA_URL"),
+		GiteaToken:         os.Getenv("GITEA_TOKEN"),
+		GiteaWebhookSecret: os.Getenv("GITEA_WEBHOOK_SECRET"),
+
+		BlobCacheDir: os.Getenv("BLOB_CACHE_DIR"),
+
+		SnapshotMode: getEnvOrDefault("SNAPSHOT_MODE", SnapshotOff),
+		SnapshotDir:  os.Getenv("SNAPSHOT_DIR"),
+
+		RedactionAuditLog: os.Getenv("REDACTION_AUDIT_LOG"),
+
+		PromptDir: os.Getenv("PROMPT_DIR"),
+
+		FeedbackDir: os.Getenv("FEEDBACK_DIR"),
+
+		LLMRecordDir: os.Getenv("LLM_RECORD_DIR"),
+		LLMReplayDir: os.Getenv("LLM_REPLAY_DIR"),
+	}
+
+	if analyzers := os.Getenv("ANALYZERS"); analyzers != "" {
+		cfg.Analyzers = strings.Split(analyzers, ",")
+	}
+
+	switch patterns := getEnvOrDefault("REDACT_PATTERNS", "email,ipv4,ipv6,jwt,bearer"); patterns {
+	case "none":
+	default:
+		cfg.RedactPatterns = strings.Split(patterns, ",")
+	}
+
+	switch cfg.SnapshotMode {
+	case SnapshotOff, SnapshotTarball:
+	default:
+		return nil, fmt.Errorf("invalid SNAPSHOT_MODE: %s", cfg.SnapshotMode)
+	}
+
+	cacheMB, err := getEnvIntOrDefault("BLOB_CACHE_MB", 256)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BlobCacheBytes = cacheMB << 20
+
+	snapshotMB, err := getEnvIntOrDefault("SNAPSHOT_MAX_MB", 512)
+	if err != nil {
+		return nil, err
+	}
+	cfg.SnapshotMaxBytes = int64(snapshotMB) << 20
+
+	cfg.SnapshotMaxEntries, err = getEnvIntOrDefault("SNAPSHOT_CACHE_ENTRIES", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.PostAnalyzerFindings, err = getEnvBoolOrDefault("ANALYZERS_POST_FINDINGS", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ReviewTimeout, err = getEnvDurationOrDefault("REVIEW_TIMEOUT", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.FeedbackPollInterval, err = getEnvDurationOrDefault("FEEDBACK_POLL_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.GitHubAPIURL != "" {
+		if u, err := url.Parse(cfg.GitHubAPIURL); err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid GITHUB_API_URL: %s", cfg.GitHubAPIURL)
+		}
+	}
+
+	if cfg.LLMRecordDir != "" && cfg.LLMReplayDir != "" {
+		return nil, fmt.Errorf("LLM_RECORD_DIR and LLM_REPLAY_DIR cannot both be set")
+	}
+
+	if u, err := url.Parse(cfg.GitLabURL); err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid GITLAB_URL: %s", cfg.GitLabURL)
+	}
+
+	// Required environment variables. GitHub is optional once another forge
+	// is configured.
+	required := map[string]*string{}
+	if (cfg.GitLabToken == "" && cfg.GiteaToken == "") || os.Getenv("GITHUB_TOKEN") != "" {
+		required["GITHUB_TOKEN"] = &cfg.GitHubToken
+		required["WEBHOOK_SECRET"] = &cfg.WebhookSecret
+	}
+	if cfg.GitLabToken != "" {
+		required["GITLAB_WEBHOOK_SECRET"] = &cfg.GitLabWebhookSecret
+	}
+	if cfg.GiteaToken != "" {
+		required["GITEA_URL"] = &cfg.GiteaURL
+		required["GITEA_WEBHOOK_SECRET"] = &cfg.GiteaWebhookSecret
 	}
 
-	// Required environment variables
-	required := map[string]*string{
-		"GITHUB_TOKEN":   &cfg.GitHubToken,
-		"OLLAMA_HOST":    &cfg.OllamaURL,
-		"WEBHOOK_SECRET": &cfg.WebhookSecret,
+	// Replayed reviews do not need a model
+	cfg.OllamaURL = os.Getenv("OLLAMA_HOST")
+	if cfg.LLMReplayDir == "" {
+		required["OLLAMA_HOST"] = &cfg.OllamaURL
 	}
 
 	var missing []string
@@ -51,6 +210,41 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// GitHubHost returns the host pull request URLs are served from: github.com,
+// or the GitHub Enterprise Server host when GITHUB_API_URL is set
+func (c *Config) GitHubHost() string {
+	if c.GitHubAPIURL == "" {
+		return "github.com"
+	}
+
+	u, err := url.Parse(c.GitHubAPIURL)
+	if err != nil {
+		return "github.com"
+	}
+	if u.Hostname() == "api.github.com" {
+		return "github.com"
+	}
+	return u.Host
+}
+
+// GitLabHost returns the host GitLab merge request URLs are served from
+func (c *Config) GitLabHost() string {
+	u, err := url.Parse(c.GitLabURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// GiteaHost returns the host Gitea pull request URLs are served from
+func (c *Config) GiteaHost() string {
+	u, err := url.Parse(c.GiteaURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
 // MustLoad loads configuration and panics on error
 func MustLoad() *Config {
 	cfg, err := Load()
@@ -67,3 +261,45 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvIntOrDefault returns environment variable value as an integer or default if not set
+func getEnvIntOrDefault(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvBoolOrDefault returns environment variable value as a boolean or default if not set
+func getEnvBoolOrDefault(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// getEnvDurationOrDefault returns environment variable value as a duration or default if not set
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,228 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// DefaultLineTolerance is how many lines a comment may be from an expected
+// finding and still match it
+const DefaultLineTolerance = 3
+
+// allTypes is the row reporting totals across comment types
+const allTypes = "all"
+
+// Reviewer produces a review of a fixture
+type Reviewer func(ctx context.Context, fixture *Fixture) (*types.ReviewResponse, error)
+
+// Config is one model and prompt configuration under evaluation
+type Config struct {
+	Name   string
+	Client llm.Client
+	Review Reviewer
+
+	// LineTolerance is the allowed distance between matching lines
+	LineTolerance int
+}
+
+// TypeScore counts expected and predicted findings of one comment type
+type TypeScore struct {
+	Expected  int `json:"expected"`
+	Predicted int `json:"predicted"`
+	Matched   int `json:"matched"`
+}
+
+// Precision returns the share of predicted findings that were expected
+func (s TypeScore) Precision() float64 {
+	return ratio(s.Matched, s.Predicted)
+}
+
+// Recall returns the share of expected findings that were predicted
+func (s TypeScore) Recall() float64 {
+	return ratio(s.Matched, s.Expected)
+}
+
+// CaseResult is the outcome of reviewing one fixture
+type CaseResult struct {
+	Name         string               `json:"name"`
+	Error        string               `json:"error,omitempty"`
+	InvalidJSON  bool                 `json:"invalid_json,omitempty"`
+	Latency      time.Duration        `json:"latency"`
+	InputTokens  int                  `json:"input_tokens"`
+	OutputTokens int                  `json:"output_tokens"`
+	Scores       map[string]TypeScore `json:"scores"`
+	ExactLines   int                  `json:"exact_lines"`
+}
+
+// Result aggregates the outcomes of every fixture for one configuration
+type Result struct {
+	Config string       `json:"config"`
+	Cases  []CaseResult `json:"cases"`
+}
+
+// Run reviews every fixture with a configuration and scores the reviews
+func Run(ctx context.Context, cfg Config, fixtures []*Fixture) *Result {
+	usage, _ := cfg.Client.(llm.UsageReporter)
+	result := &Result{Config: cfg.Name}
+	for _, fixture := range fixtures {
+		var before llm.Usage
+		if usage != nil {
+			before = usage.Usage()
+		}
+
+		start := time.Now()
+		review, err := cfg.Review(ctx, fixture)
+		c := CaseResult{Name: fixture.Name, Latency: time.Since(start)}
+
+		if usage != nil {
+			after := usage.Usage()
+			c.InputTokens = after.InputTokens - before.InputTokens
+			c.OutputTokens = after.OutputTokens - before.OutputTokens
+		}
+
+		if err != nil {
+			log.Printf("Fixture %s failed: %v", fixture.Name, err)
+			c.Error = err.Error()
+			c.InvalidJSON = errors.Is(err, llm.ErrInvalidResponse)
+			review = &types.ReviewResponse{}
+		}
+		c.Scores, c.ExactLines = score(fixture.Expected, review.FileComments, cfg.LineTolerance)
+
+		result.Cases = append(result.Cases, c)
+	}
+	return result
+}
+
+// score matches review comments to expected findings of the same path and
+// type within tolerance lines, closest first
+func score(expected []Expected, comments []types.FileComment, tolerance int) (map[string]TypeScore, int) {
+	scores := make(map[string]TypeScore)
+	add := func(commentType types.CommentType, update func(*TypeScore)) {
+		for _, key := range []string{string(commentType), allTypes} {
+			s := scores[key]
+			update(&s)
+			scores[key] = s
+		}
+	}
+
+	for _, e := range expected {
+		add(e.Type, func(s *TypeScore) { s.Expected++ })
+	}
+	for _, comment := range comments {
+		add(comment.Type, func(s *TypeScore) { s.Predicted++ })
+	}
+
+	// Candidate pairs, matched greedily from the closest
+	type pair struct{ e, c, distance int }
+	var pairs []pair
+	for i, e := range expected {
+		for j, comment := range comments {
+			if comment.Path != e.Path || comment.Type != e.Type {
+				continue
+			}
+			if d := abs(comment.Line - e.Line); d <= tolerance {
+				pairs = append(pairs, pair{i, j, d})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].distance < pairs[j].distance })
+
+	exact := 0
+	usedExpected := make(map[int]bool)
+	usedComment := make(map[int]bool)
+	for _, p := range pairs {
+		if usedExpected[p.e] || usedComment[p.c] {
+			continue
+		}
+		usedExpected[p.e] = true
+		usedComment[p.c] = true
+		add(expected[p.e].Type, func(s *TypeScore) { s.Matched++ })
+		if p.distance == 0 {
+			exact++
+		}
+	}
+
+	return scores, exact
+}
+
+// Totals sums the scores of every case by comment type
+func (r *Result) Totals() map[string]TypeScore {
+	totals := make(map[string]TypeScore)
+	for _, c := range r.Cases {
+		for key, s := range c.Scores {
+			total := totals[key]
+			total.Expected += s.Expected
+			total.Predicted += s.Predicted
+			total.Matched += s.Matched
+			totals[key] = total
+		}
+	}
+	return totals
+}
+
+// Summary holds the headline metrics of a result
+type Summary struct {
+	Cases        int
+	Errors       int
+	ValidJSON    float64
+	LineAccuracy float64
+	MeanLatency  time.Duration
+	MaxLatency   time.Duration
+	InputTokens  int
+	OutputTokens int
+}
+
+// Summarize computes the headline metrics of a result. JSON validity is the
+// share of model responses that parsed; other errors are counted separately.
+func (r *Result) Summarize() Summary {
+	s := Summary{Cases: len(r.Cases)}
+
+	invalid, exact := 0, 0
+	var latency time.Duration
+	for _, c := range r.Cases {
+		latency += c.Latency
+		if c.Latency > s.MaxLatency {
+			s.MaxLatency = c.Latency
+		}
+		s.InputTokens += c.InputTokens
+		s.OutputTokens += c.OutputTokens
+		exact += c.ExactLines
+
+		switch {
+		case c.InvalidJSON:
+			invalid++
+		case c.Error != "":
+			s.Errors++
+		}
+	}
+
+	responses := len(r.Cases) - s.Errors
+	s.ValidJSON = ratio(responses-invalid, responses)
+	if len(r.Cases) > 0 {
+		s.MeanLatency = latency / time.Duration(len(r.Cases))
+	}
+	s.LineAccuracy = ratio(exact, r.Totals()[allTypes].Matched)
+	return s
+}
+
+// ratio returns n/d, or zero when d is zero
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
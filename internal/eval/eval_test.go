@@ -0,0 +1,116 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+func TestScore(t *testing.T) {
+	expected := []Expected{
+		{Path: "main.go", Line: 10, Type: types.TypeBug},
+		{Path: "main.go", Line: 20, Type: types.TypeBug},
+		{Path: "db.go", Line: 5, Type: types.TypeSecurity},
+	}
+
+	tests := []struct {
+		name     string
+		comments []types.FileComment
+		scores   map[string]TypeScore
+		exact    int
+	}{
+		{
+			name: "no comments",
+			scores: map[string]TypeScore{
+				"bug":      {Expected: 2},
+				"security": {Expected: 1},
+				allTypes:   {Expected: 3},
+			},
+		},
+		{
+			name: "exact and near matches",
+			comments: []types.FileComment{
+				{Path: "main.go", Line: 10, Type: types.TypeBug},
+				{Path: "main.go", Line: 22, Type: types.TypeBug},
+				{Path: "db.go", Line: 5, Type: types.TypeSecurity},
+			},
+			scores: map[string]TypeScore{
+				"bug":      {Expected: 2, Predicted: 2, Matched: 2},
+				"security": {Expected: 1, Predicted: 1, Matched: 1},
+				allTypes:   {Expected: 3, Predicted: 3, Matched: 3},
+			},
+			exact: 2,
+		},
+		{
+			name: "wrong type, path or distance",
+			comments: []types.FileComment{
+				{Path: "main.go", Line: 10, Type: types.TypeStyle},
+				{Path: "db.go", Line: 10, Type: types.TypeBug},
+				{Path: "main.go", Line: 24, Type: types.TypeBug},
+			},
+			scores: map[string]TypeScore{
+				"bug":      {Expected: 2, Predicted: 2},
+				"security": {Expected: 1},
+				"style":    {Predicted: 1},
+				allTypes:   {Expected: 3, Predicted: 3},
+			},
+		},
+		{
+			name: "closest comment wins a contested finding",
+			comments: []types.FileComment{
+				{Path: "main.go", Line: 12, Type: types.TypeBug},
+				{Path: "main.go", Line: 11, Type: types.TypeBug},
+			},
+			scores: map[string]TypeScore{
+				"bug":      {Expected: 2, Predicted: 2, Matched: 1},
+				"security": {Expected: 1},
+				allTypes:   {Expected: 3, Predicted: 2, Matched: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores, exact := score(expected, tt.comments, DefaultLineTolerance)
+			if !reflect.DeepEqual(scores, tt.scores) {
+				t.Errorf("got scores %+v, want %+v", scores, tt.scores)
+			}
+			if exact != tt.exact {
+				t.Errorf("got %d exact lines, want %d", exact, tt.exact)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	result := &Result{Cases: []CaseResult{
+		{
+			Latency:     2 * time.Second,
+			InputTokens: 100, OutputTokens: 10,
+			Scores:     map[string]TypeScore{allTypes: {Expected: 2, Predicted: 2, Matched: 2}},
+			ExactLines: 1,
+		},
+		{Latency: 4 * time.Second, InvalidJSON: true, Error: "invalid model response"},
+		{Latency: 6 * time.Second, Error: "connection refused"},
+	}}
+
+	want := Summary{
+		Cases:        3,
+		Errors:       1,
+		ValidJSON:    0.5,
+		LineAccuracy: 0.5,
+		MeanLatency:  4 * time.Second,
+		MaxLatency:   6 * time.Second,
+		InputTokens:  100,
+		OutputTokens: 10,
+	}
+	if got := result.Summarize(); got != want {
+		t.Errorf("got summary %+v, want %+v", got, want)
+	}
+
+	if got := (&Result{}).Summarize(); got != (Summary{}) {
+		t.Errorf("got summary %+v for no cases", got)
+	}
+}
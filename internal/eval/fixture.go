@@ -0,0 +1,145 @@
+package eval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/go-github/v74/github"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/workspace"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// Files making up a fixture directory
+const (
+	diffFile     = "diff.patch"
+	expectedFile = "expected.json"
+	prFile       = "pr.json"
+	headDir      = "head"
+	baseDir      = "base"
+)
+
+// Expected is a finding a good review of the fixture should report
+type Expected struct {
+	Path        string            `json:"path"`
+	Line        int               `json:"line"`
+	Type        types.CommentType `json:"type"`
+	Description string            `json:"description,omitempty"`
+}
+
+// prMetadata is the optional pull request description in pr.json
+type prMetadata struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	BaseBranch  string `json:"base_branch"`
+	HeadBranch  string `json:"head_branch"`
+	Draft       bool   `json:"draft"`
+}
+
+// Fixture is a labeled pull request stored on disk
+type Fixture struct {
+	Name     string
+	PR       *github.PullRequest
+	Files    []*github.CommitFile
+	Expected []Expected
+
+	// Head and Base hold the file contents after and before the change
+	Head *workspace.Snapshot
+	Base *workspace.Snapshot
+}
+
+// LoadFixtures loads every fixture in the subdirectories of dir, sorted by name
+func LoadFixtures(dir string) ([]*Fixture, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var fixtures []*Fixture
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		fixture, err := LoadFixture(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+
+	sort.Slice(fixtures, func(i, j int) bool { return fixtures[i].Name < fixtures[j].Name })
+	return fixtures, nil
+}
+
+// LoadFixture loads a single fixture directory
+func LoadFixture(dir string) (*Fixture, error) {
+	name := filepath.Base(dir)
+
+	diff, err := os.ReadFile(filepath.Join(dir, diffFile))
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: failed to read %s: %w", name, diffFile, err)
+	}
+	files, err := gh.ParseUnifiedDiff(string(diff))
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", name, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("fixture %s: %s contains no files", name, diffFile)
+	}
+
+	var expected []Expected
+	if err := readJSON(filepath.Join(dir, expectedFile), &expected); err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", name, err)
+	}
+
+	meta := prMetadata{Title: name, BaseBranch: "main", HeadBranch: name}
+	if err := readJSON(filepath.Join(dir, prFile), &meta); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("fixture %s: %w", name, err)
+	}
+
+	additions, deletions := 0, 0
+	for _, file := range files {
+		additions += file.GetAdditions()
+		deletions += file.GetDeletions()
+	}
+
+	return &Fixture{
+		Name: name,
+		PR: &github.PullRequest{
+			Title:        github.Ptr(meta.Title),
+			Body:         github.Ptr(meta.Description),
+			User:         &github.User{Login: github.Ptr(meta.Author)},
+			Base:         &github.PullRequestBranch{Ref: github.Ptr(meta.BaseBranch), SHA: github.Ptr("base")},
+			Head:         &github.PullRequestBranch{Ref: github.Ptr(meta.HeadBranch), SHA: github.Ptr("head")},
+			Draft:        github.Ptr(meta.Draft),
+			Additions:    github.Ptr(additions),
+			Deletions:    github.Ptr(deletions),
+			ChangedFiles: github.Ptr(len(files)),
+		},
+		Files:    files,
+		Expected: expected,
+		Head:     &workspace.Snapshot{Owner: "fixture", Repo: name, Ref: "head", Root: filepath.Join(dir, headDir)},
+		Base:     &workspace.Snapshot{Owner: "fixture", Repo: name, Ref: "base", Root: filepath.Join(dir, baseDir)},
+	}, nil
+}
+
+// readJSON decodes a JSON file into v
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteReport writes a text report of a single configuration's results
+func WriteReport(w io.Writer, r *Result) error {
+	s := r.Summarize()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Configuration:\t%s\n", r.Config)
+	fmt.Fprintf(tw, "Fixtures:\t%d (%d errors)\n", s.Cases, s.Errors)
+	fmt.Fprintf(tw, "JSON validity:\t%s\n", percent(s.ValidJSON))
+	fmt.Fprintf(tw, "Line accuracy:\t%s\n", percent(s.LineAccuracy))
+	fmt.Fprintf(tw, "Latency:\tmean %s, max %s\n", s.MeanLatency.Round(time.Millisecond), s.MaxLatency.Round(time.Millisecond))
+	fmt.Fprintf(tw, "Tokens:\t%d in, %d out\n", s.InputTokens, s.OutputTokens)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "TYPE\tEXPECTED\tPREDICTED\tMATCHED\tPRECISION\tRECALL")
+	totals := r.Totals()
+	for _, key := range typeKeys(totals) {
+		t := totals[key]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\n", key, t.Expected, t.Predicted, t.Matched, percent(t.Precision()), percent(t.Recall()))
+	}
+	return tw.Flush()
+}
+
+// WriteComparison writes two configurations' headline metrics side by side
+func WriteComparison(w io.Writer, a, b *Result) error {
+	sa, sb := a.Summarize(), b.Summarize()
+	ta, tb := a.Totals(), b.Totals()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "METRIC\t%s\t%s\n", a.Config, b.Config)
+	fmt.Fprintf(tw, "errors\t%d\t%d\n", sa.Errors, sb.Errors)
+	fmt.Fprintf(tw, "JSON validity\t%s\t%s\n", percent(sa.ValidJSON), percent(sb.ValidJSON))
+	fmt.Fprintf(tw, "line accuracy\t%s\t%s\n", percent(sa.LineAccuracy), percent(sb.LineAccuracy))
+	fmt.Fprintf(tw, "mean latency\t%s\t%s\n", sa.MeanLatency.Round(time.Millisecond), sb.MeanLatency.Round(time.Millisecond))
+	fmt.Fprintf(tw, "input tokens\t%d\t%d\n", sa.InputTokens, sb.InputTokens)
+	fmt.Fprintf(tw, "output tokens\t%d\t%d\n", sa.OutputTokens, sb.OutputTokens)
+
+	union := make(map[string]TypeScore, len(ta))
+	for key := range ta {
+		union[key] = TypeScore{}
+	}
+	for key := range tb {
+		union[key] = TypeScore{}
+	}
+	for _, key := range typeKeys(union) {
+		fmt.Fprintf(tw, "%s precision\t%s\t%s\n", key, percent(ta[key].Precision()), percent(tb[key].Precision()))
+		fmt.Fprintf(tw, "%s recall\t%s\t%s\n", key, percent(ta[key].Recall()), percent(tb[key].Recall()))
+	}
+	return tw.Flush()
+}
+
+// typeKeys returns the comment types in a score map sorted, with the total last
+func typeKeys(scores map[string]TypeScore) []string {
+	keys := make([]string, 0, len(scores))
+	for key := range scores {
+		if key != allTypes {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if _, ok := scores[allTypes]; ok {
+		keys = append(keys, allTypes)
+	}
+	return keys
+}
+
+// percent formats a ratio as a percentage
+func percent(r float64) string {
+	return fmt.Sprintf("%.1f%%", r*100)
+}
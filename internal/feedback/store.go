@@ -0,0 +1,312 @@
+// Package feedback records how engineers responded to the findings the
+// reviewer posted, so later reviews of a repository can leave out findings
+// it rejected and show the model examples of feedback it found useful.
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// Verdict is how engineers responded to a finding
+type Verdict string
+
+// Verdicts
+const (
+	Accepted Verdict = "accepted"
+	Rejected Verdict = "rejected"
+)
+
+// Finding is a posted finding and the verdict it received
+type Finding struct {
+	Fingerprint string            `json:"fingerprint"`
+	Path        string            `json:"path"`
+	Type        types.CommentType `json:"type"`
+	Body        string            `json:"body"`
+	Verdict     Verdict           `json:"verdict,omitempty"`
+	Posted      time.Time         `json:"posted"`
+	Updated     time.Time         `json:"updated,omitempty"`
+}
+
+// PullRequest is a reviewed change request whose findings can still get
+// feedback
+type PullRequest struct {
+	Owner    string
+	Repo     string
+	Number   int
+	Reviewed time.Time
+}
+
+// repository is the feedback stored for one repository
+type repository struct {
+	Owner    string              `json:"owner"`
+	Repo     string              `json:"repo"`
+	Findings map[string]*Finding `json:"findings"`
+	Pulls    map[int]time.Time   `json:"pulls"`
+}
+
+// Store keeps the feedback of each repository in a JSON file under a
+// directory. With an empty directory, feedback is kept in memory only.
+type Store struct {
+	mu    sync.Mutex
+	dir   string
+	repos map[string]*repository
+}
+
+// NewStore creates a store saving to dir
+func NewStore(dir string) (*Store, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create feedback directory: %w", err)
+		}
+	}
+	return &Store{dir: dir, repos: make(map[string]*repository)}, nil
+}
+
+// RecordPosted stores the fingerprinted comments posted on a change request,
+// keeping the verdicts of findings posted before
+func (s *Store) RecordPosted(owner, repo string, number int, comments []types.FileComment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.load(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	r.Pulls[number] = now
+	for _, comment := range comments {
+		if comment.Fingerprint == "" {
+			continue
+		}
+		if finding, ok := r.Findings[comment.Fingerprint]; ok {
+			finding.Body = comment.Body
+			continue
+		}
+		r.Findings[comment.Fingerprint] = &Finding{
+			Fingerprint: comment.Fingerprint,
+			Path:        comment.Path,
+			Type:        comment.Type,
+			Body:        comment.Body,
+			Posted:      now,
+		}
+	}
+	return s.save(r)
+}
+
+// SetVerdict records the verdict on a posted finding. Rejections take
+// precedence: accepting a rejected finding has no effect, and an empty
+// verdict only withdraws an acceptance. Fingerprints the store didn't record
+// are ignored, so markers copied into other comments can't add findings.
+func (s *Store) SetVerdict(owner, repo, fingerprint string, verdict Verdict) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.load(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	finding, ok := r.Findings[fingerprint]
+	if !ok || finding.Verdict == verdict {
+		return nil
+	}
+	if finding.Verdict == Rejected && verdict != Rejected {
+		return nil
+	}
+	finding.Verdict = verdict
+	finding.Updated = time.Now().UTC()
+	return s.save(r)
+}
+
+// Suppresses reports whether engineers rejected a finding like the comment:
+// one with the same fingerprint, or of the same type with the same text
+func (s *Store) Suppresses(owner, repo string, comment types.FileComment) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.load(owner, repo)
+	if err != nil {
+		return false
+	}
+
+	if finding, ok := r.Findings[comment.Fingerprint]; ok && finding.Verdict == Rejected {
+		return true
+	}
+	body := normalize(comment.Body)
+	for _, finding := range r.Findings {
+		if finding.Verdict == Rejected && finding.Type == comment.Type && normalize(finding.Body) == body {
+			return true
+		}
+	}
+	return false
+}
+
+// Examples returns up to limit of the most recently accepted and rejected
+// findings in a repository
+func (s *Store) Examples(owner, repo string, limit int) (accepted, rejected []Finding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.load(owner, repo)
+	if err != nil {
+		return nil, nil
+	}
+
+	for _, finding := range r.Findings {
+		switch finding.Verdict {
+		case Accepted:
+			accepted = append(accepted, *finding)
+		case Rejected:
+			rejected = append(rejected, *finding)
+		}
+	}
+	return latest(accepted, limit), latest(rejected, limit)
+}
+
+// PullRequests returns the change requests reviewed since a time, in every
+// repository the store has loaded or saved
+func (s *Store) PullRequests(since time.Time) ([]PullRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadAll(); err != nil {
+		return nil, err
+	}
+
+	var pulls []PullRequest
+	for _, r := range s.repos {
+		for number, reviewed := range r.Pulls {
+			if reviewed.After(since) {
+				pulls = append(pulls, PullRequest{Owner: r.Owner, Repo: r.Repo, Number: number, Reviewed: reviewed})
+			}
+		}
+	}
+	sort.Slice(pulls, func(i, j int) bool {
+		return pulls[i].Reviewed.After(pulls[j].Reviewed)
+	})
+	return pulls, nil
+}
+
+// load returns the feedback of a repository, reading it from disk the first
+// time it is used
+func (s *Store) load(owner, repo string) (*repository, error) {
+	key := owner + "/" + repo
+	if r, ok := s.repos[key]; ok {
+		return r, nil
+	}
+
+	r := &repository{Owner: owner, Repo: repo, Findings: make(map[string]*Finding), Pulls: make(map[int]time.Time)}
+	if s.dir != "" {
+		data, err := os.ReadFile(s.path(key))
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, r); err != nil {
+				return nil, fmt.Errorf("failed to parse feedback for %s: %w", key, err)
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, fmt.Errorf("failed to read feedback for %s: %w", key, err)
+		}
+	}
+	s.repos[key] = r
+	return r, nil
+}
+
+// loadAll reads the feedback of every repository saved in the directory
+func (s *Store) loadAll() error {
+	if s.dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list feedback directory: %w", err)
+	}
+	for _, entry := range entries {
+		key, err := url.PathUnescape(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		owner, repo, ok := cut(key)
+		if !ok {
+			continue
+		}
+		if _, err := s.load(owner, repo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// save writes the feedback of a repository, replacing the file atomically
+func (s *Store) save(r *repository) error {
+	if s.dir == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode feedback: %w", err)
+	}
+
+	path := s.path(r.Owner + "/" + r.Repo)
+	tmp, err := os.CreateTemp(s.dir, ".feedback-*")
+	if err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	return nil
+}
+
+// path returns the file holding a repository's feedback
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+// cut splits a repository key at its last slash, as GitLab namespaces can
+// contain slashes themselves
+func cut(key string) (owner, repo string, ok bool) {
+	i := strings.LastIndex(key, "/")
+	if i <= 0 || i == len(key)-1 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// latest returns up to limit findings, most recently updated first
+func latest(findings []Finding, limit int) []Finding {
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Updated.After(findings[j].Updated)
+	})
+	if len(findings) > limit {
+		findings = findings[:limit]
+	}
+	return findings
+}
+
+// normalize lowercases text and collapses its whitespace
+func normalize(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
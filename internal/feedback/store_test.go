@@ -0,0 +1,65 @@
+package feedback_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/feedback"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := feedback.NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noisy := types.FileComment{Path: "main.go", Body: "Consider adding a comment", Type: types.TypeStyle, Fingerprint: "00000000000000aa"}
+	useful := types.FileComment{Path: "main.go", Body: "This error is ignored", Type: types.TypeBug, Fingerprint: "00000000000000bb"}
+	if err := store.RecordPosted("lehigh/demo", "app", 7, []types.FileComment{noisy, useful}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A rejection wins over a later acceptance, and unknown fingerprints are ignored
+	for _, verdict := range []struct {
+		fingerprint string
+		verdict     feedback.Verdict
+	}{
+		{noisy.Fingerprint, feedback.Rejected},
+		{noisy.Fingerprint, feedback.Accepted},
+		{useful.Fingerprint, feedback.Accepted},
+		{"00000000000000cc", feedback.Rejected},
+	} {
+		if err := store.SetVerdict("lehigh/demo", "app", verdict.fingerprint, verdict.verdict); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Feedback survives a restart
+	store, err = feedback.NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	similar := types.FileComment{Path: "other.go", Body: "consider  adding a\ncomment", Type: types.TypeStyle, Fingerprint: "00000000000000dd"}
+	if !store.Suppresses("lehigh/demo", "app", noisy) || !store.Suppresses("lehigh/demo", "app", similar) {
+		t.Error("rejected finding is not suppressed")
+	}
+	if store.Suppresses("lehigh/demo", "app", useful) || store.Suppresses("lehigh/other", "app", noisy) {
+		t.Error("finding suppressed without a rejection in its repository")
+	}
+
+	accepted, rejected := store.Examples("lehigh/demo", "app", 5)
+	if len(accepted) != 1 || accepted[0].Body != useful.Body || len(rejected) != 1 || rejected[0].Body != noisy.Body {
+		t.Errorf("got examples %+v and %+v", accepted, rejected)
+	}
+
+	pulls, err := store.PullRequests(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulls) != 1 || pulls[0].Owner != "lehigh/demo" || pulls[0].Repo != "app" || pulls[0].Number != 7 {
+		t.Errorf("got pull requests %+v", pulls)
+	}
+}
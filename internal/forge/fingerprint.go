@@ -0,0 +1,129 @@
+package forge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// OutdatedMarker is added to posted findings whose code has changed since
+const OutdatedMarker = "<!-- mountain-hawk:outdated -->"
+
+// fingerprintMarker matches the hidden fingerprint of a posted finding
+var fingerprintMarker = regexp.MustCompile(`<!-- mountain-hawk:fingerprint:([0-9a-f]{16}) -->`)
+
+// listedLocation matches the path:line starting an item of a file comment list
+var listedLocation = regexp.MustCompile("(?m)^- \\[?`([^`\n]+):[0-9]+`")
+
+// PostedFinding is a file comment the reviewer posted on a change request
+// in an earlier review
+type PostedFinding struct {
+	Fingerprint string
+
+	// CommentID identifies the inline comment holding the finding, or is
+	// zero for findings listed in a review body or note. Body is the inline
+	// comment's body.
+	CommentID int64
+	Body      string
+
+	// Path is the file of a finding whose forge doesn't track whether its
+	// code changed: one listed in a review body or note, or an inline
+	// finding on GitLab or Gitea. It is empty when the forge tracks it or the
+	// list doesn't give it.
+	Path string
+
+	// Outdated is true when the code the finding refers to has changed, and
+	// Marked when the comment already says so
+	Outdated bool
+	Marked   bool
+
+	// ThumbsUp and ThumbsDown count the reactions collaborators on the
+	// repository left on an inline finding
+	ThumbsUp   int
+	ThumbsDown int
+}
+
+// FindingHistory is implemented by forges that can list the findings the
+// reviewer posted earlier, so later reviews of a change request don't repeat
+// them
+type FindingHistory interface {
+	// ListFindings returns the fingerprinted findings the reviewer posted
+	ListFindings(ctx context.Context, owner, repo string, number int) ([]PostedFinding, error)
+
+	// MarkOutdated notes on an inline finding that its code has changed
+	MarkOutdated(ctx context.Context, owner, repo string, number int, finding PostedFinding) error
+
+	// IsCollaborator reports whether a user is a collaborator on a
+	// repository, whose feedback on findings counts
+	IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error)
+}
+
+// OutdatedBody returns the body of an inline finding with a note at the top
+// that its code has changed
+func OutdatedBody(body string) string {
+	return fmt.Sprintf("**Outdated:** the code this comment refers to has changed. %s\n\n%s", OutdatedMarker, body)
+}
+
+// Collaborators returns a function reporting whether a user is a
+// collaborator on a repository, checking each user once. Users who can't be
+// checked aren't trusted.
+func Collaborators(ctx context.Context, history FindingHistory, owner, repo string) func(user string) bool {
+	checked := make(map[string]bool)
+	return func(user string) bool {
+		if collaborator, ok := checked[user]; ok {
+			return collaborator
+		}
+		collaborator, err := history.IsCollaborator(ctx, owner, repo, user)
+		if err != nil {
+			log.Printf("Failed to check whether %s collaborates on %s/%s: %v", user, owner, repo, err)
+		}
+		checked[user] = collaborator
+		return collaborator
+	}
+}
+
+// Fingerprint identifies a finding by its path, type and the code it targets,
+// so the same finding gets the same fingerprint when a PR is reviewed again
+func Fingerprint(path, snippet string, commentType types.CommentType) string {
+	hash := sha256.Sum256([]byte(path + "\n" + string(commentType) + "\n" + strings.Join(strings.Fields(snippet), " ")))
+	return hex.EncodeToString(hash[:8])
+}
+
+// FingerprintMarker returns the hidden HTML comment holding a fingerprint
+func FingerprintMarker(fingerprint string) string {
+	return fmt.Sprintf("<!-- mountain-hawk:fingerprint:%s -->", fingerprint)
+}
+
+// ParseFingerprints returns the fingerprints marked in a posted body
+func ParseFingerprints(body string) []string {
+	var fingerprints []string
+	for _, match := range fingerprintMarker.FindAllStringSubmatch(body, -1) {
+		fingerprints = append(fingerprints, match[1])
+	}
+	return fingerprints
+}
+
+// ParseListedFindings returns the fingerprinted findings in a posted review
+// body or note, with the path of the file comment list item each belongs to
+func ParseListedFindings(body string) []PostedFinding {
+	locations := listedLocation.FindAllStringSubmatchIndex(body, -1)
+
+	var findings []PostedFinding
+	for _, match := range fingerprintMarker.FindAllStringSubmatchIndex(body, -1) {
+		finding := PostedFinding{Fingerprint: body[match[2]:match[3]]}
+		for _, location := range locations {
+			if location[0] > match[0] {
+				break
+			}
+			finding.Path = body[location[2]:location[3]]
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
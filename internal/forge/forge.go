@@ -0,0 +1,75 @@
+// Package forge defines the operations the reviewer needs from a code hosting
+// service. Change requests and their files are described with the go-github
+// types on every forge, so the review pipeline does not depend on where a
+// change is hosted.
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// Supported forges
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+	Gitea  = "gitea"
+)
+
+// ErrNotFound is returned when a change request, repository or file does not exist
+var ErrNotFound = errors.New("not found")
+
+// Forge is a code hosting service that change requests are reviewed on. A
+// change request is a GitHub or Gitea pull request or a GitLab merge request; owner is
+// the repository's namespace and number its per-repository number.
+type Forge interface {
+	// GetChangeRequest fetches a change request and the repository it targets
+	GetChangeRequest(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Repository, error)
+
+	// ListFiles returns the files a change request changes, with their patches
+	ListFiles(ctx context.Context, owner, repo string, number int) ([]*github.CommitFile, error)
+
+	// ReadFile returns the content of a file at a ref
+	ReadFile(ctx context.Context, owner, repo, path, ref string) (string, error)
+
+	// ListDirectory returns the paths of the files in a directory at a ref
+	ListDirectory(ctx context.Context, owner, repo, dir, ref string) ([]string, error)
+
+	// PostReview posts a review, anchoring file comments to the changed lines
+	PostReview(ctx context.Context, owner, repo string, number int, review *types.ReviewResponse, files []*github.CommitFile) error
+
+	// PostNote posts a general comment on a change request
+	PostNote(ctx context.Context, owner, repo string, number int, body string) error
+
+	// LineURL returns the web URL of a line of a file at a ref
+	LineURL(repository *github.Repository, ref, path string, line int) string
+}
+
+// MergeBaser is implemented by forges whose change requests report the
+// latest commit of the base branch rather than the merge base their diff is
+// computed from
+type MergeBaser interface {
+	// MergeBase returns the best common ancestor of two commits
+	MergeBase(ctx context.Context, owner, repo, base, head string) (string, error)
+}
+
+// LineURL joins a repository web URL, the forge's route to a file at a ref
+// and an escaped path into a link to a line. It returns "" when the
+// repository URL is unknown.
+func LineURL(repositoryURL, route, path string, line int) string {
+	if repositoryURL == "" {
+		return ""
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("%s/%s/%s#L%d", strings.TrimSuffix(repositoryURL, "/"), route, strings.Join(segments, "/"), line)
+}
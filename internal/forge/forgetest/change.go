@@ -0,0 +1,80 @@
+package forgetest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// The scripted change adds lines 2 and 3 of MainPath, keeping lines 1 and 4
+// as context
+const (
+	MainPath  = "cmd/main.go"
+	MainPatch = "@@ -1,2 +1,4 @@\n package main\n+\n+func main() {}\n // end\n"
+	MainHead  = "package main\n\nfunc main() {}\n// end\n"
+)
+
+// Change identifies a scripted change request that changes MainPath with
+// MainPatch, and whose head commit HeadSHA holds MainHead
+type Change struct {
+	Owner   string
+	Repo    string
+	Number  int
+	HeadSHA string
+}
+
+// Review returns a review with a comment on an added line of MainPath and
+// one on a line outside the diff
+func Review(decision types.ReviewDecision) *types.ReviewResponse {
+	return &types.ReviewResponse{
+		Decision: decision,
+		FileComments: []types.FileComment{
+			{Path: MainPath, Line: 3, Body: "Empty main", Severity: types.SeverityError, Type: types.TypeBug},
+			{Path: MainPath, Line: 40, Body: "Outside the diff", Severity: types.SeverityInfo, Type: types.TypeStyle},
+		},
+		Summary: "One bug",
+	}
+}
+
+// CheckChange checks that a forge reads a scripted change request, its files
+// and the files at its head commit
+func CheckChange(t *testing.T, f forge.Forge, change Change) {
+	t.Helper()
+	ctx := context.Background()
+
+	pr, repo, err := f.GetChangeRequest(ctx, change.Owner, change.Repo, change.Number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.GetNumber() != change.Number || pr.GetHead().GetSHA() != change.HeadSHA || pr.GetAdditions() < 2 {
+		t.Errorf("unexpected change request: %+v", pr)
+	}
+	if repo.GetOwner().GetLogin() != change.Owner || repo.GetName() != change.Repo {
+		t.Errorf("unexpected repository: %s/%s", repo.GetOwner().GetLogin(), repo.GetName())
+	}
+
+	files, err := f.ListFiles(ctx, change.Owner, change.Repo, change.Number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 || files[0].GetFilename() != MainPath || strings.TrimSuffix(files[0].GetPatch(), "\n") != strings.TrimSuffix(MainPatch, "\n") {
+		t.Fatalf("unexpected files: %v", files)
+	}
+
+	content, err := f.ReadFile(ctx, change.Owner, change.Repo, MainPath, change.HeadSHA)
+	if err != nil || content != MainHead {
+		t.Errorf("ReadFile = %q, %v", content, err)
+	}
+	if _, err := f.ReadFile(ctx, change.Owner, change.Repo, "missing.go", change.HeadSHA); !errors.Is(err, forge.ErrNotFound) {
+		t.Errorf("ReadFile of a missing file = %v, want ErrNotFound", err)
+	}
+
+	paths, err := f.ListDirectory(ctx, change.Owner, change.Repo, "cmd", change.HeadSHA)
+	if err != nil || len(paths) != 1 || paths[0] != MainPath {
+		t.Errorf("ListDirectory = %v, %v", paths, err)
+	}
+}
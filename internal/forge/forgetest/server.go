@@ -0,0 +1,121 @@
+// Package forgetest holds what the fake forge servers and the tests of each
+// forge share: the repository file store behind the fakes, their response
+// helpers, and a scripted change request every forge is checked against.
+package forgetest
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+)
+
+// Token is the access token the fakes accept
+const Token = "test-token"
+
+// Trees holds the files of repositories at commits. The zero value is empty
+// and ready to use.
+type Trees struct {
+	mu sync.Mutex
+
+	// trees holds each repository's files, keyed by commit and then path
+	trees map[string]map[string]map[string]string
+}
+
+// Add sets files in a repository at a commit, keyed by path. Repositories are
+// named by their full path, such as owner/repo.
+func (t *Trees) Add(repo, ref string, files map[string]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.trees == nil {
+		t.trees = make(map[string]map[string]map[string]string)
+	}
+	if t.trees[repo] == nil {
+		t.trees[repo] = make(map[string]map[string]string)
+	}
+	if t.trees[repo][ref] == nil {
+		t.trees[repo][ref] = make(map[string]string)
+	}
+	for p, content := range files {
+		t.trees[repo][ref][p] = content
+	}
+}
+
+// Files returns the files of a repository at a commit, or nil if none were added
+func (t *Trees) Files(repo, ref string) map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return maps.Clone(t.trees[repo][ref])
+}
+
+// Find returns the first file content at any commit of a repository that
+// match accepts
+func (t *Trees) Find(repo string, match func(content string) bool) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, ref := range sortedKeys(t.trees[repo]) {
+		tree := t.trees[repo][ref]
+		for _, p := range SortedPaths(tree) {
+			if match(tree[p]) {
+				return tree[p], true
+			}
+		}
+	}
+	return "", false
+}
+
+// SortedPaths returns the paths of a tree in lexical order
+func SortedPaths(tree map[string]string) []string {
+	return sortedKeys(tree)
+}
+
+// DirPaths returns the paths of the files directly inside a directory of a
+// tree, in lexical order. The root directory is "".
+func DirPaths(tree map[string]string, dir string) []string {
+	var paths []string
+	for _, p := range SortedPaths(tree) {
+		if d := path.Dir(p); d == dir || (d == "." && dir == "") {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// Authenticate rejects requests whose header doesn't hold value, answering
+// with message as the forges do
+func Authenticate(header, value, message string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(header) != value {
+			WriteError(w, http.StatusUnauthorized, message)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WriteJSON writes v as a JSON response
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes an error response with a message, the form GitHub,
+// GitLab and Gitea share
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, map[string]any{"message": message})
+}
+
+// sortedKeys returns the keys of a map in lexical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,156 @@
+package forge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// FormatReviewBody formats the general comments, summary, policy outcome and
+// prompt version of a review as Markdown. event names the forge's review
+// event for a decision. Comments outside the diff and sections are added
+// after the general comments.
+func FormatReviewBody(review *types.ReviewResponse, event func(types.ReviewDecision) string, sections ...string) string {
+	var body strings.Builder
+
+	// Add general comments to review body
+	for _, comment := range review.GeneralComments {
+		if body.Len() > 0 {
+			body.WriteString("\n\n")
+		}
+		body.WriteString(FormatGeneralComment(comment))
+	}
+
+	outside := FormatFileCommentList("Outside the diff", review.OutsideDiff, func(comment types.FileComment) string {
+		return comment.URL
+	})
+	for _, section := range append([]string{outside}, sections...) {
+		if section == "" {
+			continue
+		}
+		if body.Len() > 0 {
+			body.WriteString("\n\n")
+		}
+		body.WriteString(section)
+	}
+
+	// Add summary if provided
+	if review.Summary != "" {
+		if body.Len() > 0 {
+			body.WriteString("\n\n---\n\n")
+		}
+		body.WriteString(fmt.Sprintf("**Summary:** %s", review.Summary))
+	}
+
+	// Show the final event and any policy rules that changed it
+	if review.Policy != nil {
+		if body.Len() > 0 {
+			body.WriteString("\n\n---\n\n")
+		}
+		body.WriteString(formatPolicyOutcome(review.Decision, review.Policy, event))
+	}
+
+	// Record the prompt version so results can be traced
+	if review.PromptVersion != "" {
+		if body.Len() > 0 {
+			body.WriteString("\n\n")
+		}
+		body.WriteString(fmt.Sprintf("<sub>Prompt: %s</sub>", review.PromptVersion))
+	}
+
+	return body.String()
+}
+
+// formatPolicyOutcome describes the posted review event and the policy rules
+// that changed the model's decision
+func formatPolicyOutcome(decision types.ReviewDecision, outcome *types.PolicyOutcome, event func(types.ReviewDecision) string) string {
+	if len(outcome.Rules) == 0 {
+		return fmt.Sprintf("**Review event:** %s", event(decision))
+	}
+
+	rules := make([]string, 0, len(outcome.Rules))
+	for _, rule := range outcome.Rules {
+		rules = append(rules, "`"+rule+"`")
+	}
+	return fmt.Sprintf("**Review event:** %s (the model decided %s; changed by policy %s)",
+		event(decision), event(outcome.ModelDecision), strings.Join(rules, ", "))
+}
+
+// FormatGeneralComment formats a general comment with appropriate emoji
+func FormatGeneralComment(comment types.GeneralComment) string {
+	return fmt.Sprintf("%s%s", severityEmoji(comment.Severity), comment.Body)
+}
+
+// FormatFileComment formats a file comment with severity and type indicators
+func FormatFileComment(comment types.FileComment) string {
+	formatted := fmt.Sprintf("%s%s**%s**: %s",
+		severityEmoji(comment.Severity),
+		typeEmoji(comment.Type),
+		strings.Title(string(comment.Type)),
+		comment.Body,
+	)
+
+	// Say where the model put a comment that was moved to a changed line
+	if comment.OriginalLine != 0 {
+		formatted += fmt.Sprintf("\n\n<sub>Moved from line %d</sub>", comment.OriginalLine)
+	}
+
+	// Let later reviews recognize the finding
+	if comment.Fingerprint != "" {
+		formatted += " " + FingerprintMarker(comment.Fingerprint)
+	}
+	return formatted
+}
+
+// FormatFileCommentList formats file comments as a Markdown list under a
+// heading, each prefixed with its path:line. link returns the URL of a
+// comment's line, or "" to leave it unlinked.
+func FormatFileCommentList(heading string, comments []types.FileComment, link func(types.FileComment) string) string {
+	if len(comments) == 0 {
+		return ""
+	}
+
+	var list strings.Builder
+	list.WriteString(fmt.Sprintf("**%s:**\n", heading))
+	for _, comment := range comments {
+		location := fmt.Sprintf("`%s:%d`", comment.Path, comment.Line)
+		if url := link(comment); url != "" {
+			location = fmt.Sprintf("[%s](%s)", location, url)
+		}
+		list.WriteString(fmt.Sprintf("\n- %s %s", location, FormatFileComment(comment)))
+	}
+	return list.String()
+}
+
+// severityEmoji returns emoji for severity level
+func severityEmoji(severity types.Severity) string {
+	switch severity {
+	case types.SeverityError:
+		return "🚨 "
+	case types.SeverityWarning:
+		return "⚠️ "
+	case types.SeverityInfo:
+		return "💡 "
+	default:
+		return ""
+	}
+}
+
+// typeEmoji returns emoji for comment type
+func typeEmoji(commentType types.CommentType) string {
+	switch commentType {
+	case types.TypeBug:
+		return "🐛 "
+	case types.TypeSecurity:
+		return "🔒 "
+	case types.TypePerformance:
+		return "⚡ "
+	case types.TypeStyle:
+		return "🎨 "
+	case types.TypeMaintainability:
+		return "🔧 "
+	default:
+		return ""
+	}
+}
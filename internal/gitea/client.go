@@ -0,0 +1,295 @@
+// Package gitea reviews Gitea and Forgejo pull requests through the Gitea API.
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// perPage is the page size requested from paginated endpoints
+const perPage = 50
+
+// Error is an unsuccessful Gitea API response
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gitea returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a Gitea 404 response
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client wraps the Gitea API
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the Gitea or Forgejo instance at baseURL,
+// e.g. https://codeberg.org, authenticating with an access token
+func NewClient(baseURL, token string) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/api/v1/")
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Gitea URL: %s", baseURL)
+	}
+
+	return &Client{
+		baseURL: u,
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: time.Minute,
+		},
+	}, nil
+}
+
+// repoPath returns the API path of a repository
+func repoPath(owner, repo string) string {
+	return "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+// escapePath escapes each segment of a file path
+func escapePath(filePath string) string {
+	segments := strings.Split(strings.Trim(filePath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// GetRepository retrieves a repository
+func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*Repository, error) {
+	var repository Repository
+	if err := c.getJSON(ctx, repoPath(owner, repo), nil, &repository); err != nil {
+		return nil, err
+	}
+	return &repository, nil
+}
+
+// GetPullRequest retrieves a pull request
+func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
+	var pr PullRequest
+	path := fmt.Sprintf("%s/pulls/%d", repoPath(owner, repo), number)
+	if err := c.getJSON(ctx, path, nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// GetPullRequestDiff retrieves the unified diff of a pull request
+func (c *Client) GetPullRequestDiff(ctx context.Context, owner, repo string, number int) (string, error) {
+	return c.getText(ctx, fmt.Sprintf("%s/pulls/%d.diff", repoPath(owner, repo), number), nil)
+}
+
+// GetRawFile retrieves the content of a file at a ref
+func (c *Client) GetRawFile(ctx context.Context, owner, repo, filePath, ref string) (string, error) {
+	return c.getText(ctx, repoPath(owner, repo)+"/raw/"+escapePath(filePath), url.Values{"ref": {ref}})
+}
+
+// ListContents retrieves the entries of a directory at a ref
+func (c *Client) ListContents(ctx context.Context, owner, repo, dir, ref string) ([]ContentsEntry, error) {
+	path := repoPath(owner, repo) + "/contents"
+	if dir = strings.Trim(dir, "/"); dir != "" {
+		path += "/" + escapePath(dir)
+	}
+
+	var entries []ContentsEntry
+	if err := c.getJSON(ctx, path, url.Values{"ref": {ref}}, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// DownloadTarball streams a gzipped tarball of the repository at ref
+func (c *Client) DownloadTarball(ctx context.Context, owner, repo, ref string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, repoPath(owner, repo)+"/archive/"+url.PathEscape(ref)+".tar.gz", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// CreateReview creates a pull request review with line comments
+func (c *Client) CreateReview(ctx context.Context, owner, repo string, number int, review *CreateReview) error {
+	return c.postJSON(ctx, fmt.Sprintf("%s/pulls/%d/reviews", repoPath(owner, repo), number), review)
+}
+
+// CreateIssueComment creates a general comment on a pull request
+func (c *Client) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	return c.postJSON(ctx, fmt.Sprintf("%s/issues/%d/comments", repoPath(owner, repo), number), &Comment{Body: body})
+}
+
+// CurrentUser retrieves the user the token authenticates as
+func (c *Client) CurrentUser(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.getJSON(ctx, "user", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListReviews retrieves the reviews on a pull request
+func (c *Client) ListReviews(ctx context.Context, owner, repo string, number int) ([]PullReview, error) {
+	var reviews []PullReview
+	path := fmt.Sprintf("%s/pulls/%d/reviews", repoPath(owner, repo), number)
+	for page := 1; ; page++ {
+		var batch []PullReview
+		query := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(perPage)}}
+		if err := c.getJSON(ctx, path, query, &batch); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, batch...)
+		if len(batch) < perPage {
+			return reviews, nil
+		}
+	}
+}
+
+// ListReviewComments retrieves the line comments of a pull request review
+func (c *Client) ListReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]PullReviewComment, error) {
+	var comments []PullReviewComment
+	path := fmt.Sprintf("%s/pulls/%d/reviews/%d/comments", repoPath(owner, repo), number, reviewID)
+	if err := c.getJSON(ctx, path, nil, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// ListIssueComments retrieves the general comments on a pull request
+func (c *Client) ListIssueComments(ctx context.Context, owner, repo string, number int) ([]IssueComment, error) {
+	var comments []IssueComment
+	path := fmt.Sprintf("%s/issues/%d/comments", repoPath(owner, repo), number)
+	if err := c.getJSON(ctx, path, nil, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// EditComment replaces the body of a comment, review line comments included
+func (c *Client) EditComment(ctx context.Context, owner, repo string, id int64, body string) error {
+	path := fmt.Sprintf("%s/issues/comments/%d", repoPath(owner, repo), id)
+	resp, err := c.do(ctx, http.MethodPatch, path, nil, &Comment{Body: body})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ListCommentReactions retrieves the reactions on a comment
+func (c *Client) ListCommentReactions(ctx context.Context, owner, repo string, id int64) ([]Reaction, error) {
+	var reactions []Reaction
+	path := fmt.Sprintf("%s/issues/comments/%d/reactions", repoPath(owner, repo), id)
+	if err := c.getJSON(ctx, path, nil, &reactions); err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
+
+// IsCollaborator reports whether a user is a collaborator on a repository
+func (c *Client) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodGet, repoPath(owner, repo)+"/collaborators/"+url.PathEscape(user), nil, nil)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// getJSON decodes the JSON response of a GET request into out
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// getText returns the body of a GET request
+func (c *Client) getText(ctx context.Context, path string, query url.Values) (string, error) {
+	resp, err := c.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	return string(data), nil
+}
+
+// postJSON sends body as JSON and discards the response
+func (c *Client) postJSON(ctx context.Context, path string, body any) error {
+	resp, err := c.do(ctx, http.MethodPost, path, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends an API request and returns the response if it was successful
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	// Paths are escaped, so they are parsed rather than joined
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API path %s: %w", path, err)
+	}
+	u := c.baseURL.ResolveReference(ref)
+	u.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "token "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	return resp, nil
+}
@@ -0,0 +1,118 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+)
+
+var _ forge.FindingHistory = (*Forge)(nil)
+
+// ListFindings returns the fingerprinted findings the reviewer's account
+// posted on a pull request, in review line comments, review bodies and
+// comments. Only reactions from collaborators are counted. Gitea doesn't
+// say reliably when a line comment's code has changed, so inline findings
+// carry their path for the caller to check against the head revision.
+func (f *Forge) ListFindings(ctx context.Context, owner, repo string, number int) ([]forge.PostedFinding, error) {
+	user, err := f.client.CurrentUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the authenticated user: %w", err)
+	}
+	own := func(author User) bool {
+		return strings.EqualFold(author.Login, user.Login)
+	}
+	collaborator := forge.Collaborators(ctx, f, owner, repo)
+
+	reviews, err := f.client.ListReviews(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	var findings []forge.PostedFinding
+	for _, review := range reviews {
+		if !own(review.User) {
+			continue
+		}
+		findings = append(findings, forge.ParseListedFindings(review.Body)...)
+
+		comments, err := f.client.ListReviewComments(ctx, owner, repo, number, review.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list comments of review %d: %w", review.ID, err)
+		}
+		for _, comment := range comments {
+			fingerprints := forge.ParseFingerprints(comment.Body)
+			if !own(comment.User) || len(fingerprints) == 0 {
+				continue
+			}
+			up, down, err := f.countReactions(ctx, owner, repo, comment.ID, collaborator)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list reactions on comment %d: %w", comment.ID, err)
+			}
+			for _, fingerprint := range fingerprints {
+				findings = append(findings, forge.PostedFinding{
+					Fingerprint: fingerprint,
+					CommentID:   comment.ID,
+					Body:        comment.Body,
+					Path:        comment.Path,
+					Marked:      strings.Contains(comment.Body, forge.OutdatedMarker),
+					ThumbsUp:    up,
+					ThumbsDown:  down,
+				})
+			}
+		}
+	}
+
+	// Reviews Gitea rejected were posted as issue comments
+	issueComments, err := f.client.ListIssueComments(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issue comments: %w", err)
+	}
+	for _, comment := range issueComments {
+		if own(comment.User) {
+			findings = append(findings, forge.ParseListedFindings(comment.Body)...)
+		}
+	}
+
+	return findings, nil
+}
+
+// MarkOutdated notes at the top of a review line comment that its code has
+// changed
+func (f *Forge) MarkOutdated(ctx context.Context, owner, repo string, number int, finding forge.PostedFinding) error {
+	if strings.Contains(finding.Body, forge.OutdatedMarker) {
+		return nil
+	}
+
+	if err := f.client.EditComment(ctx, owner, repo, finding.CommentID, forge.OutdatedBody(finding.Body)); err != nil {
+		return fmt.Errorf("failed to edit review comment: %w", err)
+	}
+	return nil
+}
+
+// IsCollaborator reports whether a user is a collaborator on a repository
+func (f *Forge) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	return f.client.IsCollaborator(ctx, owner, repo, user)
+}
+
+// countReactions counts the thumbs up and thumbs down reactions
+// collaborators left on a review line comment
+func (f *Forge) countReactions(ctx context.Context, owner, repo string, commentID int64, collaborator func(user string) bool) (up, down int, err error) {
+	reactions, err := f.client.ListCommentReactions(ctx, owner, repo, commentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, reaction := range reactions {
+		if !collaborator(reaction.User.Login) {
+			continue
+		}
+		switch reaction.Content {
+		case "+1":
+			up++
+		case "-1":
+			down++
+		}
+	}
+	return up, down, nil
+}
@@ -0,0 +1,199 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// Forge reviews Gitea and Forgejo pull requests. Pull requests and their
+// diffs are converted to the go-github types the review pipeline works with.
+type Forge struct {
+	client *Client
+}
+
+var _ forge.Forge = (*Forge)(nil)
+
+// NewForge creates a Gitea forge
+func NewForge(client *Client) *Forge {
+	return &Forge{client: client}
+}
+
+// GetChangeRequest fetches a pull request and its repository
+func (f *Forge) GetChangeRequest(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Repository, error) {
+	pr, err := f.client.GetPullRequest(ctx, owner, repo, number)
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+
+	repository, err := f.client.GetRepository(ctx, owner, repo)
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+
+	// Files are diffed from the merge base, while the base branch may have
+	// moved on since
+	baseSHA := pr.Base.SHA
+	if pr.MergeBase != "" {
+		baseSHA = pr.MergeBase
+	}
+
+	change := &github.PullRequest{
+		Number:       github.Ptr(pr.Number),
+		Title:        github.Ptr(pr.Title),
+		Body:         github.Ptr(pr.Body),
+		State:        github.Ptr(pr.State),
+		Draft:        github.Ptr(pr.Draft),
+		HTMLURL:      github.Ptr(pr.HTMLURL),
+		User:         &github.User{Login: github.Ptr(pr.User.Login)},
+		Base:         &github.PullRequestBranch{Ref: github.Ptr(pr.Base.Ref), SHA: github.Ptr(baseSHA)},
+		Head:         &github.PullRequestBranch{Ref: github.Ptr(pr.Head.Ref), SHA: github.Ptr(pr.Head.SHA)},
+		Additions:    github.Ptr(pr.Additions),
+		Deletions:    github.Ptr(pr.Deletions),
+		ChangedFiles: github.Ptr(pr.ChangedFiles),
+	}
+	target := &github.Repository{
+		Name:          github.Ptr(repository.Name),
+		FullName:      github.Ptr(repository.FullName),
+		DefaultBranch: github.Ptr(repository.DefaultBranch),
+		HTMLURL:       github.Ptr(repository.HTMLURL),
+		Owner:         &github.User{Login: github.Ptr(repository.Owner.Login)},
+	}
+	return change, target, nil
+}
+
+// ListFiles returns the files changed in a pull request. The files API has no
+// patches, so they come from the pull request's diff.
+func (f *Forge) ListFiles(ctx context.Context, owner, repo string, number int) ([]*github.CommitFile, error) {
+	diff, err := f.client.GetPullRequestDiff(ctx, owner, repo, number)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	files, err := gh.ParseUnifiedDiff(diff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pull request diff: %w", err)
+	}
+	return files, nil
+}
+
+// ReadFile returns the content of a file at a ref
+func (f *Forge) ReadFile(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	content, err := f.client.GetRawFile(ctx, owner, repo, path, ref)
+	return content, notFound(err)
+}
+
+// ListDirectory returns the paths of the files in a directory at a ref
+func (f *Forge) ListDirectory(ctx context.Context, owner, repo, dir, ref string) ([]string, error) {
+	entries, err := f.client.ListContents(ctx, owner, repo, dir, ref)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.Type == "file" {
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths, nil
+}
+
+// DownloadTarball streams a gzipped tarball of the repository at ref
+func (f *Forge) DownloadTarball(ctx context.Context, owner, repo, ref string) (io.ReadCloser, error) {
+	return f.client.DownloadTarball(ctx, owner, repo, ref)
+}
+
+// PostReview posts a review with a comment on each file comment's line
+func (f *Forge) PostReview(ctx context.Context, owner, repo string, number int, review *types.ReviewResponse, files []*github.CommitFile) error {
+	patches := make(map[string]*gh.Patch)
+	for _, file := range files {
+		patches[file.GetFilename()] = gh.FilePatch(file)
+	}
+
+	// Process file comments
+	var comments []ReviewComment
+	var outside []types.FileComment
+	for _, comment := range review.FileComments {
+		patch, exists := patches[comment.Path]
+		if !exists {
+			log.Printf("File %s not found in PR files", comment.Path)
+			continue
+		}
+
+		// Gitea anchors comments by line number rather than diff position
+		if !patch.IsAdded(comment.Line) {
+			log.Printf("Could not find %s:%d in the diff, moving it outside the diff", comment.Path, comment.Line)
+			outside = append(outside, comment)
+			continue
+		}
+
+		comments = append(comments, ReviewComment{
+			Path:        comment.Path,
+			Body:        forge.FormatFileComment(comment),
+			NewPosition: comment.Line,
+		})
+	}
+
+	if len(outside) > 0 {
+		withOutside := *review
+		withOutside.OutsideDiff = slices.Concat(review.OutsideDiff, outside)
+		review = &withOutside
+	}
+
+	// General comments, summary and review metadata go in the review body
+	body := forge.FormatReviewBody(review, mapDecisionToEvent)
+
+	err := f.client.CreateReview(ctx, owner, repo, number, &CreateReview{
+		Body:     body,
+		Event:    mapDecisionToEvent(review.Decision),
+		Comments: comments,
+	})
+	if err != nil {
+		// Fallback: post as general comment
+		if body != "" {
+			log.Printf("Failed to create review, posting as comment: %v", err)
+			return f.client.CreateIssueComment(ctx, owner, repo, number, body)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// PostNote posts a general comment on a pull request
+func (f *Forge) PostNote(ctx context.Context, owner, repo string, number int, body string) error {
+	return f.client.CreateIssueComment(ctx, owner, repo, number, body)
+}
+
+// LineURL returns the web URL of a line of a file at a ref
+func (f *Forge) LineURL(repository *github.Repository, ref, path string, line int) string {
+	return forge.LineURL(repository.GetHTMLURL(), "src/commit/"+ref, path, line)
+}
+
+// mapDecisionToEvent maps review decision to Gitea review state
+func mapDecisionToEvent(decision types.ReviewDecision) string {
+	switch decision {
+	case types.DecisionApprove:
+		return StateApproved
+	case types.DecisionRequestChanges:
+		return StateRequestChanges
+	default:
+		return StateComment
+	}
+}
+
+// notFound marks Gitea 404 responses as forge.ErrNotFound
+func notFound(err error) error {
+	if IsNotFound(err) {
+		return fmt.Errorf("%w: %w", forge.ErrNotFound, err)
+	}
+	return err
+}
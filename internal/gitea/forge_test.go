@@ -0,0 +1,177 @@
+package gitea_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge/forgetest"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitea"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitea/giteatest"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// diff is the scripted change as git diff prints it, with a binary logo
+const diff = "diff --git a/cmd/main.go b/cmd/main.go\n" +
+	"index 1111111..2222222 100644\n" +
+	"--- a/cmd/main.go\n" +
+	"+++ b/cmd/main.go\n" +
+	forgetest.MainPatch +
+	"diff --git a/logo.png b/logo.png\n" +
+	"new file mode 100644\n" +
+	"index 0000000..3333333\n" +
+	"Binary files /dev/null and b/logo.png differ\n"
+
+func newPullRequest(t *testing.T) (*giteatest.Server, *gitea.Forge) {
+	t.Helper()
+
+	server := giteatest.NewServer()
+	t.Cleanup(server.Close)
+
+	server.AddPullRequest(&giteatest.PullRequest{
+		Owner: "libraries", Repo: "catalog", Number: 5,
+		Title: "Add main", Author: "dev",
+		BaseRef: "main", HeadRef: "feature",
+		BaseSHA: "base", HeadSHA: "head", MergeBase: "fork",
+		Diff: diff,
+	})
+	server.AddFiles("libraries", "catalog", "head", map[string]string{forgetest.MainPath: forgetest.MainHead})
+	return server, gitea.NewForge(server.Client())
+}
+
+func TestChangeRequest(t *testing.T) {
+	_, f := newPullRequest(t)
+	forgetest.CheckChange(t, f, forgetest.Change{Owner: "libraries", Repo: "catalog", Number: 5, HeadSHA: "head"})
+
+	// Base versions are read at the merge base the diff is computed from
+	pr, _, err := f.GetChangeRequest(context.Background(), "libraries", "catalog", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.GetBase().GetSHA() != "fork" {
+		t.Errorf("got base %s, want the merge base fork", pr.GetBase().GetSHA())
+	}
+
+	// Files come from the pull request's diff, binary files included
+	files, err := f.ListFiles(context.Background(), "libraries", "catalog", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[1].GetFilename() != "logo.png" || files[1].GetStatus() != "added" {
+		t.Errorf("unexpected files: %v", files)
+	}
+}
+
+func TestPostReview(t *testing.T) {
+	server, f := newPullRequest(t)
+	ctx := context.Background()
+
+	files, err := f.ListFiles(ctx, "libraries", "catalog", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.PostReview(ctx, "libraries", "catalog", 5, forgetest.Review(types.DecisionRequestChanges), files); err != nil {
+		t.Fatal(err)
+	}
+
+	reviews := server.Reviews()
+	if len(reviews) != 1 {
+		t.Fatalf("got %d reviews, want 1", len(reviews))
+	}
+	req := reviews[0].Request
+	if req.Event != gitea.StateRequestChanges || !strings.Contains(req.Body, "**Summary:** One bug") || !strings.Contains(req.Body, "Outside the diff") {
+		t.Errorf("unexpected review: %+v", req)
+	}
+	if len(req.Comments) != 1 || req.Comments[0].NewPosition != 3 || !strings.Contains(req.Comments[0].Body, "Empty main") {
+		t.Errorf("unexpected comments: %+v", req.Comments)
+	}
+}
+
+func TestFindingHistory(t *testing.T) {
+	server, f := newPullRequest(t)
+	ctx := context.Background()
+
+	files, err := f.ListFiles(ctx, "libraries", "catalog", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	review := forgetest.Review(types.DecisionComment)
+	review.FileComments[0].Fingerprint = "0000000000000001"
+	review.FileComments[1].Fingerprint = "0000000000000002"
+	if err := f.PostReview(ctx, "libraries", "catalog", 5, review, files); err != nil {
+		t.Fatal(err)
+	}
+
+	// Markers copied into someone else's comment don't count, and neither
+	// do reactions from users who aren't collaborators
+	server.AddIssueComment("libraries", "catalog", 5, &gitea.IssueComment{Body: forge.FingerprintMarker("0000000000000003"), User: gitea.User{Login: "dev"}})
+	server.AddCollaborator("libraries", "catalog", "maintainer")
+	inline := server.ReviewComments("libraries", "catalog", 5)[0]
+	server.AddReaction(inline.ID, "maintainer", "-1")
+	server.AddReaction(inline.ID, "dev", "+1")
+
+	findings, err := f.ListFindings(ctx, "libraries", "catalog", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []forge.PostedFinding{
+		{Fingerprint: "0000000000000002", Path: forgetest.MainPath},
+		{Fingerprint: "0000000000000001", CommentID: inline.ID, Body: inline.Body, Path: forgetest.MainPath, ThumbsDown: 1},
+	}
+	if !reflect.DeepEqual(findings, want) {
+		t.Errorf("got findings %+v, want %+v", findings, want)
+	}
+
+	// Marking a line comment outdated edits it once
+	for range 2 {
+		findings[1].Body = server.ReviewComments("libraries", "catalog", 5)[0].Body
+		if err := f.MarkOutdated(ctx, "libraries", "catalog", 5, findings[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if body := server.ReviewComments("libraries", "catalog", 5)[0].Body; strings.Count(body, forge.OutdatedMarker) != 1 || !strings.Contains(body, "Empty main") {
+		t.Errorf("unexpected outdated comment: %q", body)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	payload := `{"action":"synchronized","number":5,"repository":{"name":"catalog","owner":{"login":"libraries"}}}`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(payload))
+
+	for _, tc := range []struct {
+		name, signature string
+		valid           bool
+	}{
+		{"valid", hex.EncodeToString(mac.Sum(nil)), true},
+		{"wrong", strings.Repeat("0", 64), false},
+		{"missing", "", false},
+	} {
+		r := httptest.NewRequest("POST", "/webhook/gitea", strings.NewReader(payload))
+		r.Header.Set("X-Forgejo-Event", gitea.PullRequestEvent)
+		r.Header.Set("X-Forgejo-Signature", tc.signature)
+
+		data, err := gitea.ReadWebhookPayload(r, []byte("secret"))
+		if (err == nil) != tc.valid {
+			t.Errorf("%s signature: err = %v", tc.name, err)
+			continue
+		}
+		if !tc.valid {
+			continue
+		}
+
+		hook, err := gitea.ParsePullRequestHook(r, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hook.Number != 5 || !hook.ShouldReview() {
+			t.Errorf("unexpected hook: %+v", hook)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+// Package giteatest provides an in-process fake of the Gitea API endpoints
+// the reviewer uses, for end-to-end tests without network access.
+package giteatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge/forgetest"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitea"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// PullRequest is a scripted pull request served by the fake. Diff is the
+// unified diff of the whole pull request, as git diff prints it.
+type PullRequest struct {
+	Owner   string
+	Repo    string
+	Number  int
+	Title   string
+	Body    string
+	Author  string
+	BaseRef string
+	HeadRef string
+	BaseSHA string
+	HeadSHA string
+	Diff    string
+
+	// MergeBase is reported as the pull request's merge base, if set
+	MergeBase string
+}
+
+// Review is a review posted to the fake
+type Review struct {
+	Owner   string
+	Repo    string
+	Number  int
+	Request gitea.CreateReview
+}
+
+// Comment is an issue comment posted to the fake
+type Comment struct {
+	Owner  string
+	Repo   string
+	Number int
+	Body   string
+}
+
+// botUser is the account the fake attributes posted reviews and comments to
+var botUser = gitea.User{Login: "mountain-hawk"}
+
+// Server is a fake Gitea API. Create one with NewServer and close it when done.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	pulls    map[string]*PullRequest
+	trees    forgetest.Trees
+	reviews  []Review
+	comments []Comment
+
+	// Posted and scripted comments as listed, which share one ID sequence
+	reviewComments map[int64][]*gitea.PullReviewComment
+	issueComments  map[string][]*gitea.IssueComment
+	reactions      map[int64][]gitea.Reaction
+	collaborators  map[string]bool
+	nextCommentID  int64
+}
+
+// NewServer starts a fake Gitea API server
+func NewServer() *Server {
+	s := &Server{
+		pulls:          make(map[string]*PullRequest),
+		reviewComments: make(map[int64][]*gitea.PullReviewComment),
+		issueComments:  make(map[string][]*gitea.IssueComment),
+		reactions:      make(map[int64][]gitea.Reaction),
+		collaborators:  make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/user", s.handleUser)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}", s.handleRepository)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/collaborators/{user}", s.handleCollaborator)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/pulls/{number}", s.handlePullRequest)
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/pulls/{number}/reviews", s.handleCreateReview)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/pulls/{number}/reviews", s.handleListReviews)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/pulls/{number}/reviews/{id}/comments", s.handleListReviewComments)
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/issues/{number}/comments", s.handleCreateComment)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/issues/{number}/comments", s.handleListComments)
+	mux.HandleFunc("PATCH /api/v1/repos/{owner}/{repo}/issues/comments/{id}", s.handleEditComment)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/issues/comments/{id}/reactions", s.handleReactions)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/raw/{path...}", s.handleRaw)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/contents", s.handleContents)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/contents/{path...}", s.handleContents)
+	s.Server = httptest.NewServer(forgetest.Authenticate("Authorization", "token "+forgetest.Token, "token is required", mux))
+
+	return s
+}
+
+// Client returns a reviewer Gitea client pointed at the fake
+func (s *Server) Client() *gitea.Client {
+	client, err := gitea.NewClient(s.URL, forgetest.Token)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// AddPullRequest scripts a pull request
+func (s *Server) AddPullRequest(pr *PullRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pulls[pullKey(pr.Owner, pr.Repo, pr.Number)] = pr
+}
+
+// AddFiles sets the files in a repository at a commit, keyed by path
+func (s *Server) AddFiles(owner, repo, ref string, files map[string]string) {
+	s.trees.Add(owner+"/"+repo, ref, files)
+}
+
+// Reviews returns the reviews posted so far
+func (s *Server) Reviews() []Review {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Review(nil), s.reviews...)
+}
+
+// Comments returns the issue comments posted so far
+func (s *Server) Comments() []Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Comment(nil), s.comments...)
+}
+
+// AddIssueComment scripts a general comment listed on a pull request, and
+// sets its ID. Comments without a user are attributed to the reviewer's
+// account.
+func (s *Server) AddIssueComment(owner, repo string, number int, comment *gitea.IssueComment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if comment.User.Login == "" {
+		comment.User = botUser
+	}
+	s.nextCommentID++
+	comment.ID = s.nextCommentID
+	key := pullKey(owner, repo, number)
+	s.issueComments[key] = append(s.issueComments[key], comment)
+}
+
+// AddReaction scripts a user's reaction, such as "+1" or "-1", on a comment
+func (s *Server) AddReaction(commentID int64, login, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reactions[commentID] = append(s.reactions[commentID], gitea.Reaction{User: gitea.User{Login: login}, Content: content})
+}
+
+// AddCollaborator makes a user a collaborator on a repository
+func (s *Server) AddCollaborator(owner, repo, login string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collaborators[owner+"/"+repo+"/"+login] = true
+}
+
+// ReviewComments returns the line comments of every review posted on a pull
+// request, as edited so far
+func (s *Server) ReviewComments(owner, repo string, number int) []gitea.PullReviewComment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var comments []gitea.PullReviewComment
+	for i, review := range s.reviews {
+		if review.Owner != owner || review.Repo != repo || review.Number != number {
+			continue
+		}
+		for _, comment := range s.reviewComments[int64(i+1)] {
+			comments = append(comments, *comment)
+		}
+	}
+	return comments
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	forgetest.WriteJSON(w, http.StatusOK, &botUser)
+}
+
+func (s *Server) handleCollaborator(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	collaborator := s.collaborators[r.PathValue("owner")+"/"+r.PathValue("repo")+"/"+r.PathValue("user")]
+	s.mu.Unlock()
+
+	if !collaborator {
+		forgetest.WriteError(w, http.StatusNotFound, "user is not a collaborator")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRepository(w http.ResponseWriter, r *http.Request) {
+	owner, repo := r.PathValue("owner"), r.PathValue("repo")
+	forgetest.WriteJSON(w, http.StatusOK, &gitea.Repository{
+		Name:          repo,
+		FullName:      owner + "/" + repo,
+		DefaultBranch: "main",
+		Owner:         gitea.User{Login: owner},
+	})
+}
+
+// handlePullRequest serves a pull request, or its diff for NUMBER.diff
+func (s *Server) handlePullRequest(w http.ResponseWriter, r *http.Request) {
+	number, isDiff := strings.CutSuffix(r.PathValue("number"), ".diff")
+	pr := s.pullRequest(r.PathValue("owner"), r.PathValue("repo"), number)
+	if pr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "pull request does not exist")
+		return
+	}
+
+	if isDiff {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(pr.Diff))
+		return
+	}
+
+	files, err := gh.ParseUnifiedDiff(pr.Diff)
+	if err != nil {
+		forgetest.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	additions, deletions := 0, 0
+	for _, file := range files {
+		additions += file.GetAdditions()
+		deletions += file.GetDeletions()
+	}
+
+	forgetest.WriteJSON(w, http.StatusOK, &gitea.PullRequest{
+		Number:       pr.Number,
+		Title:        pr.Title,
+		Body:         pr.Body,
+		State:        "open",
+		User:         gitea.User{Login: pr.Author},
+		Base:         gitea.Branch{Ref: pr.BaseRef, SHA: pr.BaseSHA},
+		Head:         gitea.Branch{Ref: pr.HeadRef, SHA: pr.HeadSHA},
+		MergeBase:    pr.MergeBase,
+		Additions:    additions,
+		Deletions:    deletions,
+		ChangedFiles: len(files),
+	})
+}
+
+func (s *Server) handleCreateReview(w http.ResponseWriter, r *http.Request) {
+	pr := s.pullRequest(r.PathValue("owner"), r.PathValue("repo"), r.PathValue("number"))
+	if pr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "pull request does not exist")
+		return
+	}
+
+	var req gitea.CreateReview
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		forgetest.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	switch req.Event {
+	case gitea.StateApproved, gitea.StateRequestChanges, gitea.StateComment:
+	default:
+		forgetest.WriteError(w, http.StatusUnprocessableEntity, "unknown review event: "+req.Event)
+		return
+	}
+
+	// Comments must be on a file the pull request changes
+	files, _ := gh.ParseUnifiedDiff(pr.Diff)
+	for _, comment := range req.Comments {
+		found := false
+		for _, file := range files {
+			found = found || file.GetFilename() == comment.Path
+		}
+		if !found || comment.NewPosition <= 0 {
+			forgetest.WriteError(w, http.StatusUnprocessableEntity, "invalid review comment on "+comment.Path)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.reviews = append(s.reviews, Review{Owner: pr.Owner, Repo: pr.Repo, Number: pr.Number, Request: req})
+	id := len(s.reviews)
+	for _, comment := range req.Comments {
+		s.nextCommentID++
+		s.reviewComments[int64(id)] = append(s.reviewComments[int64(id)], &gitea.PullReviewComment{
+			ID:   s.nextCommentID,
+			Body: comment.Body,
+			Path: comment.Path,
+			User: botUser,
+		})
+	}
+	s.mu.Unlock()
+
+	forgetest.WriteJSON(w, http.StatusOK, map[string]any{"id": id, "state": req.Event, "body": req.Body})
+}
+
+func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	owner, repo := r.PathValue("owner"), r.PathValue("repo")
+	number, _ := strconv.Atoi(r.PathValue("number"))
+
+	var comment gitea.Comment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		forgetest.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	s.mu.Lock()
+	s.comments = append(s.comments, Comment{Owner: owner, Repo: repo, Number: number, Body: comment.Body})
+	s.nextCommentID++
+	key := pullKey(owner, repo, number)
+	s.issueComments[key] = append(s.issueComments[key], &gitea.IssueComment{ID: s.nextCommentID, Body: comment.Body, User: botUser})
+	s.mu.Unlock()
+
+	forgetest.WriteJSON(w, http.StatusCreated, &comment)
+}
+
+func (s *Server) handleListReviews(w http.ResponseWriter, r *http.Request) {
+	pr := s.pullRequest(r.PathValue("owner"), r.PathValue("repo"), r.PathValue("number"))
+	if pr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "pull request does not exist")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reviews := []gitea.PullReview{}
+	for i, review := range s.reviews {
+		if review.Owner == pr.Owner && review.Repo == pr.Repo && review.Number == pr.Number {
+			reviews = append(reviews, gitea.PullReview{ID: int64(i + 1), Body: review.Request.Body, State: review.Request.Event, User: botUser})
+		}
+	}
+	forgetest.WriteJSON(w, http.StatusOK, reviews)
+}
+
+func (s *Server) handleListReviewComments(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comments := []*gitea.PullReviewComment{}
+	comments = append(comments, s.reviewComments[id]...)
+	forgetest.WriteJSON(w, http.StatusOK, comments)
+}
+
+func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
+	number, _ := strconv.Atoi(r.PathValue("number"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comments := []*gitea.IssueComment{}
+	comments = append(comments, s.issueComments[pullKey(r.PathValue("owner"), r.PathValue("repo"), number)]...)
+	forgetest.WriteJSON(w, http.StatusOK, comments)
+}
+
+// handleEditComment edits a comment, which may be a review line comment
+func (s *Server) handleEditComment(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	var edit gitea.Comment
+	if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+		forgetest.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, comments := range s.reviewComments {
+		for _, comment := range comments {
+			if comment.ID == id {
+				comment.Body = edit.Body
+				forgetest.WriteJSON(w, http.StatusOK, comment)
+				return
+			}
+		}
+	}
+	for _, comments := range s.issueComments {
+		for _, comment := range comments {
+			if comment.ID == id {
+				comment.Body = edit.Body
+				forgetest.WriteJSON(w, http.StatusOK, comment)
+				return
+			}
+		}
+	}
+	forgetest.WriteError(w, http.StatusNotFound, "comment does not exist")
+}
+
+func (s *Server) handleReactions(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reactions := []gitea.Reaction{}
+	reactions = append(reactions, s.reactions[id]...)
+	forgetest.WriteJSON(w, http.StatusOK, reactions)
+}
+
+func (s *Server) handleRaw(w http.ResponseWriter, r *http.Request) {
+	tree := s.trees.Files(r.PathValue("owner")+"/"+r.PathValue("repo"), r.URL.Query().Get("ref"))
+	content, ok := tree[r.PathValue("path")]
+	if !ok {
+		forgetest.WriteError(w, http.StatusNotFound, "file does not exist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(content))
+}
+
+func (s *Server) handleContents(w http.ResponseWriter, r *http.Request) {
+	tree := s.trees.Files(r.PathValue("owner")+"/"+r.PathValue("repo"), r.URL.Query().Get("ref"))
+	dir := strings.Trim(r.PathValue("path"), "/")
+
+	entries := []gitea.ContentsEntry{}
+	for _, p := range forgetest.DirPaths(tree, dir) {
+		entries = append(entries, gitea.ContentsEntry{Name: path.Base(p), Path: p, Type: "file"})
+	}
+	if len(entries) == 0 {
+		forgetest.WriteError(w, http.StatusNotFound, "object does not exist")
+		return
+	}
+	forgetest.WriteJSON(w, http.StatusOK, entries)
+}
+
+// pullRequest returns the scripted pull request a request refers to
+func (s *Server) pullRequest(owner, repo, number string) *PullRequest {
+	n, _ := strconv.Atoi(number)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pulls[pullKey(owner, repo, n)]
+}
+
+// pullKey identifies a pull request
+func pullKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
@@ -0,0 +1,102 @@
+package gitea
+
+// Repository is a Gitea repository
+type Repository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+	HTMLURL       string `json:"html_url"`
+	Owner         User   `json:"owner"`
+}
+
+// User is a Gitea user or organization
+type User struct {
+	Login string `json:"login"`
+}
+
+// Branch is the base or head of a pull request
+type Branch struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+// PullRequest is a Gitea pull request
+type PullRequest struct {
+	Number       int    `json:"number"`
+	Title        string `json:"title"`
+	Body         string `json:"body"`
+	State        string `json:"state"`
+	Draft        bool   `json:"draft"`
+	HTMLURL      string `json:"html_url"`
+	User         User   `json:"user"`
+	Base         Branch `json:"base"`
+	Head         Branch `json:"head"`
+	MergeBase    string `json:"merge_base"`
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+	ChangedFiles int    `json:"changed_files"`
+}
+
+// ContentsEntry is a file or directory listed by the contents API
+type ContentsEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// Review states
+const (
+	StateApproved       = "APPROVED"
+	StateRequestChanges = "REQUEST_CHANGES"
+	StateComment        = "COMMENT"
+)
+
+// CreateReview is a new pull request review
+type CreateReview struct {
+	Body     string          `json:"body,omitempty"`
+	Event    string          `json:"event"`
+	CommitID string          `json:"commit_id,omitempty"`
+	Comments []ReviewComment `json:"comments,omitempty"`
+}
+
+// ReviewComment is a review comment on a line of the new version of a file
+type ReviewComment struct {
+	Path        string `json:"path"`
+	Body        string `json:"body"`
+	NewPosition int    `json:"new_position,omitempty"`
+	OldPosition int    `json:"old_position,omitempty"`
+}
+
+// Comment is an issue comment
+type Comment struct {
+	Body string `json:"body"`
+}
+
+// PullReview is a review listed on a pull request
+type PullReview struct {
+	ID    int64  `json:"id"`
+	Body  string `json:"body"`
+	State string `json:"state"`
+	User  User   `json:"user"`
+}
+
+// PullReviewComment is a line comment listed on a pull request review
+type PullReviewComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	Path string `json:"path"`
+	User User   `json:"user"`
+}
+
+// IssueComment is a general comment listed on a pull request
+type IssueComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	User User   `json:"user"`
+}
+
+// Reaction is a user's reaction to a comment, such as "+1" or "-1"
+type Reaction struct {
+	User    User   `json:"user"`
+	Content string `json:"content"`
+}
@@ -0,0 +1,37 @@
+package gitea
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParsePullRequestURL extracts the owner, repository and number from a pull
+// request URL such as https://codeberg.org/owner/repo/pulls/12. The URL must
+// be served from host.
+func ParsePullRequestURL(raw, host string) (owner, repo string, number int, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid pull request URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", "", 0, fmt.Errorf("invalid pull request URL: %s", raw)
+	}
+	if !strings.EqualFold(u.Host, host) {
+		return "", "", 0, fmt.Errorf("pull request URL host %s is not the configured Gitea host %s", u.Host, host)
+	}
+
+	// Trailing segments such as /files are ignored
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[2] != "pulls" {
+		return "", "", 0, fmt.Errorf("not a pull request URL: %s", raw)
+	}
+
+	number, err = strconv.Atoi(parts[3])
+	if err != nil || number <= 0 {
+		return "", "", 0, fmt.Errorf("invalid pull request number in %s", raw)
+	}
+
+	return parts[0], parts[1], number, nil
+}
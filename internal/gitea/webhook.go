@@ -0,0 +1,79 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PullRequestEvent is the event header value of pull request webhooks
+const PullRequestEvent = "pull_request"
+
+// PullRequestHook is the payload of a pull request webhook
+type PullRequestHook struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+}
+
+// ReadWebhookPayload validates the HMAC-SHA256 signature of a webhook
+// delivery and returns its payload. Forgejo sends its own headers alongside
+// or instead of Gitea's; either is accepted.
+func ReadWebhookPayload(r *http.Request, secret []byte) ([]byte, error) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+
+	signature := r.Header.Get("X-Forgejo-Signature")
+	if signature == "" {
+		signature = r.Header.Get("X-Gitea-Signature")
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil || len(secret) == 0 {
+		return nil, fmt.Errorf("missing or invalid webhook signature")
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return nil, fmt.Errorf("webhook signature does not match")
+	}
+	return payload, nil
+}
+
+// ParsePullRequestHook parses a pull request webhook payload
+func ParsePullRequestHook(r *http.Request, payload []byte) (*PullRequestHook, error) {
+	event := r.Header.Get("X-Forgejo-Event")
+	if event == "" {
+		event = r.Header.Get("X-Gitea-Event")
+	}
+	if event != PullRequestEvent {
+		return nil, fmt.Errorf("unsupported Gitea event: %q", event)
+	}
+
+	var hook PullRequestHook
+	if err := json.Unmarshal(payload, &hook); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+	if hook.Number == 0 || hook.Repository.Name == "" {
+		return nil, fmt.Errorf("not a pull request webhook payload")
+	}
+	return &hook, nil
+}
+
+// ShouldReview determines if the hook should trigger a review: pull requests
+// that are opened, reopened, or pushed to
+func (h *PullRequestHook) ShouldReview() bool {
+	switch h.Action {
+	case "opened", "reopened", "synchronized":
+		return true
+	default:
+		return false
+	}
+}
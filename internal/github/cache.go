@@ -0,0 +1,264 @@
+package github
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BlobCache stores file contents keyed by repository and blob SHA. Blobs are
+// content addressed, so entries never need invalidating.
+type BlobCache interface {
+	// Get returns the cached content for a key
+	Get(key string) (string, bool)
+
+	// Put stores content for a key
+	Put(key, content string)
+}
+
+// NewBlobCache creates a cache holding up to maxBytes, on disk in dir or in
+// memory when dir is empty
+func NewBlobCache(dir string, maxBytes int) (BlobCache, error) {
+	if dir != "" {
+		return NewDiskBlobCache(dir, maxBytes)
+	}
+	return NewMemoryBlobCache(maxBytes), nil
+}
+
+// blobCacheKey builds the cache key for a blob in a repository
+func blobCacheKey(owner, repo, sha string) string {
+	return owner + "/" + repo + "@" + sha
+}
+
+// MemoryBlobCache is an in-memory BlobCache that evicts the least recently
+// used blobs once it holds more than maxBytes
+type MemoryBlobCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// memoryEntry is a cached blob in a MemoryBlobCache
+type memoryEntry struct {
+	key     string
+	content string
+}
+
+// NewMemoryBlobCache creates an in-memory blob cache holding up to maxBytes
+func NewMemoryBlobCache(maxBytes int) *MemoryBlobCache {
+	return &MemoryBlobCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached content for a key
+func (c *MemoryBlobCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*memoryEntry).content, true
+}
+
+// Put stores content for a key, evicting old entries to stay within the size limit
+func (c *MemoryBlobCache) Put(key, content string) {
+	if len(content) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, content: content})
+	c.size += len(content)
+
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*memoryEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.content)
+	}
+}
+
+// DiskBlobCache is a BlobCache that stores blobs as files under a directory.
+// Once the blobs take more than maxBytes, the least recently used ones are
+// removed; file modification times record use across restarts.
+type DiskBlobCache struct {
+	dir      string
+	maxBytes int
+
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// diskEntry is a cached blob file in a DiskBlobCache
+type diskEntry struct {
+	path string
+	size int
+}
+
+// NewDiskBlobCache creates a blob cache in dir holding up to maxBytes,
+// creating the directory if needed and indexing the blobs already in it
+func NewDiskBlobCache(dir string, maxBytes int) (*DiskBlobCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	type blob struct {
+		diskEntry
+		used time.Time
+	}
+	var blobs []blob
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Blobs are fanned out into subdirectories, and temporary files are
+		// left behind only by interrupted writes
+		if entry.IsDir() || filepath.Dir(path) == filepath.Clean(dir) || strings.HasPrefix(entry.Name(), "blob-") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, blob{diskEntry{path: path, size: int(info.Size())}, info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index blob cache: %w", err)
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].used.Before(blobs[j].used) })
+
+	c := &DiskBlobCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+	for _, blob := range blobs {
+		c.entries[blob.path] = c.order.PushFront(&blob.diskEntry)
+		c.size += blob.size
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict()
+	return c, nil
+}
+
+// Get returns the cached content for a key, marking it as recently used
+func (c *DiskBlobCache) Get(key string) (string, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		log.Printf("Failed to mark blob cache entry as used: %v", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[path]; ok {
+		c.order.MoveToFront(element)
+	}
+	return string(data), true
+}
+
+// Put stores content for a key, writing through a temporary file so readers
+// never see a partial blob
+func (c *DiskBlobCache) Put(key, content string) {
+	if len(content) > c.maxBytes {
+		return
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Printf("Failed to create blob cache directory: %v", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "blob-*")
+	if err != nil {
+		log.Printf("Failed to write blob cache entry: %v", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		log.Printf("Failed to write blob cache entry: %v", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		log.Printf("Failed to write blob cache entry: %v", err)
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		log.Printf("Failed to write blob cache entry: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[path]; ok {
+		// Blobs are content addressed, so the size is unchanged
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[path] = c.order.PushFront(&diskEntry{path: path, size: len(content)})
+	c.size += len(content)
+	c.evict()
+}
+
+// evict removes the least recently used blobs until the cache fits in
+// maxBytes. The caller must hold the lock.
+func (c *DiskBlobCache) evict() {
+	for c.size > c.maxBytes && c.order.Len() > 0 {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*diskEntry)
+		if err := os.Remove(entry.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Failed to evict blob cache entry: %v", err)
+			return
+		}
+		c.order.Remove(oldest)
+		delete(c.entries, entry.path)
+		c.size -= entry.size
+	}
+}
+
+// path returns the file a key is stored in, fanned out by hash prefix
+func (c *DiskBlobCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name[:2], name[2:])
+}
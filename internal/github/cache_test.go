@@ -0,0 +1,51 @@
+package github_test
+
+import (
+	"testing"
+
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+func TestDiskBlobCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := gh.NewDiskBlobCache(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Put("a", "aaaa")
+	cache.Put("b", "bbbb")
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("a is not cached")
+	}
+	// b is now the least recently used blob
+	cache.Put("c", "cccc")
+	cache.Put("huge", "more than the whole cache")
+
+	for _, tt := range []struct {
+		key    string
+		cached bool
+	}{
+		{"a", true},
+		{"b", false},
+		{"huge", false},
+		{"c", true},
+	} {
+		if _, ok := cache.Get(tt.key); ok != tt.cached {
+			t.Errorf("%s cached: %t, want %t", tt.key, ok, tt.cached)
+		}
+	}
+
+	// Reopening with a smaller limit evicts down to it, keeping the most
+	// recently used blob
+	cache, err = gh.NewDiskBlobCache(dir, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Error("c was evicted")
+	}
+	if _, ok := cache.Get("a"); ok {
+		t.Error("a was kept")
+	}
+}
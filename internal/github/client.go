@@ -2,6 +2,15 @@ package github
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
 
 	"github.com/google/go-github/v74/github"
 	"golang.org/x/oauth2"
@@ -9,20 +18,130 @@ import (
 
 // Client wraps the GitHub API client with our application-specific methods
 type Client struct {
-	client *github.Client
+	client    *github.Client
+	cache     BlobCache
+	rateLimit *RateLimitTransport
+}
+
+// Options configures the GitHub API the client talks to
+type Options struct {
+	// BaseURL and UploadURL point the client at a GitHub Enterprise Server
+	// instance, e.g. https://github.example.edu/api/v3/. UploadURL defaults
+	// to BaseURL.
+	BaseURL   string
+	UploadURL string
+
+	// CABundle is the path of a PEM file of additional trusted certificate
+	// authorities, for servers with internal certificates
+	CABundle string
 }
 
 // NewClient creates a new GitHub client with authentication
 func NewClient(token string) *Client {
-	ctx := context.Background()
+	client, _ := NewClientWithOptions(token, Options{})
+	return client
+}
+
+// NewClientWithOptions creates a GitHub client for github.com or a GitHub
+// Enterprise Server instance
+func NewClientWithOptions(token string, opts Options) (*Client, error) {
+	var base http.RoundTripper
+	if opts.CABundle != "" {
+		httpClient, err := caBundleClient(opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		base = httpClient.Transport
+	}
+
+	rateLimit := NewRateLimitTransport(base)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: rateLimit})
+
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
 
-	return &Client{
-		client: github.NewClient(tc),
+	client := github.NewClient(tc)
+	if opts.BaseURL != "" {
+		uploadURL := opts.UploadURL
+		if uploadURL == "" {
+			uploadURL = opts.BaseURL
+		}
+
+		var err error
+		client, err = client.WithEnterpriseURLs(opts.BaseURL, uploadURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub Enterprise URL: %w", err)
+		}
 	}
+
+	// The transport tracks and waits out rate limits itself. go-github's own
+	// check would fail requests before they reach it.
+	client.DisableRateLimitCheck = true
+
+	return &Client{client: client, rateLimit: rateLimit}, nil
+}
+
+// RateLimit returns the API quota reported by the last response
+func (c *Client) RateLimit() Quota {
+	if c.rateLimit == nil {
+		return Quota{}
+	}
+	return c.rateLimit.Quota()
+}
+
+// caBundleClient returns an HTTP client trusting the system roots and the
+// certificates in a PEM file
+func caBundleClient(path string) (*http.Client, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
+
+// SetBaseURL points the client at a different API root, such as a test server
+func (c *Client) SetBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid GitHub base URL: %w", err)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	c.client.BaseURL = u
+	return nil
+}
+
+// IsNotFound reports whether err is a GitHub 404 response
+func IsNotFound(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound
+}
+
+// IsUnprocessable reports whether err is a GitHub 422 response, such as a
+// review with a comment outside the diff
+func IsUnprocessable(err error) bool {
+	var errResp *github.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusUnprocessableEntity
+}
+
+// SetBlobCache sets the cache used for blob contents. A single cache can be
+// shared by every review the process runs.
+func (c *Client) SetBlobCache(cache BlobCache) {
+	c.cache = cache
 }
 
 // GetPRFiles retrieves all files changed in a pull request
@@ -41,20 +160,236 @@ func (c *Client) GetFileContent(ctx context.Context, owner, repo, path, ref stri
 		return "", err
 	}
 
+	// The Contents API omits files over 1 MB, which the Git Blob API still serves
+	if fileContent.GetEncoding() == "none" {
+		return c.GetBlobContent(ctx, owner, repo, fileContent.GetSHA())
+	}
+
 	content, err := fileContent.GetContent()
 	if err != nil {
 		return "", err
 	}
 
+	if c.cache != nil && fileContent.GetSHA() != "" {
+		c.cache.Put(blobCacheKey(owner, repo, fileContent.GetSHA()), content)
+	}
+
+	return content, nil
+}
+
+// GetBlobContent retrieves the content of a blob by SHA, using the blob cache if set
+func (c *Client) GetBlobContent(ctx context.Context, owner, repo, sha string) (string, error) {
+	key := blobCacheKey(owner, repo, sha)
+	if c.cache != nil {
+		if content, ok := c.cache.Get(key); ok {
+			return content, nil
+		}
+	}
+
+	data, _, err := c.client.Git.GetBlobRaw(ctx, owner, repo, sha)
+	if err != nil {
+		return "", err
+	}
+
+	content := string(data)
+	if c.cache != nil {
+		c.cache.Put(key, content)
+	}
+
 	return content, nil
 }
 
+// GetTree retrieves the blob SHA of every file at a commit, keyed by path.
+// truncated is true when the repository is too large for a single listing.
+func (c *Client) GetTree(ctx context.Context, owner, repo, ref string) (blobs map[string]string, truncated bool, err error) {
+	tree, _, err := c.client.Git.GetTree(ctx, owner, repo, ref, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	blobs = make(map[string]string, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			blobs[entry.GetPath()] = entry.GetSHA()
+		}
+	}
+
+	return blobs, tree.GetTruncated(), nil
+}
+
+// ListDirectory returns the paths of the files in a directory at a specific commit
+func (c *Client) ListDirectory(ctx context.Context, owner, repo, path, ref string) ([]string, error) {
+	_, dirContent, _, err := c.client.Repositories.GetContents(
+		ctx, owner, repo, path,
+		&github.RepositoryContentGetOptions{Ref: ref},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range dirContent {
+		if entry.GetType() == "file" {
+			paths = append(paths, entry.GetPath())
+		}
+	}
+
+	return paths, nil
+}
+
+// DownloadTarball streams a gzipped tarball of the repository at ref
+func (c *Client) DownloadTarball(ctx context.Context, owner, repo, ref string) (io.ReadCloser, error) {
+	link, _, err := c.client.Repositories.GetArchiveLink(
+		ctx, owner, repo, github.Tarball,
+		&github.RepositoryContentGetOptions{Ref: ref}, 3,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("tarball download returned status %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
 // CreateReview creates a pull request review with comments
 func (c *Client) CreateReview(ctx context.Context, owner, repo string, prNumber int, review *github.PullRequestReviewRequest) error {
 	_, _, err := c.client.PullRequests.CreateReview(ctx, owner, repo, prNumber, review)
 	return err
 }
 
+// CreatePendingReview creates an unsubmitted review with comments and returns its ID
+func (c *Client) CreatePendingReview(ctx context.Context, owner, repo string, prNumber int, comments []*github.DraftReviewComment) (int64, error) {
+	review, _, err := c.client.PullRequests.CreateReview(ctx, owner, repo, prNumber, &github.PullRequestReviewRequest{
+		Comments: comments,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return review.GetID(), nil
+}
+
+// DeletePendingReview deletes an unsubmitted review
+func (c *Client) DeletePendingReview(ctx context.Context, owner, repo string, prNumber int, reviewID int64) error {
+	_, _, err := c.client.PullRequests.DeletePendingReview(ctx, owner, repo, prNumber, reviewID)
+	return err
+}
+
+// ListReviewComments retrieves every inline review comment on a pull request
+func (c *Client) ListReviewComments(ctx context.Context, owner, repo string, prNumber int) ([]*github.PullRequestComment, error) {
+	var all []*github.PullRequestComment
+	opts := &github.PullRequestListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.client.PullRequests.ListComments(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListReviews retrieves every review of a pull request
+func (c *Client) ListReviews(ctx context.Context, owner, repo string, prNumber int) ([]*github.PullRequestReview, error) {
+	var all []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := c.client.PullRequests.ListReviews(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, reviews...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListIssueComments retrieves every general comment on a pull request
+func (c *Client) ListIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*github.IssueComment, error) {
+	var all []*github.IssueComment
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.client.Issues.ListComments(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListReviewCommentReactions retrieves every reaction on an inline review comment
+func (c *Client) ListReviewCommentReactions(ctx context.Context, owner, repo string, commentID int64) ([]*github.Reaction, error) {
+	var all []*github.Reaction
+	opts := &github.ListReactionOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		reactions, resp, err := c.client.Reactions.ListPullRequestCommentReactions(ctx, owner, repo, commentID, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, reactions...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// IsCollaborator reports whether a user is a collaborator on a repository
+func (c *Client) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	collaborator, _, err := c.client.Repositories.IsCollaborator(ctx, owner, repo, user)
+	return collaborator, err
+}
+
+// EditReviewComment replaces the body of an inline review comment
+func (c *Client) EditReviewComment(ctx context.Context, owner, repo string, commentID int64, body string) error {
+	_, _, err := c.client.PullRequests.EditComment(ctx, owner, repo, commentID, &github.PullRequestComment{Body: &body})
+	return err
+}
+
+// MergeBase returns the best common ancestor of two commits
+func (c *Client) MergeBase(ctx context.Context, owner, repo, base, head string) (string, error) {
+	comparison, _, err := c.client.Repositories.CompareCommits(ctx, owner, repo, base, head, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return "", err
+	}
+	sha := comparison.GetMergeBaseCommit().GetSHA()
+	if sha == "" {
+		return "", fmt.Errorf("%s and %s have no merge base", base, head)
+	}
+	return sha, nil
+}
+
+// AuthenticatedLogin returns the login of the token's user. App installation
+// tokens have no user and fail.
+func (c *Client) AuthenticatedLogin(ctx context.Context) (string, error) {
+	user, _, err := c.client.Users.Get(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return user.GetLogin(), nil
+}
+
 // CreateIssueComment creates a general comment on the pull request
 func (c *Client) CreateIssueComment(ctx context.Context, owner, repo string, prNumber int, body string) error {
 	comment := &github.IssueComment{
@@ -1,111 +1,450 @@
 package github
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/google/go-github/v74/github"
 )
 
-// CalculateDiffPosition converts a line number to a diff position for GitHub API
-func CalculateDiffPosition(file *github.CommitFile, lineNumber int) int {
-	patch := file.GetPatch()
+// LineKind is the kind of a line in a hunk
+type LineKind int
+
+// Line kinds
+const (
+	LineContext LineKind = iota
+	LineAdded
+	LineDeleted
+)
+
+// PatchLine is a line of a hunk
+type PatchLine struct {
+	Kind LineKind
+	Text string
+
+	// OldLine and NewLine are the line's numbers in the base and head
+	// versions of the file, zero for added and deleted lines respectively
+	OldLine int
+	NewLine int
+
+	// Position is the diff position GitHub anchors review comments to
+	Position int
+
+	// NoNewline is true for a last line without a trailing newline
+	NoNewline bool
+}
+
+// Hunk is a hunk of a patch
+type Hunk struct {
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+
+	// Section is the text after the hunk header, often the enclosing function
+	Section string
+
+	Lines []PatchLine
+}
+
+// NewRange returns the head file line range the hunk covers. A pure deletion
+// hunk still anchors to the line it was removed before.
+func (h *Hunk) NewRange() LineRange {
+	r := LineRange{Start: h.NewStart, End: h.NewStart}
+	for _, line := range h.Lines {
+		if line.Kind != LineDeleted {
+			r.End = line.NewLine
+		}
+	}
+	return r
+}
+
+// OldRange returns the base file line range the hunk covers. A pure addition
+// hunk anchors to the line it was added after, or the first line.
+func (h *Hunk) OldRange() LineRange {
+	start := max(h.OldStart, 1)
+	r := LineRange{Start: start, End: start}
+	for _, line := range h.Lines {
+		if line.Kind != LineAdded {
+			r.End = line.OldLine
+		}
+	}
+	return r
+}
+
+// Patch is a parsed unified diff of a single file, as GitHub reports in a
+// pull request's file list
+type Patch struct {
+	Hunks []Hunk
+
+	// Lines indexed by their number in each version of the file
+	newLines map[int]PatchLine
+	oldLines map[int]PatchLine
+}
+
+// hunkHeader matches a hunk header and its optional section heading
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// ParsePatch parses the hunks of a single file patch. Lines before the first
+// hunk, such as git's file headers, are skipped.
+func ParsePatch(patch string) (*Patch, error) {
+	p := &Patch{
+		newLines: make(map[int]PatchLine),
+		oldLines: make(map[int]PatchLine),
+	}
 	if patch == "" {
-		return -1
+		return p, nil
 	}
 
-	lines := strings.Split(patch, "\n")
-	position := 0
-	newLineNumber := 0
+	var hunk *Hunk
+	position, oldLine, newLine := 0, 0, 0
+	oldLeft, newLeft := 0, 0
 
-	for _, line := range lines {
-		if strings.HasPrefix(line, "@@") {
-			// Parse hunk header: @@ -old_start,old_count +new_start,new_count @@
-			newStart := parseHunkHeader(line)
-			if newStart > 0 {
-				newLineNumber = newStart - 1 // Adjust for 0-based indexing
+	for _, text := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(text, "@@") {
+			match := hunkHeader.FindStringSubmatch(text)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header: %q", text)
+			}
+
+			p.Hunks = append(p.Hunks, Hunk{
+				OldStart: hunkNumber(match[1], 0),
+				OldCount: hunkNumber(match[2], 1),
+				NewStart: hunkNumber(match[3], 0),
+				NewCount: hunkNumber(match[4], 1),
+				Section:  match[5],
+			})
+			hunk = &p.Hunks[len(p.Hunks)-1]
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			oldLeft, newLeft = hunk.OldCount, hunk.NewCount
+
+			// Positions count from the line below the first hunk header;
+			// later hunk headers take up a position of their own
+			if position > 0 {
+				position++
 			}
-			position++
 			continue
 		}
 
-		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "+") {
-			// Context line or addition
-			newLineNumber++
-			position++
+		if hunk == nil {
+			continue
+		}
 
-			if newLineNumber == lineNumber {
-				return position
+		line := PatchLine{}
+		switch {
+		case strings.HasPrefix(text, `\`):
+			// "\ No newline at end of file" applies to the line above it
+			if n := len(hunk.Lines); n > 0 {
+				hunk.Lines[n-1].NoNewline = true
+				p.index(hunk.Lines[n-1])
 			}
-		} else if strings.HasPrefix(line, "-") {
-			// Deletion - increment position but not line number
 			position++
+			continue
+		case strings.HasPrefix(text, "+"):
+			line = PatchLine{Kind: LineAdded, Text: text[1:], NewLine: newLine}
+			newLine++
+			newLeft--
+		case strings.HasPrefix(text, "-"):
+			line = PatchLine{Kind: LineDeleted, Text: text[1:], OldLine: oldLine}
+			oldLine++
+			oldLeft--
+		case strings.HasPrefix(text, " "), text == "" && oldLeft > 0 && newLeft > 0:
+			// Some tools strip the space from empty context lines
+			line = PatchLine{Kind: LineContext, Text: strings.TrimPrefix(text, " "), OldLine: oldLine, NewLine: newLine}
+			oldLine++
+			newLine++
+			oldLeft--
+			newLeft--
+		default:
+			continue
 		}
+
+		position++
+		line.Position = position
+		hunk.Lines = append(hunk.Lines, line)
+		p.index(line)
 	}
 
-	return -1 // Line not found in diff
+	return p, nil
 }
 
-// parseHunkHeader extracts the new file starting line number from a hunk header
-func parseHunkHeader(hunkHeader string) int {
-	// Format: @@ -old_start,old_count +new_start,new_count @@
-	parts := strings.Split(hunkHeader, " ")
-	if len(parts) < 3 {
-		return 0
+// FilePatch parses the patch of a pull request file. A malformed patch is
+// logged and treated as empty.
+func FilePatch(file *github.CommitFile) *Patch {
+	patch, err := ParsePatch(file.GetPatch())
+	if err != nil {
+		log.Printf("Failed to parse patch of %s: %v", file.GetFilename(), err)
+		patch, _ = ParsePatch("")
 	}
+	return patch
+}
 
-	newPart := parts[2] // +new_start,new_count
-	if !strings.HasPrefix(newPart, "+") {
-		return 0
+// index records a line under its old and new line numbers
+func (p *Patch) index(line PatchLine) {
+	if line.Kind != LineDeleted {
+		p.newLines[line.NewLine] = line
 	}
+	if line.Kind != LineAdded {
+		p.oldLines[line.OldLine] = line
+	}
+}
 
-	var newStart int
-	fmt.Sscanf(newPart, "+%d", &newStart)
-	return newStart
+// NewLine returns the context or added line with a head file line number
+func (p *Patch) NewLine(n int) (PatchLine, bool) {
+	line, ok := p.newLines[n]
+	return line, ok
 }
 
-// GetChangedLines returns the line numbers that were changed in the file
-func GetChangedLines(file *github.CommitFile) []int {
-	patch := file.GetPatch()
-	if patch == "" {
-		return nil
+// OldLine returns the context or deleted line with a base file line number
+func (p *Patch) OldLine(n int) (PatchLine, bool) {
+	line, ok := p.oldLines[n]
+	return line, ok
+}
+
+// NewPosition returns the diff position of a head file line, or -1 if the
+// patch doesn't show it
+func (p *Patch) NewPosition(n int) int {
+	if line, ok := p.newLines[n]; ok {
+		return line.Position
 	}
+	return -1
+}
 
-	var changedLines []int
-	lines := strings.Split(patch, "\n")
-	newLineNumber := 0
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "@@") {
-			// Parse hunk header to get starting line number
-			newStart := parseHunkHeader(line)
-			if newStart > 0 {
-				newLineNumber = newStart - 1
+// OldPosition returns the diff position of a base file line, or -1 if the
+// patch doesn't show it
+func (p *Patch) OldPosition(n int) int {
+	if line, ok := p.oldLines[n]; ok {
+		return line.Position
+	}
+	return -1
+}
+
+// IsAdded reports whether a head file line was added by the patch
+func (p *Patch) IsAdded(n int) bool {
+	line, ok := p.newLines[n]
+	return ok && line.Kind == LineAdded
+}
+
+// AddedLines returns the added lines in order
+func (p *Patch) AddedLines() []PatchLine {
+	var added []PatchLine
+	for _, hunk := range p.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == LineAdded {
+				added = append(added, line)
 			}
-			continue
 		}
+	}
+	return added
+}
 
-		if strings.HasPrefix(line, " ") {
-			// Context line
-			newLineNumber++
-		} else if strings.HasPrefix(line, "+") {
-			// Addition
-			newLineNumber++
-			changedLines = append(changedLines, newLineNumber)
-		}
-		// Deletions don't increment newLineNumber
+// hunkNumber parses an optional hunk header number
+func hunkNumber(s string, defaultValue int) int {
+	if s == "" {
+		return defaultValue
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// CalculateDiffPosition converts a line number to a diff position for GitHub
+// API. The line just below the first hunk header is position 1. Earlier
+// versions counted that header too, which anchored every comment one line
+// below the line it was meant for.
+func CalculateDiffPosition(file *github.CommitFile, lineNumber int) int {
+	return FilePatch(file).NewPosition(lineNumber)
+}
+
+// AddedLine is a line added by a patch
+type AddedLine struct {
+	Line int
+	Text string
+}
+
+// GetAddedLines returns the line numbers and text of the lines added in the file
+func GetAddedLines(file *github.CommitFile) []AddedLine {
+	var added []AddedLine
+	for _, line := range FilePatch(file).AddedLines() {
+		added = append(added, AddedLine{Line: line.NewLine, Text: line.Text})
 	}
+	return added
+}
 
+// GetChangedLines returns the line numbers that were changed in the file
+func GetChangedLines(file *github.CommitFile) []int {
+	var changedLines []int
+	for _, line := range FilePatch(file).AddedLines() {
+		changedLines = append(changedLines, line.NewLine)
+	}
 	return changedLines
 }
 
 // IsLineInDiff checks if a specific line number is part of the diff
 func IsLineInDiff(file *github.CommitFile, lineNumber int) bool {
-	changedLines := GetChangedLines(file)
-	for _, line := range changedLines {
-		if line == lineNumber {
-			return true
+	return FilePatch(file).IsAdded(lineNumber)
+}
+
+// LineRange is an inclusive range of line numbers in the new file version
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Contains reports whether the line falls within the range
+func (r LineRange) Contains(line int) bool {
+	return line >= r.Start && line <= r.End
+}
+
+// GetHunkRanges returns the new file line range covered by each hunk in the patch
+func GetHunkRanges(file *github.CommitFile) []LineRange {
+	var ranges []LineRange
+	for _, hunk := range FilePatch(file).Hunks {
+		ranges = append(ranges, hunk.NewRange())
+	}
+	return ranges
+}
+
+// GetBaseHunkRanges returns the base file line range covered by each hunk in the patch
+func GetBaseHunkRanges(file *github.CommitFile) []LineRange {
+	var ranges []LineRange
+	for _, hunk := range FilePatch(file).Hunks {
+		ranges = append(ranges, hunk.OldRange())
+	}
+	return ranges
+}
+
+// ParseUnifiedDiff splits a unified diff, as produced by git diff, into the
+// per-file patches GitHub reports for a pull request
+func ParseUnifiedDiff(diff string) ([]*github.CommitFile, error) {
+	var files []*github.CommitFile
+	var file *github.CommitFile
+	var patch strings.Builder
+	oldPath, gitPath := "", ""
+	headerDone := false
+	oldLeft, newLeft := 0, 0
+
+	start := func() {
+		file = &github.CommitFile{Status: github.Ptr("modified")}
+		oldPath = ""
+		headerDone = false
+		patch.Reset()
+	}
+	flush := func() {
+		if file == nil {
+			return
+		}
+		if file.GetStatus() != "renamed" {
+			file.PreviousFilename = nil
+		}
+		if file.Filename == nil {
+			file.Filename = github.Ptr(oldPath)
+		}
+		// Binary and mode-only changes have no ---/+++ lines to name the file
+		if file.GetFilename() == "" {
+			file.Filename = github.Ptr(gitPath)
+		}
+		file.Patch = github.Ptr(strings.TrimSuffix(patch.String(), "\n"))
+		file.Additions = github.Ptr(file.GetAdditions())
+		file.Deletions = github.Ptr(file.GetDeletions())
+		file.Changes = github.Ptr(file.GetAdditions() + file.GetDeletions())
+		files = append(files, file)
+		file = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Hunk bodies are consumed by count, so "--- " inside one is content
+		if oldLeft > 0 || newLeft > 0 {
+			patch.WriteString(line + "\n")
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+				file.Additions = github.Ptr(file.GetAdditions() + 1)
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+				file.Deletions = github.Ptr(file.GetDeletions() + 1)
+			case strings.HasPrefix(line, `\`):
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			start()
+			// The new name follows the last " b/"; names with spaces are ambiguous
+			if i := strings.LastIndex(line, " b/"); i >= 0 {
+				gitPath = line[i+3:]
+			}
+		case file != nil && !headerDone && strings.HasPrefix(line, "new file mode "):
+			file.Status = github.Ptr("added")
+		case file != nil && !headerDone && strings.HasPrefix(line, "deleted file mode "):
+			file.Status = github.Ptr("removed")
+		case strings.HasPrefix(line, "--- "):
+			// Plain unified diffs have no "diff --git" line between files
+			if file == nil || headerDone {
+				flush()
+				start()
+			}
+			oldPath = diffPath(line[4:])
+			if oldPath == "" {
+				file.Status = github.Ptr("added")
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if file == nil {
+				return nil, fmt.Errorf("invalid diff: %q without a preceding ---", line)
+			}
+			headerDone = true
+			if name := diffPath(line[4:]); name != "" {
+				file.Filename = github.Ptr(name)
+			} else {
+				file.Status = github.Ptr("removed")
+			}
+		case file != nil && strings.HasPrefix(line, "rename from "):
+			file.Status = github.Ptr("renamed")
+			file.PreviousFilename = github.Ptr(strings.TrimPrefix(line, "rename from "))
+		case file != nil && strings.HasPrefix(line, "rename to "):
+			file.Filename = github.Ptr(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "@@"):
+			if file == nil {
+				return nil, errors.New("invalid diff: hunk outside a file")
+			}
+			match := hunkHeader.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header: %q", line)
+			}
+			oldLeft, newLeft = hunkNumber(match[2], 1), hunkNumber(match[4], 1)
+			patch.WriteString(line + "\n")
 		}
 	}
-	return false
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+	flush()
+
+	return files, nil
+}
+
+// diffPath strips the a/ or b/ prefix from a ---/+++ path, returning an empty
+// string for /dev/null
+func diffPath(name string) string {
+	name, _, _ = strings.Cut(name, "\t")
+	if name == "/dev/null" {
+		return ""
+	}
+	if len(name) > 2 && (strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/")) {
+		return name[2:]
+	}
+	return name
 }
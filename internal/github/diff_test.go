@@ -0,0 +1,137 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// twoHunks changes lines 2 and 20 and removes the trailing newline
+const twoHunks = `@@ -1,3 +1,3 @@ package main
+ import "fmt"
+-var a = 1
++var a = 2
+
+@@ -19,2 +19,3 @@ func main() {
+ 	fmt.Println(a)
+-}
+\ No newline at end of file
++	fmt.Println(a + 1)
++}`
+
+func TestParsePatch(t *testing.T) {
+	patch, err := gh.ParsePatch(twoHunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patch.Hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(patch.Hunks))
+	}
+	hunk := patch.Hunks[1]
+	if hunk.OldStart != 19 || hunk.OldCount != 2 || hunk.NewStart != 19 || hunk.NewCount != 3 || hunk.Section != "func main() {" {
+		t.Errorf("unexpected second hunk header %+v", hunk)
+	}
+	if r := hunk.NewRange(); r != (gh.LineRange{Start: 19, End: 21}) {
+		t.Errorf("NewRange = %+v, want 19-21", r)
+	}
+
+	// The empty context line keeps its place without a leading space
+	if line, ok := patch.NewLine(3); !ok || line.Kind != gh.LineContext || line.OldLine != 3 {
+		t.Errorf("line 3 = %+v, %v; want the context line", line, ok)
+	}
+
+	if deleted, ok := patch.OldLine(20); !ok || !deleted.NoNewline || deleted.Kind != gh.LineDeleted {
+		t.Errorf("old line 20 = %+v, %v; want a deleted line without a newline", deleted, ok)
+	}
+
+	positions := []struct {
+		line, position int
+	}{
+		{1, 1}, {2, 3}, {3, 4},
+		// The second header and the no newline marker take a position each
+		{19, 6}, {20, 9}, {21, 10},
+		{4, -1}, {22, -1},
+	}
+	for _, tt := range positions {
+		if got := patch.NewPosition(tt.line); got != tt.position {
+			t.Errorf("NewPosition(%d) = %d, want %d", tt.line, got, tt.position)
+		}
+	}
+	if got := patch.OldPosition(2); got != 2 {
+		t.Errorf("OldPosition(2) = %d, want 2", got)
+	}
+
+	var added []int
+	for _, line := range patch.AddedLines() {
+		added = append(added, line.NewLine)
+	}
+	if len(added) != 3 || added[0] != 2 || added[1] != 20 || added[2] != 21 {
+		t.Errorf("added lines = %v, want [2 20 21]", added)
+	}
+	if patch.IsAdded(19) || !patch.IsAdded(20) {
+		t.Error("IsAdded does not match the added lines")
+	}
+}
+
+func TestParsePatchInvalidHeader(t *testing.T) {
+	if _, err := gh.ParsePatch("@@ -1 +x @@\n+a"); err == nil {
+		t.Error("expected an error for an invalid hunk header")
+	}
+}
+
+func FuzzParsePatch(f *testing.F) {
+	f.Add(twoHunks)
+	f.Add("@@ -0,0 +1 @@\n+only")
+	f.Add("@@ -1,2 +0,0 @@\n-a\n-b")
+	f.Add("diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		patch, err := gh.ParsePatch(text)
+		if err != nil {
+			return
+		}
+
+		last := 0
+		for _, hunk := range patch.Hunks {
+			for _, line := range hunk.Lines {
+				if line.Position <= last {
+					t.Fatalf("position %d follows %d", line.Position, last)
+				}
+				last = line.Position
+
+				if line.Kind != gh.LineDeleted {
+					if found, ok := patch.NewLine(line.NewLine); !ok || found.NewLine != line.NewLine || found.Kind == gh.LineDeleted {
+						t.Fatalf("new line %d is not indexed", line.NewLine)
+					}
+				}
+				if line.Kind != gh.LineAdded {
+					if found, ok := patch.OldLine(line.OldLine); !ok || found.OldLine != line.OldLine || found.Kind == gh.LineAdded {
+						t.Fatalf("old line %d is not indexed", line.OldLine)
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestCalculateDiffPosition(t *testing.T) {
+	file := &github.CommitFile{Filename: github.Ptr("main.go"), Patch: github.Ptr(twoHunks)}
+
+	// The first hunk header doesn't take a position. Counting it, as
+	// CalculateDiffPosition once did, put line 1 at position 2, which GitHub
+	// shows on line 2.
+	tests := []struct {
+		line, position int
+	}{
+		{1, 1}, {2, 3}, {3, 4},
+		{19, 6}, {20, 9},
+		{4, -1},
+	}
+	for _, tt := range tests {
+		if got := gh.CalculateDiffPosition(file, tt.line); got != tt.position {
+			t.Errorf("CalculateDiffPosition(%d) = %d, want %d", tt.line, got, tt.position)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+)
+
+var _ forge.FindingHistory = (*Forge)(nil)
+
+// ListFindings returns the fingerprinted findings the reviewer's account
+// posted on a pull request, inline and in review bodies and comments. Only
+// reactions from collaborators are counted, so anyone else can't reject a
+// finding for the whole repository. GitHub
+// clears the line of an inline comment once the code it refers to changes;
+// findings in bodies carry their path for the caller to check instead.
+func (f *Forge) ListFindings(ctx context.Context, owner, repo string, number int) ([]forge.PostedFinding, error) {
+	own := f.ownComment(ctx)
+	collaborator := forge.Collaborators(ctx, f, owner, repo)
+
+	comments, err := f.ListReviewComments(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list review comments: %w", err)
+	}
+
+	var findings []forge.PostedFinding
+	for _, comment := range comments {
+		if !own(comment.GetUser()) {
+			continue
+		}
+		fingerprints := forge.ParseFingerprints(comment.GetBody())
+		if len(fingerprints) == 0 {
+			continue
+		}
+		up, down, err := f.countReactions(ctx, owner, repo, comment, collaborator)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reactions on comment %d: %w", comment.GetID(), err)
+		}
+		for _, fingerprint := range fingerprints {
+			findings = append(findings, forge.PostedFinding{
+				Fingerprint: fingerprint,
+				CommentID:   comment.GetID(),
+				Body:        comment.GetBody(),
+				Outdated:    comment.Line == nil,
+				Marked:      strings.Contains(comment.GetBody(), forge.OutdatedMarker),
+				ThumbsUp:    up,
+				ThumbsDown:  down,
+			})
+		}
+	}
+
+	reviews, err := f.ListReviews(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	for _, review := range reviews {
+		if own(review.GetUser()) {
+			findings = append(findings, forge.ParseListedFindings(review.GetBody())...)
+		}
+	}
+
+	// Reviews GitHub rejected were posted as issue comments
+	issueComments, err := f.ListIssueComments(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issue comments: %w", err)
+	}
+	for _, comment := range issueComments {
+		if own(comment.GetUser()) {
+			findings = append(findings, forge.ParseListedFindings(comment.GetBody())...)
+		}
+	}
+
+	return findings, nil
+}
+
+// MarkOutdated notes at the top of an inline finding that its code has changed
+func (f *Forge) MarkOutdated(ctx context.Context, owner, repo string, number int, finding forge.PostedFinding) error {
+	if strings.Contains(finding.Body, forge.OutdatedMarker) {
+		return nil
+	}
+
+	if err := f.EditReviewComment(ctx, owner, repo, finding.CommentID, forge.OutdatedBody(finding.Body)); err != nil {
+		return fmt.Errorf("failed to edit review comment: %w", err)
+	}
+	return nil
+}
+
+// countReactions counts the thumbs up and thumbs down reactions
+// collaborators left on an inline comment. Comments without either reaction
+// aren't listed.
+func (f *Forge) countReactions(ctx context.Context, owner, repo string, comment *github.PullRequestComment, collaborator func(login string) bool) (up, down int, err error) {
+	if comment.GetReactions().GetPlusOne() == 0 && comment.GetReactions().GetMinusOne() == 0 {
+		return 0, 0, nil
+	}
+
+	reactions, err := f.ListReviewCommentReactions(ctx, owner, repo, comment.GetID())
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, reaction := range reactions {
+		if !collaborator(reaction.GetUser().GetLogin()) {
+			continue
+		}
+		switch reaction.GetContent() {
+		case "+1":
+			up++
+		case "-1":
+			down++
+		}
+	}
+	return up, down, nil
+}
+
+// ownComment returns a function reporting whether a comment was written by
+// the reviewer's account, so markers copied into other people's comments
+// can't hide findings
+func (f *Forge) ownComment(ctx context.Context) func(*github.User) bool {
+	login, err := f.AuthenticatedLogin(ctx)
+	if err != nil {
+		// App installation tokens can't read their user, and comment as a bot
+		log.Printf("Could not get the authenticated user, trusting bot comments: %v", err)
+		return func(user *github.User) bool {
+			return user.GetType() == "Bot"
+		}
+	}
+	return func(user *github.User) bool {
+		return strings.EqualFold(user.GetLogin(), login)
+	}
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// Forge reviews GitHub pull requests. It embeds the client, so the tree,
+// blob and tarball methods the reviewer uses when available are promoted.
+type Forge struct {
+	*Client
+	poster *ReviewPoster
+}
+
+var (
+	_ forge.Forge      = (*Forge)(nil)
+	_ forge.MergeBaser = (*Forge)(nil)
+)
+
+// NewForge creates a GitHub forge
+func NewForge(client *Client) *Forge {
+	return &Forge{
+		Client: client,
+		poster: NewReviewPoster(client),
+	}
+}
+
+// GetChangeRequest fetches a pull request and its repository
+func (f *Forge) GetChangeRequest(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Repository, error) {
+	pr, repository, err := f.GetPullRequest(ctx, owner, repo, number)
+	return pr, repository, notFound(err)
+}
+
+// ListFiles returns the files changed in a pull request
+func (f *Forge) ListFiles(ctx context.Context, owner, repo string, number int) ([]*github.CommitFile, error) {
+	files, err := f.GetPRFiles(ctx, owner, repo, number)
+	return files, notFound(err)
+}
+
+// ReadFile returns the content of a file at a ref
+func (f *Forge) ReadFile(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	content, err := f.GetFileContent(ctx, owner, repo, path, ref)
+	return content, notFound(err)
+}
+
+// ListDirectory returns the paths of the files in a directory at a ref
+func (f *Forge) ListDirectory(ctx context.Context, owner, repo, dir, ref string) ([]string, error) {
+	paths, err := f.Client.ListDirectory(ctx, owner, repo, dir, ref)
+	return paths, notFound(err)
+}
+
+// PostReview posts a review on a pull request
+func (f *Forge) PostReview(ctx context.Context, owner, repo string, number int, review *types.ReviewResponse, files []*github.CommitFile) error {
+	return f.poster.PostReview(ctx, owner, repo, number, review, files)
+}
+
+// PostNote posts a general comment on a pull request
+func (f *Forge) PostNote(ctx context.Context, owner, repo string, number int, body string) error {
+	return f.CreateIssueComment(ctx, owner, repo, number, body)
+}
+
+// LineURL returns the web URL of a line of a file at a ref
+func (f *Forge) LineURL(repository *github.Repository, ref, path string, line int) string {
+	return forge.LineURL(repository.GetHTMLURL(), "blob/"+ref, path, line)
+}
+
+// notFound marks GitHub 404 responses as forge.ErrNotFound
+func notFound(err error) error {
+	if IsNotFound(err) {
+		return fmt.Errorf("%w: %w", forge.ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,789 @@
+// Package githubtest provides an in-process fake of the GitHub API endpoints
+// the reviewer uses, for end-to-end tests without network access.
+package githubtest
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge/forgetest"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// PullRequest is a scripted pull request served by the fake
+type PullRequest struct {
+	Owner   string
+	Repo    string
+	Number  int
+	Title   string
+	Body    string
+	Author  string
+	Draft   bool
+	BaseRef string
+	HeadRef string
+	BaseSHA string
+	HeadSHA string
+	Files   []*github.CommitFile
+
+	// MergeBase is the merge base of BaseSHA and HeadSHA, which is BaseSHA
+	// itself if unset
+	MergeBase string
+}
+
+// Review is a review posted to the fake
+type Review struct {
+	Owner   string
+	Repo    string
+	Number  int
+	Request github.PullRequestReviewRequest
+}
+
+// Comment is an issue comment posted to the fake
+type Comment struct {
+	Owner  string
+	Repo   string
+	Number int
+	Body   string
+}
+
+// botUser is the account the fake attributes posted reviews and comments to,
+// as for an app installation token
+var botUser = &github.User{Login: github.Ptr("mountain-hawk[bot]"), Type: github.Ptr("Bot")}
+
+// Server is a fake GitHub API. Create one with NewServer and close it when done.
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	pulls        map[string]*PullRequest
+	trees        forgetest.Trees
+	reviews      []Review
+	pending      map[int64]Review
+	inline       map[string][]*github.PullRequestComment
+	reactions    map[int64][]*github.Reaction
+	collaborator map[string]bool
+	comments     []Comment
+	checkRuns    []*github.CheckRun
+	reviewStatus int
+	reviewErrors []string
+	deleteStatus int
+	attempts     int
+	nextID       int64
+}
+
+// NewServer starts a fake GitHub API server
+func NewServer() *Server {
+	s := &Server{
+		pulls:   make(map[string]*PullRequest),
+		pending: make(map[int64]Review),
+		inline:  make(map[string][]*github.PullRequestComment),
+
+		reactions:    make(map[int64][]*github.Reaction),
+		collaborator: make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user", s.handleUser)
+	mux.HandleFunc("GET /repos/{owner}/{repo}", s.handleRepository)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}", s.handlePullRequest)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}/files", s.handlePullRequestFiles)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}/reviews", s.handleListReviews)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/pulls/{number}/reviews", s.handleCreateReview)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}/comments", s.handleListReviewComments)
+	mux.HandleFunc("PATCH /repos/{owner}/{repo}/pulls/comments/{id}", s.handleEditReviewComment)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/comments/{id}/reactions", s.handleListReactions)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/collaborators/{user}", s.handleCollaborator)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/compare/{basehead}", s.handleCompare)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/issues/{number}/comments", s.handleListComments)
+	mux.HandleFunc("DELETE /repos/{owner}/{repo}/pulls/{number}/reviews/{id}", s.handleDeletePendingReview)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/issues/{number}/comments", s.handleCreateComment)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/contents/{path...}", s.handleContents)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/git/blobs/{sha}", s.handleBlob)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/git/trees/{ref}", s.handleTree)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/tarball/{ref}", s.handleArchiveLink)
+	mux.HandleFunc("GET /_archive/{owner}/{repo}/{ref}", s.handleArchive)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/check-runs", s.handleCreateCheckRun)
+	mux.HandleFunc("PATCH /repos/{owner}/{repo}/check-runs/{id}", s.handleUpdateCheckRun)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Client returns a reviewer GitHub client pointed at the fake
+func (s *Server) Client() *gh.Client {
+	client := gh.NewClient(forgetest.Token)
+	if err := client.SetBaseURL(s.URL); err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// AddPullRequest scripts a pull request
+func (s *Server) AddPullRequest(pr *PullRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pulls[pullKey(pr.Owner, pr.Repo, pr.Number)] = pr
+}
+
+// AddFiles sets the files in a repository at a commit, keyed by path
+func (s *Server) AddFiles(owner, repo, ref string, files map[string]string) {
+	s.trees.Add(owner+"/"+repo, ref, files)
+}
+
+// FailReviews makes every following review creation fail with status and
+// errs, or succeed again if status is zero
+func (s *Server) FailReviews(status int, errs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reviewStatus = status
+	s.reviewErrors = errs
+}
+
+// FailPendingDeletes makes every following pending review deletion fail with
+// status, or succeed again if status is zero
+func (s *Server) FailPendingDeletes(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteStatus = status
+}
+
+// AddPendingReview scripts a pending review on a pull request, as left by an
+// earlier run
+func (s *Server) AddPendingReview(owner, repo string, number int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[s.newID()] = Review{Owner: owner, Repo: repo, Number: number}
+}
+
+// ReviewAttempts returns the number of review creations requested so far,
+// including pending and failed ones
+func (s *Server) ReviewAttempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+// Reviews returns the reviews posted so far
+func (s *Server) Reviews() []Review {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Review(nil), s.reviews...)
+}
+
+// Comments returns the issue comments posted so far
+func (s *Server) Comments() []Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Comment(nil), s.comments...)
+}
+
+// PendingReviews returns the number of reviews created without an event and
+// not yet deleted
+func (s *Server) PendingReviews() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// AddReviewComment scripts an inline review comment on a pull request, by
+// the fake's bot account unless the comment names a user. A comment without
+// a line is outdated.
+func (s *Server) AddReviewComment(owner, repo string, number int, comment *github.PullRequestComment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if comment.ID == nil {
+		comment.ID = github.Ptr(s.newID())
+	}
+	if comment.User == nil {
+		comment.User = botUser
+	}
+	key := pullKey(owner, repo, number)
+	s.inline[key] = append(s.inline[key], comment)
+}
+
+// AddReaction scripts a user's reaction, such as "+1" or "-1", on an inline
+// review comment added with AddReviewComment
+func (s *Server) AddReaction(commentID int64, login, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reactions[commentID] = append(s.reactions[commentID], &github.Reaction{
+		User:    &github.User{Login: github.Ptr(login)},
+		Content: github.Ptr(content),
+	})
+
+	for _, comments := range s.inline {
+		for _, comment := range comments {
+			if comment.GetID() != commentID {
+				continue
+			}
+			if comment.Reactions == nil {
+				comment.Reactions = &github.Reactions{}
+			}
+			switch content {
+			case "+1":
+				comment.Reactions.PlusOne = github.Ptr(comment.Reactions.GetPlusOne() + 1)
+			case "-1":
+				comment.Reactions.MinusOne = github.Ptr(comment.Reactions.GetMinusOne() + 1)
+			}
+		}
+	}
+}
+
+// AddCollaborator makes a user a collaborator on a repository
+func (s *Server) AddCollaborator(owner, repo, login string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collaborator[owner+"/"+repo+"/"+login] = true
+}
+
+// ReviewComments returns the inline review comments on a pull request
+func (s *Server) ReviewComments(owner, repo string, number int) []*github.PullRequestComment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*github.PullRequestComment(nil), s.inline[pullKey(owner, repo, number)]...)
+}
+
+// CheckRuns returns the check runs in their latest state
+func (s *Server) CheckRuns() []*github.CheckRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*github.CheckRun(nil), s.checkRuns...)
+}
+
+func (s *Server) handleRepository(w http.ResponseWriter, r *http.Request) {
+	owner, repo := r.PathValue("owner"), r.PathValue("repo")
+	forgetest.WriteJSON(w, http.StatusOK, &github.Repository{
+		Name:     github.Ptr(repo),
+		FullName: github.Ptr(owner + "/" + repo),
+		HTMLURL:  github.Ptr(s.URL + "/" + owner + "/" + repo),
+		Owner:    &github.User{Login: github.Ptr(owner)},
+	})
+}
+
+func (s *Server) handlePullRequest(w http.ResponseWriter, r *http.Request) {
+	pr := s.pullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	additions, deletions := 0, 0
+	for _, file := range pr.Files {
+		additions += file.GetAdditions()
+		deletions += file.GetDeletions()
+	}
+
+	forgetest.WriteJSON(w, http.StatusOK, &github.PullRequest{
+		Number:       github.Ptr(pr.Number),
+		Title:        github.Ptr(pr.Title),
+		Body:         github.Ptr(pr.Body),
+		Draft:        github.Ptr(pr.Draft),
+		User:         &github.User{Login: github.Ptr(pr.Author)},
+		Base:         &github.PullRequestBranch{Ref: github.Ptr(pr.BaseRef), SHA: github.Ptr(pr.BaseSHA)},
+		Head:         &github.PullRequestBranch{Ref: github.Ptr(pr.HeadRef), SHA: github.Ptr(pr.HeadSHA)},
+		Additions:    github.Ptr(additions),
+		Deletions:    github.Ptr(deletions),
+		ChangedFiles: github.Ptr(len(pr.Files)),
+	})
+}
+
+func (s *Server) handlePullRequestFiles(w http.ResponseWriter, r *http.Request) {
+	pr := s.pullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	forgetest.WriteJSON(w, http.StatusOK, pr.Files)
+}
+
+func (s *Server) handleCreateReview(w http.ResponseWriter, r *http.Request) {
+	pr := s.pullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	var req github.PullRequestReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	s.mu.Lock()
+	s.attempts++
+	status, errs := s.reviewStatus, s.reviewErrors
+	pending := s.hasPending(pr)
+	s.mu.Unlock()
+	if status != 0 {
+		writeError(w, status, http.StatusText(status), errs...)
+		return
+	}
+
+	// GitHub allows one pending review per user on a pull request
+	if req.GetEvent() == "" && pending {
+		writeError(w, http.StatusUnprocessableEntity, "Unprocessable Entity", "User can only have one pending review per pull request")
+		return
+	}
+
+	// GitHub rejects the whole review if any comment cannot be placed
+	for _, comment := range req.Comments {
+		if message := validateComment(pr, comment); message != "" {
+			writeError(w, http.StatusUnprocessableEntity, "Unprocessable Entity", message)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	review := Review{Owner: pr.Owner, Repo: pr.Repo, Number: pr.Number, Request: req}
+	id := s.newID()
+	if req.GetEvent() == "" {
+		// Reviews without an event stay pending until submitted or deleted
+		s.pending[id] = review
+	} else {
+		s.reviews = append(s.reviews, review)
+		key := pullKey(pr.Owner, pr.Repo, pr.Number)
+		for _, comment := range req.Comments {
+			s.inline[key] = append(s.inline[key], &github.PullRequestComment{
+				ID:       github.Ptr(s.newID()),
+				Path:     comment.Path,
+				Position: comment.Position,
+				Line:     newLine(pr, comment.GetPath(), comment.GetPosition()),
+				Body:     comment.Body,
+				User:     botUser,
+			})
+		}
+	}
+	s.mu.Unlock()
+
+	forgetest.WriteJSON(w, http.StatusOK, &github.PullRequestReview{ID: github.Ptr(id), Body: req.Body, State: req.Event})
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	// Installation tokens have no user
+	writeError(w, http.StatusForbidden, "Resource not accessible by integration")
+}
+
+func (s *Server) handleListReviews(w http.ResponseWriter, r *http.Request) {
+	pr := s.pullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reviews := []*github.PullRequestReview{}
+	for i, review := range s.reviews {
+		if review.Owner == pr.Owner && review.Repo == pr.Repo && review.Number == pr.Number {
+			reviews = append(reviews, &github.PullRequestReview{
+				ID:    github.Ptr(int64(i + 1)),
+				Body:  review.Request.Body,
+				State: review.Request.Event,
+				User:  botUser,
+			})
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(s.pending)) {
+		if review := s.pending[id]; review.Owner == pr.Owner && review.Repo == pr.Repo && review.Number == pr.Number {
+			reviews = append(reviews, &github.PullRequestReview{ID: github.Ptr(id), State: github.Ptr("PENDING"), User: botUser})
+		}
+	}
+	forgetest.WriteJSON(w, http.StatusOK, reviews)
+}
+
+func (s *Server) handleListReviewComments(w http.ResponseWriter, r *http.Request) {
+	pr := s.pullRequest(r)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	comments := s.ReviewComments(pr.Owner, pr.Repo, pr.Number)
+	if comments == nil {
+		comments = []*github.PullRequestComment{}
+	}
+	forgetest.WriteJSON(w, http.StatusOK, comments)
+}
+
+func (s *Server) handleEditReviewComment(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	var edit github.PullRequestComment
+	if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, comments := range s.inline {
+		for _, comment := range comments {
+			if comment.GetID() == id {
+				comment.Body = edit.Body
+				forgetest.WriteJSON(w, http.StatusOK, comment)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) handleListReactions(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	forgetest.WriteJSON(w, http.StatusOK, append([]*github.Reaction{}, s.reactions[id]...))
+}
+
+func (s *Server) handleCollaborator(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.collaborator[r.PathValue("owner")+"/"+r.PathValue("repo")+"/"+r.PathValue("user")] {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCompare serves the merge base of a scripted pull request's base and
+// head commits
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	base, head, _ := strings.Cut(r.PathValue("basehead"), "...")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, pr := range s.pulls {
+		if pr.Owner != r.PathValue("owner") || pr.Repo != r.PathValue("repo") || pr.BaseSHA != base || pr.HeadSHA != head {
+			continue
+		}
+		mergeBase := pr.MergeBase
+		if mergeBase == "" {
+			mergeBase = base
+		}
+		forgetest.WriteJSON(w, http.StatusOK, &github.CommitsComparison{
+			MergeBaseCommit: &github.RepositoryCommit{SHA: github.Ptr(mergeBase)},
+		})
+		return
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
+	owner, repo := r.PathValue("owner"), r.PathValue("repo")
+	number, _ := strconv.Atoi(r.PathValue("number"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comments := []*github.IssueComment{}
+	for _, comment := range s.comments {
+		if comment.Owner == owner && comment.Repo == repo && comment.Number == number {
+			comments = append(comments, &github.IssueComment{Body: github.Ptr(comment.Body), User: botUser})
+		}
+	}
+	forgetest.WriteJSON(w, http.StatusOK, comments)
+}
+
+func (s *Server) handleDeletePendingReview(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	s.mu.Lock()
+	status := s.deleteStatus
+	review, ok := s.pending[id]
+	if status == 0 {
+		delete(s.pending, id)
+	}
+	s.mu.Unlock()
+	if status != 0 {
+		writeError(w, status, http.StatusText(status))
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	forgetest.WriteJSON(w, http.StatusOK, &github.PullRequestReview{ID: github.Ptr(id), Body: review.Request.Body, State: github.Ptr("PENDING")})
+}
+
+func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	owner, repo := r.PathValue("owner"), r.PathValue("repo")
+	number, _ := strconv.Atoi(r.PathValue("number"))
+
+	var comment github.IssueComment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	s.mu.Lock()
+	s.comments = append(s.comments, Comment{Owner: owner, Repo: repo, Number: number, Body: comment.GetBody()})
+	comment.ID = github.Ptr(s.newID())
+	s.mu.Unlock()
+
+	forgetest.WriteJSON(w, http.StatusCreated, &comment)
+}
+
+func (s *Server) handleContents(w http.ResponseWriter, r *http.Request) {
+	tree := s.tree(r.PathValue("owner"), r.PathValue("repo"), r.URL.Query().Get("ref"))
+	p := strings.Trim(r.PathValue("path"), "/")
+
+	if content, ok := tree[p]; ok {
+		encoded := base64.StdEncoding.EncodeToString([]byte(content))
+		forgetest.WriteJSON(w, http.StatusOK, &github.RepositoryContent{
+			Type:     github.Ptr("file"),
+			Name:     github.Ptr(path.Base(p)),
+			Path:     github.Ptr(p),
+			SHA:      github.Ptr(blobSHA(content)),
+			Size:     github.Ptr(len(content)),
+			Encoding: github.Ptr("base64"),
+			Content:  github.Ptr(encoded),
+		})
+		return
+	}
+
+	// Directory listings hold the files directly inside the directory
+	var entries []*github.RepositoryContent
+	for _, filePath := range forgetest.DirPaths(tree, p) {
+		entries = append(entries, &github.RepositoryContent{
+			Type: github.Ptr("file"),
+			Name: github.Ptr(path.Base(filePath)),
+			Path: github.Ptr(filePath),
+			SHA:  github.Ptr(blobSHA(tree[filePath])),
+		})
+	}
+	if len(entries) == 0 {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	forgetest.WriteJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleBlob(w http.ResponseWriter, r *http.Request) {
+	owner, repo, sha := r.PathValue("owner"), r.PathValue("repo"), r.PathValue("sha")
+
+	content, ok := s.trees.Find(owner+"/"+repo, func(content string) bool {
+		return blobSHA(content) == sha
+	})
+	if ok {
+		w.Header().Set("Content-Type", "application/vnd.github.raw")
+		w.Write([]byte(content))
+		return
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
+	tree := s.tree(r.PathValue("owner"), r.PathValue("repo"), r.PathValue("ref"))
+	if tree == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	var entries []*github.TreeEntry
+	for _, filePath := range forgetest.SortedPaths(tree) {
+		entries = append(entries, &github.TreeEntry{
+			Path: github.Ptr(filePath),
+			Type: github.Ptr("blob"),
+			Mode: github.Ptr("100644"),
+			SHA:  github.Ptr(blobSHA(tree[filePath])),
+		})
+	}
+	forgetest.WriteJSON(w, http.StatusOK, &github.Tree{SHA: github.Ptr(r.PathValue("ref")), Entries: entries, Truncated: github.Ptr(false)})
+}
+
+func (s *Server) handleArchiveLink(w http.ResponseWriter, r *http.Request) {
+	owner, repo, ref := r.PathValue("owner"), r.PathValue("repo"), r.PathValue("ref")
+	if s.tree(owner, repo, ref) == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s/_archive/%s/%s/%s", s.URL, owner, repo, ref))
+	w.WriteHeader(http.StatusFound)
+}
+
+func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
+	owner, repo, ref := r.PathValue("owner"), r.PathValue("repo"), r.PathValue("ref")
+	tree := s.tree(owner, repo, ref)
+
+	// Like GitHub, entries sit under a single top-level directory
+	prefix := fmt.Sprintf("%s-%s-%s/", owner, repo, ref)
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, filePath := range forgetest.SortedPaths(tree) {
+		content := tree[filePath]
+		tw.WriteHeader(&tar.Header{Name: prefix + filePath, Mode: 0o644, Size: int64(len(content)), ModTime: time.Unix(0, 0)})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+}
+
+func (s *Server) handleCreateCheckRun(w http.ResponseWriter, r *http.Request) {
+	var opts github.CreateCheckRunOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	s.mu.Lock()
+	run := &github.CheckRun{
+		ID:         github.Ptr(s.newID()),
+		Name:       github.Ptr(opts.Name),
+		HeadSHA:    github.Ptr(opts.HeadSHA),
+		Status:     opts.Status,
+		Conclusion: opts.Conclusion,
+		Output:     checkRunOutput(opts.Output),
+	}
+	s.checkRuns = append(s.checkRuns, run)
+	s.mu.Unlock()
+
+	forgetest.WriteJSON(w, http.StatusCreated, run)
+}
+
+func (s *Server) handleUpdateCheckRun(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	var opts github.UpdateCheckRunOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.checkRuns {
+		if run.GetID() != id {
+			continue
+		}
+		if opts.Status != nil {
+			run.Status = opts.Status
+		}
+		if opts.Conclusion != nil {
+			run.Conclusion = opts.Conclusion
+		}
+		if opts.Output != nil {
+			run.Output = checkRunOutput(opts.Output)
+		}
+		forgetest.WriteJSON(w, http.StatusOK, run)
+		return
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+// pullRequest returns the scripted pull request a request refers to
+func (s *Server) pullRequest(r *http.Request) *PullRequest {
+	number, _ := strconv.Atoi(r.PathValue("number"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pulls[pullKey(r.PathValue("owner"), r.PathValue("repo"), number)]
+}
+
+// tree returns the files of a repository at a commit
+func (s *Server) tree(owner, repo, ref string) map[string]string {
+	return s.trees.Files(owner+"/"+repo, ref)
+}
+
+// hasPending reports whether a pull request has a pending review. The
+// caller must hold s.mu.
+func (s *Server) hasPending(pr *PullRequest) bool {
+	for _, review := range s.pending {
+		if review.Owner == pr.Owner && review.Repo == pr.Repo && review.Number == pr.Number {
+			return true
+		}
+	}
+	return false
+}
+
+// newID returns a unique object ID. The caller must hold s.mu.
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+// validateComment returns why GitHub would reject a review comment, if it would
+func validateComment(pr *PullRequest, comment *github.DraftReviewComment) string {
+	for _, file := range pr.Files {
+		if file.GetFilename() != comment.GetPath() {
+			continue
+		}
+
+		if comment.Position != nil {
+			// Positions count the lines after the first hunk header
+			lines := strings.Count(file.GetPatch(), "\n")
+			if comment.GetPosition() < 1 || comment.GetPosition() > lines {
+				return "Pull request review thread position is invalid"
+			}
+			return ""
+		}
+
+		for _, hunk := range gh.GetHunkRanges(file) {
+			if hunk.Contains(comment.GetLine()) {
+				return ""
+			}
+		}
+		return "Pull request review thread line must be part of the diff"
+	}
+	return "Path could not be resolved"
+}
+
+// newLine returns the head file line at a diff position of a pull request
+// file, or nil if there is none
+func newLine(pr *PullRequest, path string, position int) *int {
+	for _, file := range pr.Files {
+		if file.GetFilename() != path {
+			continue
+		}
+		for _, hunk := range gh.FilePatch(file).Hunks {
+			for _, line := range hunk.Lines {
+				if line.Position == position && line.Kind != gh.LineDeleted {
+					return github.Ptr(line.NewLine)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkRunOutput converts check run options output to the stored form
+func checkRunOutput(output *github.CheckRunOutput) *github.CheckRunOutput {
+	if output == nil {
+		return nil
+	}
+	copied := *output
+	return &copied
+}
+
+// pullKey identifies a pull request
+func pullKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+// blobSHA returns the git blob SHA of content
+func blobSHA(content string) string {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "blob %d\x00", len(content))
+	hash.Write([]byte(content))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// writeError writes a GitHub-style error response
+func writeError(w http.ResponseWriter, status int, message string, errs ...string) {
+	forgetest.WriteJSON(w, status, map[string]any{"message": message, "errors": errs})
+}
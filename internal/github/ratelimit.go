@@ -0,0 +1,236 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// Rate limit retry defaults
+const (
+	defaultMaxRetries = 3
+	defaultMaxWait    = 15 * time.Minute
+
+	// secondaryBackoff is the first wait after a secondary rate limit
+	// response without Retry-After, as GitHub recommends
+	secondaryBackoff = time.Minute
+)
+
+// RateLimitError is returned when a request is rate limited and waiting for
+// the limit to lift would pass the request's deadline
+type RateLimitError struct {
+	// Secondary is true for secondary (abuse) rate limits
+	Secondary bool
+
+	// Wait is how long GitHub asked the client to wait
+	Wait time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limit"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+	return fmt.Sprintf("GitHub %s exceeded; retry in %s", kind, e.Wait.Round(time.Second))
+}
+
+// IsRateLimited reports whether err is a rate limit the transport gave up
+// waiting out, or one go-github reported after the transport's retries ran out
+func IsRateLimited(err error) bool {
+	var rateLimitErr *RateLimitError
+	var primaryErr *github.RateLimitError
+	var secondaryErr *github.AbuseRateLimitError
+	var errResp *github.ErrorResponse
+	return errors.As(err, &rateLimitErr) || errors.As(err, &primaryErr) || errors.As(err, &secondaryErr) ||
+		errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusTooManyRequests
+}
+
+// Quota is the primary rate limit state reported by the last response
+type Quota struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Resource  string
+}
+
+// RateLimitTransport is an http.RoundTripper that waits out GitHub rate
+// limits. It tracks the X-RateLimit headers, waits until the reset when the
+// quota is exhausted, honors Retry-After, and backs off with jitter from
+// secondary rate limits. When the wait would pass the request's deadline, or
+// MaxWait for requests without one, it fails with a *RateLimitError instead.
+type RateLimitTransport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	MaxWait    time.Duration
+
+	mu    sync.Mutex
+	quota Quota
+}
+
+// NewRateLimitTransport wraps base, or http.DefaultTransport if base is nil
+func NewRateLimitTransport(base http.RoundTripper) *RateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RateLimitTransport{
+		Base:       base,
+		MaxRetries: defaultMaxRetries,
+		MaxWait:    defaultMaxWait,
+	}
+}
+
+// Quota returns the rate limit state reported by the last response
+func (t *RateLimitTransport) Quota() Quota {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.quota
+}
+
+// RoundTrip sends the request, retrying rate limited attempts
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Don't spend a request we know will be rejected
+	if wait := t.exhaustedFor(); wait > 0 {
+		if err := t.wait(req.Context(), wait, false); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.Base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		t.update(resp.Header)
+
+		wait, secondary, limited := t.retryAfter(resp, attempt)
+		// Requests whose body can't be replayed are returned as they are
+		if !limited || attempt >= t.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if err := t.wait(req.Context(), wait, secondary); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// exhaustedFor returns how long until the primary quota resets, if it is used up
+func (t *RateLimitTransport) exhaustedFor() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.quota.Limit == 0 || t.quota.Remaining > 0 {
+		return 0
+	}
+	return max(time.Until(t.quota.Reset), 0)
+}
+
+// update records the quota reported by a response
+func (t *RateLimitTransport) update(header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.quota = Quota{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+		Resource:  header.Get("X-RateLimit-Resource"),
+	}
+}
+
+// retryAfter reports whether a response is a rate limit rejection and how
+// long to wait before retrying it
+func (t *RateLimitTransport) retryAfter(resp *http.Response, attempt int) (wait time.Duration, secondary, limited bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false, false
+	}
+
+	retryAfter, hasRetryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+	switch {
+	case resp.Header.Get("X-RateLimit-Remaining") == "0":
+		reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		return max(time.Until(time.Unix(reset, 0)), retryAfter), false, true
+	case hasRetryAfter:
+		return retryAfter, true, true
+	case isSecondaryRateLimit(resp):
+		return secondaryBackoff << attempt, true, true
+	default:
+		// A 403 for missing permissions
+		return 0, false, false
+	}
+}
+
+// wait sleeps for d plus jitter, or fails if that would pass the context's
+// deadline or the transport's MaxWait
+func (t *RateLimitTransport) wait(ctx context.Context, d time.Duration, secondary bool) error {
+	// Spread out clients that were limited at the same time
+	d += time.Duration(rand.Int64N(int64(d/10 + time.Second)))
+
+	limit := t.MaxWait
+	if deadline, ok := ctx.Deadline(); ok {
+		limit = time.Until(deadline)
+	}
+	if d > limit {
+		return &RateLimitError{Secondary: secondary, Wait: d}
+	}
+
+	log.Printf("GitHub rate limit reached, waiting %s", d.Round(time.Second))
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds
+func parseRetryAfter(value string) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// isSecondaryRateLimit reports whether a 403 response body describes a
+// secondary rate limit, leaving the body readable
+func isSecondaryRateLimit(resp *http.Response) bool {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+
+	message := strings.ToLower(string(data))
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse detection")
+}
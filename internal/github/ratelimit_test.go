@@ -0,0 +1,131 @@
+package github_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+func TestRateLimitTransportRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(5000-calls))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"message": "You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	transport := gh.NewRateLimitTransport(nil)
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Errorf("got status %d after %d calls, want 200 after 2", resp.StatusCode, calls)
+	}
+	if quota := transport.Quota(); quota.Limit != 5000 || quota.Remaining != 4998 {
+		t.Errorf("quota = %+v, want 4998 of 5000 remaining", quota)
+	}
+}
+
+func TestRateLimitTransportDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, `{"message": "You have exceeded a secondary rate limit"}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	client := &http.Client{Transport: gh.NewRateLimitTransport(nil)}
+	_, err := client.Do(req)
+
+	var rateLimitErr *gh.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("got error %v, want a RateLimitError", err)
+	}
+	if !rateLimitErr.Secondary || rateLimitErr.Wait < time.Hour {
+		t.Errorf("got %+v, want a secondary limit of at least an hour", rateLimitErr)
+	}
+}
+
+func TestClientWaitsForQuotaReset(t *testing.T) {
+	reset := time.Now().Add(time.Second)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if calls == 1 || time.Now().After(reset) {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+		}
+		w.Write([]byte(`{"login": "mountain-hawk[bot]"}`))
+	}))
+	defer server.Close()
+
+	client := gh.NewClient("test-token")
+	if err := client.SetBaseURL(server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	// go-github would fail the second request itself; the transport waits
+	// for the reset instead
+	for range 2 {
+		if _, err := client.AuthenticatedLogin(t.Context()); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls, want 2", calls)
+	}
+}
+
+func TestPostReviewRateLimited(t *testing.T) {
+	comments := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/comments") {
+			comments++
+			w.Write([]byte("{}"))
+			return
+		}
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, `{"message": "You have exceeded a secondary rate limit", `+
+			`"documentation_url": "https://docs.github.com/rest/using-the-rest-api/rate-limits-for-the-rest-api#about-secondary-rate-limits"}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := gh.NewClient("test-token")
+	if err := client.SetBaseURL(server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the transport's retries run out, go-github reports the limit
+	review := &types.ReviewResponse{Decision: types.DecisionComment, Summary: "Looks fine"}
+	err := gh.NewReviewPoster(client).PostReview(t.Context(), "octo", "demo", 7, review, nil)
+	if !gh.IsRateLimited(err) {
+		t.Fatalf("got error %v, want a rate limit", err)
+	}
+	if comments != 0 {
+		t.Errorf("posted %d fallback comments, want 0", comments)
+	}
+}
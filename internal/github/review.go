@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 
 	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
 	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
 )
 
@@ -22,33 +24,17 @@ func NewReviewPoster(client *Client) *ReviewPoster {
 
 // PostReview posts a structured review to GitHub
 func (rp *ReviewPoster) PostReview(ctx context.Context, owner, repo string, prNumber int, review *types.ReviewResponse, files []*github.CommitFile) error {
-	// Create file map for position calculation
+	// Create file and patch maps for position calculation
 	fileMap := make(map[string]*github.CommitFile)
+	patches := make(map[string]*Patch)
 	for _, file := range files {
 		fileMap[file.GetFilename()] = file
-	}
-
-	// Build review comments
-	var reviewComments []*github.DraftReviewComment
-	var generalBody strings.Builder
-
-	// Add general comments to review body
-	for _, comment := range review.GeneralComments {
-		if generalBody.Len() > 0 {
-			generalBody.WriteString("\n\n")
-		}
-		generalBody.WriteString(rp.formatGeneralComment(comment))
-	}
-
-	// Add summary if provided
-	if review.Summary != "" {
-		if generalBody.Len() > 0 {
-			generalBody.WriteString("\n\n---\n\n")
-		}
-		generalBody.WriteString(fmt.Sprintf("**Summary:** %s", review.Summary))
+		patches[file.GetFilename()] = FilePatch(file)
 	}
 
 	// Process file comments
+	var anchored []anchoredComment
+	var outside []types.FileComment
 	for _, comment := range review.FileComments {
 		file, exists := fileMap[comment.Path]
 		if !exists {
@@ -57,94 +43,208 @@ func (rp *ReviewPoster) PostReview(ctx context.Context, owner, repo string, prNu
 		}
 
 		// Calculate diff position
-		position := CalculateDiffPosition(file, comment.Line)
+		position := patches[comment.Path].NewPosition(comment.Line)
 		if position == -1 {
-			log.Printf("Could not calculate position for %s:%d", comment.Path, comment.Line)
+			log.Printf("Could not calculate position for %s:%d, moving it outside the diff", comment.Path, comment.Line)
+			comment.URL = blobLineURL(file, comment.Line)
+			outside = append(outside, comment)
 			continue
 		}
 
-		body := rp.formatFileComment(comment)
-		reviewComments = append(reviewComments, &github.DraftReviewComment{
-			Path:     &comment.Path,
-			Position: &position,
-			Body:     &body,
+		commentBody := forge.FormatFileComment(comment)
+		anchored = append(anchored, anchoredComment{
+			comment: comment,
+			draft: &github.DraftReviewComment{
+				Path:     github.String(comment.Path),
+				Position: &position,
+				Body:     &commentBody,
+			},
 		})
 	}
 
+	if len(outside) > 0 {
+		withOutside := *review
+		withOutside.OutsideDiff = slices.Concat(review.OutsideDiff, outside)
+		review = &withOutside
+	}
+
+	// Post the review
+	body, err := rp.createReview(ctx, owner, repo, prNumber, review, anchored, nil, fileMap)
+	if isCommentRejection(err) && len(anchored) > 0 {
+		// GitHub rejects the whole review for one bad comment, so find the
+		// comments it refuses and move them to the body
+		log.Printf("Review for PR #%d rejected, checking its %d comments: %v", prNumber, len(anchored), err)
+		valid, rejected, checkErr := rp.checkComments(ctx, owner, repo, prNumber, anchored)
+		switch {
+		case checkErr != nil:
+			log.Printf("Failed to check the comments of PR #%d: %v", prNumber, checkErr)
+		case len(valid) < len(anchored):
+			body, err = rp.createReview(ctx, owner, repo, prNumber, review, valid, rejected, fileMap)
+		}
+	}
+	if err != nil {
+		// A rate limited comment would fail the same way, so don't try
+		if IsRateLimited(err) {
+			return err
+		}
+
+		// Fallback: post as general comment, keeping the file comments
+		if body != "" {
+			log.Printf("Failed to create review, posting as comment: %v", err)
+			body = forge.FormatReviewBody(review, rp.mapDecisionToEvent, unanchoredSection(comments(anchored), fileMap))
+			return rp.client.CreateIssueComment(ctx, owner, repo, prNumber, body)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// anchoredComment is a file comment and the review comment placing it in the diff
+type anchoredComment struct {
+	comment types.FileComment
+	draft   *github.DraftReviewComment
+}
+
+// createReview posts a review with comments inline and the unanchored
+// comments listed in its body, and returns the body
+func (rp *ReviewPoster) createReview(ctx context.Context, owner, repo string, prNumber int, review *types.ReviewResponse, inline []anchoredComment, unanchored []types.FileComment, fileMap map[string]*github.CommitFile) (string, error) {
+	// General comments, summary and review metadata go in the review body
+	body := forge.FormatReviewBody(review, rp.mapDecisionToEvent, unanchoredSection(unanchored, fileMap))
+
 	// Create the review request
 	reviewRequest := &github.PullRequestReviewRequest{
-		Event:    github.String(rp.mapDecisionToEvent(review.Decision)),
-		Comments: reviewComments,
+		Event: github.String(rp.mapDecisionToEvent(review.Decision)),
+	}
+	for _, c := range inline {
+		reviewRequest.Comments = append(reviewRequest.Comments, c.draft)
 	}
 
-	if generalBody.Len() > 0 {
-		body := generalBody.String()
+	if body != "" {
 		reviewRequest.Body = &body
 	}
 
-	// Post the review
-	err := rp.client.CreateReview(ctx, owner, repo, prNumber, reviewRequest)
+	return body, rp.client.CreateReview(ctx, owner, repo, prNumber, reviewRequest)
+}
+
+// commentRejections are the parts of a 422 response that blame a review's
+// comments rather than the review itself, such as approving one's own PR
+var commentRejections = []string{"comment", "position", "review thread", "path could not be resolved"}
+
+// isCommentRejection reports whether err is a 422 response rejecting a
+// review's comments
+func isCommentRejection(err error) bool {
+	if !IsUnprocessable(err) {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, rejection := range commentRejections {
+		if strings.Contains(message, rejection) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkComments splits comments of a rejected review into those GitHub
+// accepts and those it rejects. Comments are checked with pending reviews,
+// and GitHub allows one per user on a PR, so an existing pending review
+// fails the check.
+func (rp *ReviewPoster) checkComments(ctx context.Context, owner, repo string, prNumber int, anchored []anchoredComment) (valid []anchoredComment, rejected []types.FileComment, err error) {
+	// Only their author sees pending reviews, so any listed are ours
+	reviews, err := rp.client.ListReviews(ctx, owner, repo, prNumber)
 	if err != nil {
-		// Fallback: post as general comment
-		if generalBody.Len() > 0 {
-			log.Printf("Failed to create review, posting as comment: %v", err)
-			return rp.client.CreateIssueComment(ctx, owner, repo, prNumber, generalBody.String())
+		return nil, nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	for _, review := range reviews {
+		if review.GetState() == "PENDING" {
+			return nil, nil, fmt.Errorf("pending review %d already exists", review.GetID())
 		}
-		return err
 	}
 
-	return nil
+	return rp.bisectComments(ctx, owner, repo, prNumber, anchored)
 }
 
-// formatGeneralComment formats a general comment with appropriate emoji
-func (rp *ReviewPoster) formatGeneralComment(comment types.GeneralComment) string {
-	severity := rp.getSeverityEmoji(comment.Severity)
-	return fmt.Sprintf("%s%s", severity, comment.Body)
+// bisectComments narrows comments down to the ones GitHub rejects by
+// creating and deleting pending reviews with halves of them. It stops at the
+// first probe that fails for another reason.
+func (rp *ReviewPoster) bisectComments(ctx context.Context, owner, repo string, prNumber int, anchored []anchoredComment) (valid []anchoredComment, rejected []types.FileComment, err error) {
+	if len(anchored) == 1 {
+		err := rp.probeComments(ctx, owner, repo, prNumber, anchored)
+		switch {
+		case err == nil:
+			return anchored, nil, nil
+		case !isCommentRejection(err):
+			return nil, nil, err
+		}
+		log.Printf("Comment on %s:%d rejected: %v", anchored[0].comment.Path, anchored[0].comment.Line, err)
+		return nil, []types.FileComment{anchored[0].comment}, nil
+	}
+
+	mid := len(anchored) / 2
+	for _, half := range [][]anchoredComment{anchored[:mid], anchored[mid:]} {
+		if len(half) > 1 {
+			err := rp.probeComments(ctx, owner, repo, prNumber, half)
+			if err == nil {
+				valid = append(valid, half...)
+				continue
+			}
+			if !isCommentRejection(err) {
+				return nil, nil, err
+			}
+		}
+		halfValid, halfRejected, err := rp.bisectComments(ctx, owner, repo, prNumber, half)
+		if err != nil {
+			return nil, nil, err
+		}
+		valid = append(valid, halfValid...)
+		rejected = append(rejected, halfRejected...)
+	}
+	return valid, rejected, nil
+}
+
+// probeComments checks whether GitHub accepts comments by creating a pending
+// review with them and deleting it again. A pending review that can't be
+// deleted would block the next probe, so that fails the check.
+func (rp *ReviewPoster) probeComments(ctx context.Context, owner, repo string, prNumber int, anchored []anchoredComment) error {
+	drafts := make([]*github.DraftReviewComment, len(anchored))
+	for i, c := range anchored {
+		drafts[i] = c.draft
+	}
+
+	id, err := rp.client.CreatePendingReview(ctx, owner, repo, prNumber, drafts)
+	if err != nil {
+		return err
+	}
+	if err := rp.client.DeletePendingReview(ctx, owner, repo, prNumber, id); err != nil {
+		return fmt.Errorf("failed to delete pending review %d: %w", id, err)
+	}
+	return nil
 }
 
-// formatFileComment formats a file comment with severity and type indicators
-func (rp *ReviewPoster) formatFileComment(comment types.FileComment) string {
-	severity := rp.getSeverityEmoji(comment.Severity)
-	typeEmoji := rp.getTypeEmoji(comment.Type)
-
-	return fmt.Sprintf("%s%s**%s**: %s",
-		severity,
-		typeEmoji,
-		strings.Title(string(comment.Type)),
-		comment.Body,
-	)
+// unanchoredSection lists comments that couldn't be placed in the diff,
+// linked to their lines at the PR head
+func unanchoredSection(unanchored []types.FileComment, fileMap map[string]*github.CommitFile) string {
+	return forge.FormatFileCommentList("Comments that couldn't be anchored", unanchored, func(comment types.FileComment) string {
+		return blobLineURL(fileMap[comment.Path], comment.Line)
+	})
 }
 
-// getSeverityEmoji returns emoji for severity level
-func (rp *ReviewPoster) getSeverityEmoji(severity types.Severity) string {
-	switch severity {
-	case types.SeverityError:
-		return "🚨 "
-	case types.SeverityWarning:
-		return "⚠️ "
-	case types.SeverityInfo:
-		return "💡 "
-	default:
+// blobLineURL links a line of a changed file at the PR head
+func blobLineURL(file *github.CommitFile, line int) string {
+	if file.GetBlobURL() == "" {
 		return ""
 	}
+	return fmt.Sprintf("%s#L%d", file.GetBlobURL(), line)
 }
 
-// getTypeEmoji returns emoji for comment type
-func (rp *ReviewPoster) getTypeEmoji(commentType types.CommentType) string {
-	switch commentType {
-	case types.TypeBug:
-		return "🐛 "
-	case types.TypeSecurity:
-		return "🔒 "
-	case types.TypePerformance:
-		return "⚡ "
-	case types.TypeStyle:
-		return "🎨 "
-	case types.TypeMaintainability:
-		return "🔧 "
-	default:
-		return ""
+// comments returns the file comments of anchored comments
+func comments(anchored []anchoredComment) []types.FileComment {
+	fileComments := make([]types.FileComment, len(anchored))
+	for i, c := range anchored {
+		fileComments[i] = c.comment
 	}
+	return fileComments
 }
 
 // mapDecisionToEvent maps review decision to GitHub review event
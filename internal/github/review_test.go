@@ -0,0 +1,245 @@
+package github_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/github/githubtest"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// patch adds lines 2 and 3 and keeps lines 1 and 4 as context
+const patch = "@@ -1,2 +1,4 @@\n package main\n+\n+func main() {}\n // end"
+
+func newPullRequest(t *testing.T) (*githubtest.Server, []*github.CommitFile) {
+	t.Helper()
+
+	server := githubtest.NewServer()
+	t.Cleanup(server.Close)
+
+	files := []*github.CommitFile{{
+		Filename:  github.Ptr("main.go"),
+		Status:    github.Ptr("modified"),
+		Patch:     github.Ptr(patch),
+		Additions: github.Ptr(2),
+	}}
+	server.AddPullRequest(&githubtest.PullRequest{
+		Owner: "octo", Repo: "demo", Number: 7,
+		BaseSHA: "base", HeadSHA: "head",
+		Files: files,
+	})
+	return server, files
+}
+
+func TestPostReviewComments(t *testing.T) {
+	server, files := newPullRequest(t)
+
+	review := &types.ReviewResponse{
+		Decision:        types.DecisionRequestChanges,
+		GeneralComments: []types.GeneralComment{{Body: "Needs a test", Severity: types.SeverityWarning}},
+		FileComments: []types.FileComment{
+			{Path: "main.go", Line: 3, Body: "Empty main", Severity: types.SeverityError, Type: types.TypeBug},
+			{Path: "main.go", Line: 40, Body: "Outside the diff", Severity: types.SeverityInfo, Type: types.TypeStyle},
+			{Path: "other.go", Line: 1, Body: "Not in the PR", Severity: types.SeverityInfo, Type: types.TypeStyle},
+		},
+		Summary: "One bug",
+	}
+
+	poster := gh.NewReviewPoster(server.Client())
+	if err := poster.PostReview(context.Background(), "octo", "demo", 7, review, files); err != nil {
+		t.Fatal(err)
+	}
+
+	reviews := server.Reviews()
+	if len(reviews) != 1 {
+		t.Fatalf("got %d reviews, want 1", len(reviews))
+	}
+	req := reviews[0].Request
+	if req.GetEvent() != "REQUEST_CHANGES" {
+		t.Errorf("event = %s, want REQUEST_CHANGES", req.GetEvent())
+	}
+	if body := req.GetBody(); !strings.Contains(body, "Needs a test") || !strings.Contains(body, "**Summary:** One bug") {
+		t.Errorf("unexpected review body %q", body)
+	}
+	if body := req.GetBody(); !strings.Contains(body, "**Outside the diff:**") || !strings.Contains(body, "`main.go:40`") {
+		t.Errorf("review body %q does not list the comment outside the diff", body)
+	}
+
+	if len(req.Comments) != 1 {
+		t.Fatalf("got %d inline comments, want 1", len(req.Comments))
+	}
+	comment := req.Comments[0]
+	if comment.GetPath() != "main.go" || comment.GetPosition() != 3 {
+		t.Errorf("comment at %s position %d, want main.go position 3", comment.GetPath(), comment.GetPosition())
+	}
+	if body := comment.GetBody(); !strings.Contains(body, "**Bug**: Empty main") {
+		t.Errorf("unexpected comment body %q", body)
+	}
+
+	if comments := server.Comments(); len(comments) != 0 {
+		t.Errorf("got %d issue comments, want none", len(comments))
+	}
+}
+
+func TestPostReviewFallsBackToIssueComment(t *testing.T) {
+	server, files := newPullRequest(t)
+	server.FailReviews(http.StatusUnprocessableEntity)
+
+	review := &types.ReviewResponse{
+		Decision:        types.DecisionComment,
+		GeneralComments: []types.GeneralComment{{Body: "Looks reasonable", Severity: types.SeverityInfo}},
+		FileComments: []types.FileComment{
+			{Path: "main.go", Line: 2, Body: "Blank line", Severity: types.SeverityInfo, Type: types.TypeStyle},
+		},
+	}
+
+	poster := gh.NewReviewPoster(server.Client())
+	if err := poster.PostReview(context.Background(), "octo", "demo", 7, review, files); err != nil {
+		t.Fatal(err)
+	}
+
+	if reviews := server.Reviews(); len(reviews) != 0 {
+		t.Errorf("got %d reviews, want none", len(reviews))
+	}
+	comments := server.Comments()
+	if len(comments) != 1 {
+		t.Fatalf("got %d issue comments, want 1", len(comments))
+	}
+	if comments[0].Number != 7 || !strings.Contains(comments[0].Body, "Looks reasonable") {
+		t.Errorf("unexpected issue comment %+v", comments[0])
+	}
+	if !strings.Contains(comments[0].Body, "`main.go:2`") || !strings.Contains(comments[0].Body, "Blank line") {
+		t.Errorf("issue comment %q is missing the file comment", comments[0].Body)
+	}
+}
+
+func TestPostReviewMovesRejectedComments(t *testing.T) {
+	server, files := newPullRequest(t)
+
+	// A file from a stale listing the PR no longer changes
+	files = append(files, &github.CommitFile{
+		Filename: github.Ptr("gone.go"),
+		Status:   github.Ptr("added"),
+		Patch:    github.Ptr("@@ -0,0 +1,2 @@\n+package main\n+"),
+		BlobURL:  github.Ptr("https://github.com/octo/demo/blob/head/gone.go"),
+	})
+
+	review := &types.ReviewResponse{
+		Decision: types.DecisionComment,
+		FileComments: []types.FileComment{
+			{Path: "main.go", Line: 2, Body: "Blank line", Severity: types.SeverityInfo, Type: types.TypeStyle},
+			{Path: "gone.go", Line: 1, Body: "Removed file", Severity: types.SeverityWarning, Type: types.TypeBug},
+			{Path: "main.go", Line: 3, Body: "Empty main", Severity: types.SeverityError, Type: types.TypeBug},
+		},
+	}
+
+	poster := gh.NewReviewPoster(server.Client())
+	if err := poster.PostReview(context.Background(), "octo", "demo", 7, review, files); err != nil {
+		t.Fatal(err)
+	}
+
+	reviews := server.Reviews()
+	if len(reviews) != 1 {
+		t.Fatalf("got %d reviews, want 1", len(reviews))
+	}
+	req := reviews[0].Request
+	if len(req.Comments) != 2 || req.Comments[0].GetPosition() != 2 || req.Comments[1].GetPosition() != 3 {
+		t.Errorf("got inline comments %+v, want main.go positions 2 and 3", req.Comments)
+	}
+
+	body := req.GetBody()
+	if !strings.Contains(body, "Comments that couldn't be anchored") ||
+		!strings.Contains(body, "[`gone.go:1`](https://github.com/octo/demo/blob/head/gone.go#L1)") ||
+		!strings.Contains(body, "Removed file") {
+		t.Errorf("review body %q does not list the rejected comment", body)
+	}
+
+	if pending := server.PendingReviews(); pending != 0 {
+		t.Errorf("%d pending reviews left behind", pending)
+	}
+	if comments := server.Comments(); len(comments) != 0 {
+		t.Errorf("got %d issue comments, want none", len(comments))
+	}
+}
+
+func TestPostReviewWithoutBodyReturnsError(t *testing.T) {
+	server, files := newPullRequest(t)
+	server.FailReviews(http.StatusInternalServerError)
+
+	poster := gh.NewReviewPoster(server.Client())
+	err := poster.PostReview(context.Background(), "octo", "demo", 7, &types.ReviewResponse{Decision: types.DecisionApprove}, files)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if comments := server.Comments(); len(comments) != 0 {
+		t.Errorf("got %d issue comments, want none", len(comments))
+	}
+}
+
+func TestPostReviewSkipsCommentChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(server *githubtest.Server)
+		attempts int
+		pending  int
+	}{
+		{
+			name: "rejection not caused by comments",
+			setup: func(server *githubtest.Server) {
+				server.FailReviews(http.StatusUnprocessableEntity, "Can not approve your own pull request")
+			},
+			attempts: 1,
+		},
+		{
+			name:     "existing pending review",
+			setup:    func(server *githubtest.Server) { server.AddPendingReview("octo", "demo", 7) },
+			attempts: 1,
+			pending:  1,
+		},
+		{
+			name:     "pending review not deleted",
+			setup:    func(server *githubtest.Server) { server.FailPendingDeletes(http.StatusInternalServerError) },
+			attempts: 2,
+			pending:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, files := newPullRequest(t)
+			files = append(files, &github.CommitFile{
+				Filename: github.Ptr("gone.go"),
+				Status:   github.Ptr("added"),
+				Patch:    github.Ptr("@@ -0,0 +1,2 @@\n+package main\n+"),
+			})
+			tt.setup(server)
+
+			review := &types.ReviewResponse{
+				Decision: types.DecisionApprove,
+				FileComments: []types.FileComment{
+					{Path: "main.go", Line: 2, Body: "Blank line", Severity: types.SeverityInfo, Type: types.TypeStyle},
+					{Path: "gone.go", Line: 1, Body: "Removed file", Severity: types.SeverityWarning, Type: types.TypeBug},
+				},
+				Summary: "Looks fine",
+			}
+			if err := gh.NewReviewPoster(server.Client()).PostReview(context.Background(), "octo", "demo", 7, review, files); err != nil {
+				t.Fatal(err)
+			}
+
+			if attempts := server.ReviewAttempts(); attempts != tt.attempts {
+				t.Errorf("got %d review requests, want %d", attempts, tt.attempts)
+			}
+			if pending := server.PendingReviews(); pending != tt.pending {
+				t.Errorf("got %d pending reviews, want %d", pending, tt.pending)
+			}
+			comments := server.Comments()
+			if len(comments) != 1 || !strings.Contains(comments[0].Body, "Removed file") {
+				t.Errorf("got issue comments %+v, want the fallback review", comments)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package github_test
+
+import (
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/config"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+func TestParsePullRequestURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		apiURL    string
+		raw       string
+		wantOwner string
+		wantRepo  string
+		wantPR    int
+		wantErr   bool
+	}{
+		{name: "github.com", raw: "https://github.com/octo/demo/pull/7", wantOwner: "octo", wantRepo: "demo", wantPR: 7},
+		{name: "trailing segments", raw: "https://github.com/octo/demo/pull/7/files#diff-1", wantOwner: "octo", wantRepo: "demo", wantPR: 7},
+		{name: "api.github.com", apiURL: "https://api.github.com/", raw: "https://github.com/octo/demo/pull/7", wantOwner: "octo", wantRepo: "demo", wantPR: 7},
+		{name: "Enterprise Server", apiURL: "https://github.example.edu/api/v3", raw: "https://github.example.edu/libraries/catalog/pull/42", wantOwner: "libraries", wantRepo: "catalog", wantPR: 42},
+		{name: "Enterprise Server host case", apiURL: "https://github.example.edu/api/v3/", raw: "https://GitHub.Example.edu/libraries/catalog/pull/42", wantOwner: "libraries", wantRepo: "catalog", wantPR: 42},
+		{name: "Enterprise Server port", apiURL: "http://ghes.internal:8080/api/v3/", raw: "http://ghes.internal:8080/libraries/catalog/pull/1", wantOwner: "libraries", wantRepo: "catalog", wantPR: 1},
+		{name: "github.com URL for Enterprise Server", apiURL: "https://github.example.edu/api/v3", raw: "https://github.com/octo/demo/pull/7", wantErr: true},
+		{name: "Enterprise Server URL for github.com", raw: "https://github.example.edu/libraries/catalog/pull/42", wantErr: true},
+		{name: "port mismatch", apiURL: "http://ghes.internal:8080/api/v3/", raw: "http://ghes.internal/libraries/catalog/pull/1", wantErr: true},
+		{name: "issue", raw: "https://github.com/octo/demo/issues/7", wantErr: true},
+		{name: "repository", raw: "https://github.com/octo/demo", wantErr: true},
+		{name: "bad number", raw: "https://github.com/octo/demo/pull/0", wantErr: true},
+		{name: "not http", raw: "ssh://github.com/octo/demo/pull/7", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{GitHubAPIURL: tt.apiURL}
+			owner, repo, number, err := gh.ParsePullRequestURL(tt.raw, cfg.GitHubHost())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %s/%s#%d, want an error", owner, repo, number)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner != tt.wantOwner || repo != tt.wantRepo || number != tt.wantPR {
+				t.Errorf("got %s/%s#%d, want %s/%s#%d", owner, repo, number, tt.wantOwner, tt.wantRepo, tt.wantPR)
+			}
+		})
+	}
+}
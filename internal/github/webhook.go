@@ -3,10 +3,31 @@ package github
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/google/go-github/v74/github"
 )
 
+// enterpriseHostHeader names the GitHub Enterprise Server instance that sent a
+// webhook delivery
+const enterpriseHostHeader = "X-GitHub-Enterprise-Host"
+
+// ReadWebhookPayload validates the signature of a webhook delivery and returns
+// its payload. SHA-256 signatures are preferred, but the SHA-1 signatures and
+// form encoded payloads older GitHub Enterprise Server releases send are also
+// accepted. Deliveries from an Enterprise Server other than host are rejected.
+func ReadWebhookPayload(r *http.Request, secret []byte, host string) ([]byte, error) {
+	if sender := r.Header.Get(enterpriseHostHeader); sender != "" && !strings.EqualFold(sender, host) {
+		return nil, fmt.Errorf("webhook sent by unexpected GitHub Enterprise host %s", sender)
+	}
+
+	payload, err := github.ValidatePayload(r, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate webhook signature: %w", err)
+	}
+	return payload, nil
+}
+
 // ParseWebhookEvent parses a GitHub webhook payload
 func ParseWebhookEvent(r *http.Request, payload []byte) (interface{}, error) {
 	eventType := github.WebHookType(r)
@@ -0,0 +1,107 @@
+package github_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+const payload = `{"action":"opened","number":7}`
+
+var secret = []byte("webhook secret")
+
+// sign returns a webhook signature header value for body
+func sign(newHash func() hash.Hash, prefix, body string) string {
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(body))
+	return prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestReadWebhookPayload(t *testing.T) {
+	form := "payload=" + url.QueryEscape(payload)
+
+	tests := []struct {
+		name        string
+		host        string
+		sender      string
+		body        string
+		contentType string
+		header      string
+		signature   string
+		wantErr     string
+	}{
+		{
+			name: "github.com", host: "github.com",
+			body: payload, contentType: "application/json",
+			header: "X-Hub-Signature-256", signature: sign(sha256.New, "sha256=", payload),
+		},
+		{
+			name: "Enterprise Server", host: "github.example.edu", sender: "GitHub.Example.edu",
+			body: payload, contentType: "application/json",
+			header: "X-Hub-Signature-256", signature: sign(sha256.New, "sha256=", payload),
+		},
+		{
+			name: "older Enterprise Server", host: "github.example.edu", sender: "github.example.edu",
+			body: form, contentType: "application/x-www-form-urlencoded",
+			header: "X-Hub-Signature", signature: sign(sha1.New, "sha1=", form),
+		},
+		{
+			name: "other Enterprise Server", host: "github.example.edu", sender: "github.other.edu",
+			body: payload, contentType: "application/json",
+			header: "X-Hub-Signature-256", signature: sign(sha256.New, "sha256=", payload),
+			wantErr: "unexpected GitHub Enterprise host github.other.edu",
+		},
+		{
+			name: "Enterprise Server for github.com", host: "github.com", sender: "github.example.edu",
+			body: payload, contentType: "application/json",
+			header: "X-Hub-Signature-256", signature: sign(sha256.New, "sha256=", payload),
+			wantErr: "unexpected GitHub Enterprise host",
+		},
+		{
+			name: "bad signature", host: "github.com",
+			body: payload, contentType: "application/json",
+			header: "X-Hub-Signature-256", signature: sign(sha256.New, "sha256=", payload+" "),
+			wantErr: "failed to validate webhook signature",
+		},
+		{
+			name: "unsigned", host: "github.com",
+			body: payload, contentType: "application/json",
+			wantErr: "failed to validate webhook signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/webhook", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.signature)
+			}
+			if tt.sender != "" {
+				r.Header.Set("X-GitHub-Enterprise-Host", tt.sender)
+			}
+
+			got, err := gh.ReadWebhookPayload(r, secret, tt.host)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != payload {
+				t.Errorf("got payload %q, want %q", got, payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+// Package gitlab reviews GitLab merge requests through the GitLab REST API.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// perPage is the page size requested from paginated endpoints
+const perPage = 100
+
+// Error is an unsuccessful GitLab API response
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gitlab returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a GitLab 404 response
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client wraps the GitLab REST API
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a GitLab client for the instance at baseURL, e.g.
+// https://gitlab.com, authenticating with a personal, group or project
+// access token
+func NewClient(baseURL, token string) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/api/v4/")
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid GitLab URL: %s", baseURL)
+	}
+
+	return &Client{
+		baseURL: u,
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: time.Minute,
+		},
+	}, nil
+}
+
+// projectPath returns the API path of a project from its namespace and path
+func projectPath(owner, repo string) string {
+	return "projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+// GetProject retrieves a project
+func (c *Client) GetProject(ctx context.Context, owner, repo string) (*Project, error) {
+	var project Project
+	if err := c.getJSON(ctx, projectPath(owner, repo), nil, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetMergeRequest retrieves a merge request by its project-scoped IID
+func (c *Client) GetMergeRequest(ctx context.Context, owner, repo string, iid int) (*MergeRequest, error) {
+	var mr MergeRequest
+	path := fmt.Sprintf("%s/merge_requests/%d", projectPath(owner, repo), iid)
+	if err := c.getJSON(ctx, path, nil, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// ListDiffs retrieves the file diffs of a merge request
+func (c *Client) ListDiffs(ctx context.Context, owner, repo string, iid int) ([]Diff, error) {
+	var diffs []Diff
+	path := fmt.Sprintf("%s/merge_requests/%d/diffs", projectPath(owner, repo), iid)
+	err := c.paginate(ctx, path, nil, func(data []byte) error {
+		var page []Diff
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		diffs = append(diffs, page...)
+		return nil
+	})
+	return diffs, err
+}
+
+// GetRawFile retrieves the content of a file at a ref
+func (c *Client) GetRawFile(ctx context.Context, owner, repo, filePath, ref string) (string, error) {
+	path := fmt.Sprintf("%s/repository/files/%s/raw", projectPath(owner, repo), url.PathEscape(filePath))
+	resp, err := c.do(ctx, http.MethodGet, path, url.Values{"ref": {ref}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(data), nil
+}
+
+// ListTree retrieves the entries of a directory at a ref
+func (c *Client) ListTree(ctx context.Context, owner, repo, dir, ref string) ([]TreeEntry, error) {
+	var entries []TreeEntry
+	query := url.Values{"ref": {ref}}
+	if dir != "" {
+		query.Set("path", dir)
+	}
+	err := c.paginate(ctx, projectPath(owner, repo)+"/repository/tree", query, func(data []byte) error {
+		var page []TreeEntry
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		entries = append(entries, page...)
+		return nil
+	})
+	return entries, err
+}
+
+// DownloadTarball streams a gzipped tarball of the repository at ref
+func (c *Client) DownloadTarball(ctx context.Context, owner, repo, ref string) (io.ReadCloser, error) {
+	path := projectPath(owner, repo) + "/repository/archive.tar.gz"
+	resp, err := c.do(ctx, http.MethodGet, path, url.Values{"sha": {ref}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// CreateDiscussion starts a discussion on a merge request, anchored to a
+// diff line if it has a position
+func (c *Client) CreateDiscussion(ctx context.Context, owner, repo string, iid int, discussion *Discussion) error {
+	path := fmt.Sprintf("%s/merge_requests/%d/discussions", projectPath(owner, repo), iid)
+	return c.postJSON(ctx, path, discussion)
+}
+
+// CreateNote posts a general comment on a merge request
+func (c *Client) CreateNote(ctx context.Context, owner, repo string, iid int, body string) error {
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", projectPath(owner, repo), iid)
+	return c.postJSON(ctx, path, &Note{Body: body})
+}
+
+// CurrentUser retrieves the user the token authenticates as
+func (c *Client) CurrentUser(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.getJSON(ctx, "user", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListNotes retrieves the comments on a merge request, diff notes included
+func (c *Client) ListNotes(ctx context.Context, owner, repo string, iid int) ([]ListedNote, error) {
+	var notes []ListedNote
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", projectPath(owner, repo), iid)
+	err := c.paginate(ctx, path, nil, func(data []byte) error {
+		var page []ListedNote
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		notes = append(notes, page...)
+		return nil
+	})
+	return notes, err
+}
+
+// EditNote replaces the body of a merge request comment
+func (c *Client) EditNote(ctx context.Context, owner, repo string, iid int, noteID int64, body string) error {
+	path := fmt.Sprintf("%s/merge_requests/%d/notes/%d", projectPath(owner, repo), iid, noteID)
+	return c.putJSON(ctx, path, &Note{Body: body})
+}
+
+// ListNoteAwardEmoji retrieves the emoji reactions on a merge request comment
+func (c *Client) ListNoteAwardEmoji(ctx context.Context, owner, repo string, iid int, noteID int64) ([]AwardEmoji, error) {
+	var emoji []AwardEmoji
+	path := fmt.Sprintf("%s/merge_requests/%d/notes/%d/award_emoji", projectPath(owner, repo), iid, noteID)
+	err := c.paginate(ctx, path, nil, func(data []byte) error {
+		var page []AwardEmoji
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		emoji = append(emoji, page...)
+		return nil
+	})
+	return emoji, err
+}
+
+// ListMembers retrieves the members of a project matching query, including
+// those who inherit membership from its groups
+func (c *Client) ListMembers(ctx context.Context, owner, repo, query string) ([]Member, error) {
+	var members []Member
+	err := c.paginate(ctx, projectPath(owner, repo)+"/members/all", url.Values{"query": {query}}, func(data []byte) error {
+		var page []Member
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		members = append(members, page...)
+		return nil
+	})
+	return members, err
+}
+
+// Approve approves a merge request
+func (c *Client) Approve(ctx context.Context, owner, repo string, iid int) error {
+	path := fmt.Sprintf("%s/merge_requests/%d/approve", projectPath(owner, repo), iid)
+	return c.postJSON(ctx, path, nil)
+}
+
+// getJSON decodes the JSON response of a GET request into out
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// postJSON sends body as JSON and discards the response
+func (c *Client) postJSON(ctx context.Context, path string, body any) error {
+	resp, err := c.do(ctx, http.MethodPost, path, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// putJSON sends body as JSON in a PUT request and discards the response
+func (c *Client) putJSON(ctx context.Context, path string, body any) error {
+	resp, err := c.do(ctx, http.MethodPut, path, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// paginate calls fn with the body of each page of a list endpoint
+func (c *Client) paginate(ctx context.Context, path string, query url.Values, fn func([]byte) error) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		resp, err := c.do(ctx, http.MethodGet, path, query, nil)
+		if err != nil {
+			return err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		if err := fn(data); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return nil
+}
+
+// do sends an API request and returns the response if it was successful
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	// Project and file paths are escaped, so the path is parsed rather than joined
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API path %s: %w", path, err)
+	}
+	u := c.baseURL.ResolveReference(ref)
+	u.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+)
+
+var _ forge.FindingHistory = (*Forge)(nil)
+
+// ListFindings returns the fingerprinted findings the reviewer's account
+// posted on a merge request, in diff notes and general notes. Only award
+// emoji from members who can push to the project are counted. GitLab keeps
+// a diff note on the line it was posted on, so inline findings carry their
+// path for the caller to check against the head revision.
+func (f *Forge) ListFindings(ctx context.Context, owner, repo string, number int) ([]forge.PostedFinding, error) {
+	user, err := f.client.CurrentUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the authenticated user: %w", err)
+	}
+	notes, err := f.client.ListNotes(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	collaborator := forge.Collaborators(ctx, f, owner, repo)
+
+	var findings []forge.PostedFinding
+	for _, note := range notes {
+		if note.System || !strings.EqualFold(note.Author.Username, user.Username) {
+			continue
+		}
+		if note.Position == nil {
+			findings = append(findings, forge.ParseListedFindings(note.Body)...)
+			continue
+		}
+
+		fingerprints := forge.ParseFingerprints(note.Body)
+		if len(fingerprints) == 0 {
+			continue
+		}
+		up, down, err := f.countAwards(ctx, owner, repo, number, note.ID, collaborator)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list award emoji on note %d: %w", note.ID, err)
+		}
+		for _, fingerprint := range fingerprints {
+			findings = append(findings, forge.PostedFinding{
+				Fingerprint: fingerprint,
+				CommentID:   note.ID,
+				Body:        note.Body,
+				Path:        note.Position.NewPath,
+				Marked:      strings.Contains(note.Body, forge.OutdatedMarker),
+				ThumbsUp:    up,
+				ThumbsDown:  down,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// MarkOutdated notes at the top of a diff note that its code has changed
+func (f *Forge) MarkOutdated(ctx context.Context, owner, repo string, number int, finding forge.PostedFinding) error {
+	if strings.Contains(finding.Body, forge.OutdatedMarker) {
+		return nil
+	}
+
+	if err := f.client.EditNote(ctx, owner, repo, number, finding.CommentID, forge.OutdatedBody(finding.Body)); err != nil {
+		return fmt.Errorf("failed to edit note: %w", err)
+	}
+	return nil
+}
+
+// IsCollaborator reports whether a user can push to a project, directly or
+// through its groups
+func (f *Forge) IsCollaborator(ctx context.Context, owner, repo, user string) (bool, error) {
+	members, err := f.client.ListMembers(ctx, owner, repo, user)
+	if err != nil {
+		return false, err
+	}
+	for _, member := range members {
+		if strings.EqualFold(member.Username, user) && member.AccessLevel >= DeveloperAccess {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// countAwards counts the thumbs up and thumbs down emoji collaborators
+// awarded a diff note
+func (f *Forge) countAwards(ctx context.Context, owner, repo string, number int, noteID int64, collaborator func(user string) bool) (up, down int, err error) {
+	awards, err := f.client.ListNoteAwardEmoji(ctx, owner, repo, number, noteID)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, award := range awards {
+		if !collaborator(award.User.Username) {
+			continue
+		}
+		switch award.Name {
+		case "thumbsup":
+			up++
+		case "thumbsdown":
+			down++
+		}
+	}
+	return up, down, nil
+}
@@ -0,0 +1,262 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// Forge reviews GitLab merge requests. Merge requests and their diffs are
+// converted to the go-github types the review pipeline works with.
+type Forge struct {
+	client *Client
+}
+
+var _ forge.Forge = (*Forge)(nil)
+
+// NewForge creates a GitLab forge
+func NewForge(client *Client) *Forge {
+	return &Forge{client: client}
+}
+
+// GetChangeRequest fetches a merge request and its project
+func (f *Forge) GetChangeRequest(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Repository, error) {
+	mr, err := f.client.GetMergeRequest(ctx, owner, repo, number)
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+
+	project, err := f.client.GetProject(ctx, owner, repo)
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+
+	// Line counts are not part of the merge request, so they come from the diffs
+	diffs, err := f.client.ListDiffs(ctx, owner, repo, number)
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+	var additions, deletions int
+	for _, diff := range diffs {
+		a, d := countChanges(diff.Diff)
+		additions += a
+		deletions += d
+	}
+
+	pr := &github.PullRequest{
+		Number:       github.Ptr(mr.IID),
+		Title:        github.Ptr(mr.Title),
+		Body:         github.Ptr(mr.Description),
+		State:        github.Ptr(mr.State),
+		Draft:        github.Ptr(mr.Draft),
+		HTMLURL:      github.Ptr(mr.WebURL),
+		User:         &github.User{Login: github.Ptr(mr.Author.Username)},
+		Base:         &github.PullRequestBranch{Ref: github.Ptr(mr.TargetBranch), SHA: github.Ptr(mr.DiffRefs.BaseSHA)},
+		Head:         &github.PullRequestBranch{Ref: github.Ptr(mr.SourceBranch), SHA: github.Ptr(mr.DiffRefs.HeadSHA)},
+		Additions:    github.Ptr(additions),
+		Deletions:    github.Ptr(deletions),
+		ChangedFiles: github.Ptr(len(diffs)),
+	}
+	repository := &github.Repository{
+		Name:          github.Ptr(project.Path),
+		FullName:      github.Ptr(project.PathWithNamespace),
+		DefaultBranch: github.Ptr(project.DefaultBranch),
+		HTMLURL:       github.Ptr(project.WebURL),
+		Owner:         &github.User{Login: github.Ptr(project.Namespace.FullPath)},
+	}
+	return pr, repository, nil
+}
+
+// ListFiles returns the files changed in a merge request
+func (f *Forge) ListFiles(ctx context.Context, owner, repo string, number int) ([]*github.CommitFile, error) {
+	diffs, err := f.client.ListDiffs(ctx, owner, repo, number)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	files := make([]*github.CommitFile, 0, len(diffs))
+	for _, diff := range diffs {
+		files = append(files, commitFile(diff))
+	}
+	return files, nil
+}
+
+// commitFile converts a merge request diff to the GitHub file model
+func commitFile(diff Diff) *github.CommitFile {
+	additions, deletions := countChanges(diff.Diff)
+	file := &github.CommitFile{
+		Filename:  github.Ptr(diff.NewPath),
+		Additions: github.Ptr(additions),
+		Deletions: github.Ptr(deletions),
+		Changes:   github.Ptr(additions + deletions),
+		Patch:     github.Ptr(diff.Diff),
+	}
+
+	switch {
+	case diff.NewFile:
+		file.Status = github.Ptr("added")
+	case diff.DeletedFile:
+		file.Status = github.Ptr("removed")
+		file.Filename = github.Ptr(diff.OldPath)
+	case diff.RenamedFile:
+		file.Status = github.Ptr("renamed")
+		file.PreviousFilename = github.Ptr(diff.OldPath)
+	default:
+		file.Status = github.Ptr("modified")
+	}
+	return file
+}
+
+// countChanges counts the added and removed lines in a diff
+func countChanges(diff string) (additions, deletions int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			additions++
+		case strings.HasPrefix(line, "-"):
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+// ReadFile returns the content of a file at a ref
+func (f *Forge) ReadFile(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	content, err := f.client.GetRawFile(ctx, owner, repo, path, ref)
+	return content, notFound(err)
+}
+
+// ListDirectory returns the paths of the files in a directory at a ref
+func (f *Forge) ListDirectory(ctx context.Context, owner, repo, dir, ref string) ([]string, error) {
+	entries, err := f.client.ListTree(ctx, owner, repo, strings.TrimSuffix(dir, "/"), ref)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.Type == "blob" {
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths, nil
+}
+
+// DownloadTarball streams a gzipped tarball of the repository at ref
+func (f *Forge) DownloadTarball(ctx context.Context, owner, repo, ref string) (io.ReadCloser, error) {
+	return f.client.DownloadTarball(ctx, owner, repo, ref)
+}
+
+// PostReview posts each file comment as a discussion on its diff line, the
+// rest of the review as a note, and approves the merge request if the review
+// decided to. Comments GitLab won't anchor are listed in the note.
+func (f *Forge) PostReview(ctx context.Context, owner, repo string, number int, review *types.ReviewResponse, files []*github.CommitFile) error {
+	mr, err := f.client.GetMergeRequest(ctx, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to get merge request: %w", err)
+	}
+
+	fileMap := make(map[string]*github.CommitFile)
+	patches := make(map[string]*gh.Patch)
+	for _, file := range files {
+		fileMap[file.GetFilename()] = file
+		patches[file.GetFilename()] = gh.FilePatch(file)
+	}
+
+	var outside []types.FileComment
+	for _, comment := range review.FileComments {
+		file, exists := fileMap[comment.Path]
+		if !exists {
+			log.Printf("File %s not found in MR files", comment.Path)
+			continue
+		}
+
+		// Discussions on added lines only need the new line number
+		if !patches[comment.Path].IsAdded(comment.Line) {
+			log.Printf("Could not find %s:%d in the diff, moving it outside the diff", comment.Path, comment.Line)
+			outside = append(outside, comment)
+			continue
+		}
+
+		oldPath := file.GetPreviousFilename()
+		if oldPath == "" {
+			oldPath = file.GetFilename()
+		}
+		err := f.client.CreateDiscussion(ctx, owner, repo, number, &Discussion{
+			Body: forge.FormatFileComment(comment),
+			Position: &Position{
+				BaseSHA:      mr.DiffRefs.BaseSHA,
+				StartSHA:     mr.DiffRefs.StartSHA,
+				HeadSHA:      mr.DiffRefs.HeadSHA,
+				PositionType: "text",
+				OldPath:      oldPath,
+				NewPath:      file.GetFilename(),
+				NewLine:      comment.Line,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to create discussion on %s:%d, moving it outside the diff: %v", comment.Path, comment.Line, err)
+			outside = append(outside, comment)
+		}
+	}
+
+	if len(outside) > 0 {
+		withOutside := *review
+		withOutside.OutsideDiff = slices.Concat(review.OutsideDiff, outside)
+		review = &withOutside
+	}
+
+	if body := forge.FormatReviewBody(review, mapDecisionToEvent); body != "" {
+		if err := f.client.CreateNote(ctx, owner, repo, number, body); err != nil {
+			return fmt.Errorf("failed to post review note: %w", err)
+		}
+	}
+
+	if review.Decision == types.DecisionApprove {
+		if err := f.client.Approve(ctx, owner, repo, number); err != nil {
+			return fmt.Errorf("failed to approve merge request: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PostNote posts a general comment on a merge request
+func (f *Forge) PostNote(ctx context.Context, owner, repo string, number int, body string) error {
+	return f.client.CreateNote(ctx, owner, repo, number, body)
+}
+
+// LineURL returns the web URL of a line of a file at a ref
+func (f *Forge) LineURL(repository *github.Repository, ref, path string, line int) string {
+	return forge.LineURL(repository.GetHTMLURL(), "-/blob/"+ref, path, line)
+}
+
+// mapDecisionToEvent names the action taken on the merge request for a
+// decision. GitLab has no review state for requested changes, so those
+// reviews are only posted as comments.
+func mapDecisionToEvent(decision types.ReviewDecision) string {
+	switch decision {
+	case types.DecisionApprove:
+		return "APPROVE"
+	case types.DecisionRequestChanges:
+		return "REQUEST_CHANGES (comment)"
+	default:
+		return "COMMENT"
+	}
+}
+
+// notFound marks GitLab 404 responses as forge.ErrNotFound
+func notFound(err error) error {
+	if IsNotFound(err) {
+		return fmt.Errorf("%w: %w", forge.ErrNotFound, err)
+	}
+	return err
+}
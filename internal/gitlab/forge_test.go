@@ -0,0 +1,191 @@
+package gitlab_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge/forgetest"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitlab"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitlab/gitlabtest"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+func newMergeRequest(t *testing.T) (*gitlabtest.Server, *gitlab.Forge) {
+	t.Helper()
+
+	server := gitlabtest.NewServer()
+	t.Cleanup(server.Close)
+
+	server.AddMergeRequest(&gitlabtest.MergeRequest{
+		Namespace: "libraries/apps", Project: "catalog", IID: 12,
+		Title: "Add main", Author: "dev",
+		SourceBranch: "feature", TargetBranch: "main",
+		BaseSHA: "base", StartSHA: "start", HeadSHA: "head",
+		Diffs: []gitlab.Diff{
+			{OldPath: forgetest.MainPath, NewPath: forgetest.MainPath, Diff: forgetest.MainPatch},
+			{OldPath: "old.txt", NewPath: "old.txt", Diff: "@@ -1 +0,0 @@\n-gone\n", DeletedFile: true},
+		},
+	})
+	server.AddFiles("libraries/apps", "catalog", "head", map[string]string{
+		forgetest.MainPath: forgetest.MainHead,
+		"README.md":        "# catalog\n",
+	})
+	return server, gitlab.NewForge(server.Client())
+}
+
+func TestChangeRequest(t *testing.T) {
+	_, f := newMergeRequest(t)
+	forgetest.CheckChange(t, f, forgetest.Change{Owner: "libraries/apps", Repo: "catalog", Number: 12, HeadSHA: "head"})
+
+	// Line counts are summed from the diffs, deletions included
+	pr, _, err := f.GetChangeRequest(context.Background(), "libraries/apps", "catalog", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.GetAdditions() != 2 || pr.GetDeletions() != 1 {
+		t.Errorf("got +%d -%d, want +2 -1", pr.GetAdditions(), pr.GetDeletions())
+	}
+
+	files, err := f.ListFiles(context.Background(), "libraries/apps", "catalog", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[1].GetStatus() != "removed" {
+		t.Errorf("unexpected files: %v", files)
+	}
+}
+
+func TestPostReview(t *testing.T) {
+	server, f := newMergeRequest(t)
+	ctx := context.Background()
+
+	files, err := f.ListFiles(ctx, "libraries/apps", "catalog", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.PostReview(ctx, "libraries/apps", "catalog", 12, forgetest.Review(types.DecisionApprove), files); err != nil {
+		t.Fatal(err)
+	}
+
+	discussions := server.Discussions()
+	if len(discussions) != 1 {
+		t.Fatalf("got %d discussions, want 1", len(discussions))
+	}
+	position := discussions[0].Discussion.Position
+	if position.NewPath != forgetest.MainPath || position.NewLine != 3 || position.StartSHA != "start" {
+		t.Errorf("unexpected position: %+v", position)
+	}
+
+	notes := server.Notes()
+	if len(notes) != 1 || !strings.Contains(notes[0].Body, "**Summary:** One bug") || !strings.Contains(notes[0].Body, "Outside the diff") {
+		t.Errorf("unexpected notes: %+v", notes)
+	}
+	if approvals := server.Approvals(); len(approvals) != 1 {
+		t.Errorf("got %d approvals, want 1", len(approvals))
+	}
+}
+
+func TestPostReviewFailedDiscussion(t *testing.T) {
+	server, f := newMergeRequest(t)
+	ctx := context.Background()
+
+	files, err := f.ListFiles(ctx, "libraries/apps", "catalog", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A comment GitLab refuses to anchor is kept in the note
+	server.FailDiscussions(http.StatusInternalServerError)
+	if err := f.PostReview(ctx, "libraries/apps", "catalog", 12, forgetest.Review(types.DecisionComment), files); err != nil {
+		t.Fatal(err)
+	}
+
+	if discussions := server.Discussions(); len(discussions) != 0 {
+		t.Errorf("got %d discussions, want 0", len(discussions))
+	}
+	notes := server.Notes()
+	if len(notes) != 1 || !strings.Contains(notes[0].Body, "Empty main") {
+		t.Errorf("failed comment missing from notes: %+v", notes)
+	}
+}
+
+func TestFindingHistory(t *testing.T) {
+	server, f := newMergeRequest(t)
+	ctx := context.Background()
+
+	files, err := f.ListFiles(ctx, "libraries/apps", "catalog", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	review := forgetest.Review(types.DecisionComment)
+	review.FileComments[0].Fingerprint = "0000000000000001"
+	review.FileComments[1].Fingerprint = "0000000000000002"
+	if err := f.PostReview(ctx, "libraries/apps", "catalog", 12, review, files); err != nil {
+		t.Fatal(err)
+	}
+
+	// Markers copied into someone else's note don't count, and neither do
+	// emoji from users who can't push
+	server.AddNote("libraries/apps", "catalog", 12, &gitlab.ListedNote{Body: forge.FingerprintMarker("0000000000000003"), Author: gitlab.User{Username: "dev"}})
+	server.AddMember("libraries/apps", "catalog", "maintainer", 40)
+	server.AddMember("libraries/apps", "catalog", "reporter", 20)
+	inline := server.ListedNotes("libraries/apps", "catalog", 12)[0]
+	server.AddAwardEmoji(inline.ID, "maintainer", "thumbsdown")
+	server.AddAwardEmoji(inline.ID, "reporter", "thumbsdown")
+	server.AddAwardEmoji(inline.ID, "dev", "thumbsup")
+
+	findings, err := f.ListFindings(ctx, "libraries/apps", "catalog", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []forge.PostedFinding{
+		{Fingerprint: "0000000000000001", CommentID: inline.ID, Body: inline.Body, Path: forgetest.MainPath, ThumbsDown: 1},
+		{Fingerprint: "0000000000000002", Path: forgetest.MainPath},
+	}
+	if !reflect.DeepEqual(findings, want) {
+		t.Errorf("got findings %+v, want %+v", findings, want)
+	}
+
+	// Marking a diff note outdated edits it once
+	for range 2 {
+		findings[0].Body = server.ListedNotes("libraries/apps", "catalog", 12)[0].Body
+		if err := f.MarkOutdated(ctx, "libraries/apps", "catalog", 12, findings[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if body := server.ListedNotes("libraries/apps", "catalog", 12)[0].Body; strings.Count(body, forge.OutdatedMarker) != 1 || !strings.Contains(body, "Empty main") {
+		t.Errorf("unexpected outdated note: %q", body)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	payload := `{"object_kind":"merge_request","project":{"path_with_namespace":"libraries/apps/catalog"},` +
+		`"object_attributes":{"iid":12,"action":"update","oldrev":"abc"}}`
+
+	r := httptest.NewRequest("POST", "/webhook/gitlab", strings.NewReader(payload))
+	r.Header.Set("X-Gitlab-Event", gitlab.MergeRequestEvent)
+	r.Header.Set("X-Gitlab-Token", "wrong")
+	if _, err := gitlab.ReadWebhookPayload(r, "secret"); err == nil {
+		t.Error("accepted a webhook with the wrong token")
+	}
+
+	r.Header.Set("X-Gitlab-Token", "secret")
+	data, err := gitlab.ReadWebhookPayload(r, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := gitlab.ParseMergeRequestHook(r, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner, repo := hook.Repository()
+	if owner != "libraries/apps" || repo != "catalog" || !hook.ShouldReview() {
+		t.Errorf("got %s/%s, review %v", owner, repo, hook.ShouldReview())
+	}
+}
@@ -0,0 +1,467 @@
+// Package gitlabtest provides an in-process fake of the GitLab API endpoints
+// the reviewer uses, for end-to-end tests without network access.
+package gitlabtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge/forgetest"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitlab"
+)
+
+// MergeRequest is a scripted merge request served by the fake
+type MergeRequest struct {
+	Namespace    string
+	Project      string
+	IID          int
+	Title        string
+	Description  string
+	Author       string
+	Draft        bool
+	SourceBranch string
+	TargetBranch string
+	BaseSHA      string
+	StartSHA     string
+	HeadSHA      string
+	Diffs        []gitlab.Diff
+}
+
+// Discussion is a discussion started on the fake
+type Discussion struct {
+	Namespace  string
+	Project    string
+	IID        int
+	Discussion gitlab.Discussion
+}
+
+// Note is a note posted to the fake
+type Note struct {
+	Namespace string
+	Project   string
+	IID       int
+	Body      string
+}
+
+// botUser is the account the fake attributes posted discussions and notes
+// to, as for a project access token
+var botUser = gitlab.User{Username: "project_1_bot"}
+
+// Server is a fake GitLab API. Create one with NewServer and close it when done.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	mrs         map[string]*MergeRequest
+	trees       forgetest.Trees
+	discussions []Discussion
+	failStatus  int
+	notes       []Note
+	approvals   []string
+	listed      map[string][]*gitlab.ListedNote
+	awards      map[int64][]gitlab.AwardEmoji
+	members     map[string][]gitlab.Member
+	nextNoteID  int64
+}
+
+// NewServer starts a fake GitLab API server
+func NewServer() *Server {
+	s := &Server{
+		mrs:     make(map[string]*MergeRequest),
+		listed:  make(map[string][]*gitlab.ListedNote),
+		awards:  make(map[int64][]gitlab.AwardEmoji),
+		members: make(map[string][]gitlab.Member),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/user", s.handleUser)
+	mux.HandleFunc("GET /api/v4/projects/{id}", s.handleProject)
+	mux.HandleFunc("GET /api/v4/projects/{id}/members/all", s.handleMembers)
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}", s.handleMergeRequest)
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}/diffs", s.handleDiffs)
+	mux.HandleFunc("POST /api/v4/projects/{id}/merge_requests/{iid}/discussions", s.handleCreateDiscussion)
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}/notes", s.handleListNotes)
+	mux.HandleFunc("POST /api/v4/projects/{id}/merge_requests/{iid}/notes", s.handleCreateNote)
+	mux.HandleFunc("PUT /api/v4/projects/{id}/merge_requests/{iid}/notes/{note}", s.handleEditNote)
+	mux.HandleFunc("GET /api/v4/projects/{id}/merge_requests/{iid}/notes/{note}/award_emoji", s.handleAwardEmoji)
+	mux.HandleFunc("POST /api/v4/projects/{id}/merge_requests/{iid}/approve", s.handleApprove)
+	mux.HandleFunc("GET /api/v4/projects/{id}/repository/files/{path}/raw", s.handleRawFile)
+	mux.HandleFunc("GET /api/v4/projects/{id}/repository/tree", s.handleTree)
+	s.Server = httptest.NewServer(forgetest.Authenticate("PRIVATE-TOKEN", forgetest.Token, "401 Unauthorized", mux))
+
+	return s
+}
+
+// Client returns a reviewer GitLab client pointed at the fake
+func (s *Server) Client() *gitlab.Client {
+	client, err := gitlab.NewClient(s.URL, forgetest.Token)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// AddMergeRequest scripts a merge request
+func (s *Server) AddMergeRequest(mr *MergeRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mrs[mrKey(mr.Namespace+"/"+mr.Project, mr.IID)] = mr
+}
+
+// AddFiles sets the files in a project at a commit, keyed by path
+func (s *Server) AddFiles(namespace, project, ref string, files map[string]string) {
+	s.trees.Add(namespace+"/"+project, ref, files)
+}
+
+// FailDiscussions makes every following discussion fail with status, or
+// succeed again if status is zero
+func (s *Server) FailDiscussions(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failStatus = status
+}
+
+// Discussions returns the discussions started so far
+func (s *Server) Discussions() []Discussion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Discussion(nil), s.discussions...)
+}
+
+// Notes returns the notes posted so far
+func (s *Server) Notes() []Note {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Note(nil), s.notes...)
+}
+
+// AddNote scripts a note listed on a merge request, and sets its ID. Notes
+// without an author are attributed to the reviewer's account.
+func (s *Server) AddNote(namespace, project string, iid int, note *gitlab.ListedNote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if note.Author.Username == "" {
+		note.Author = botUser
+	}
+	s.listNote(namespace+"/"+project, iid, note)
+}
+
+// AddAwardEmoji scripts a user's award emoji, such as "thumbsup" or
+// "thumbsdown", on a note
+func (s *Server) AddAwardEmoji(noteID int64, username, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.awards[noteID] = append(s.awards[noteID], gitlab.AwardEmoji{Name: name, User: gitlab.User{Username: username}})
+}
+
+// AddMember makes a user a member of a project with an access level
+func (s *Server) AddMember(namespace, project, username string, accessLevel int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := namespace + "/" + project
+	s.members[key] = append(s.members[key], gitlab.Member{Username: username, AccessLevel: accessLevel})
+}
+
+// ListedNotes returns the notes listed on a merge request, posted and
+// scripted, as edited so far
+func (s *Server) ListedNotes(namespace, project string, iid int) []gitlab.ListedNote {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var notes []gitlab.ListedNote
+	for _, note := range s.listed[mrKey(namespace+"/"+project, iid)] {
+		notes = append(notes, *note)
+	}
+	return notes
+}
+
+// Approvals returns the merge requests approved so far, as namespace/project!iid
+func (s *Server) Approvals() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.approvals...)
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	forgetest.WriteJSON(w, http.StatusOK, &botUser)
+}
+
+func (s *Server) handleMembers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	members := []gitlab.Member{}
+	for _, member := range s.members[r.PathValue("id")] {
+		if strings.Contains(member.Username, query) {
+			members = append(members, member)
+		}
+	}
+	forgetest.WriteJSON(w, http.StatusOK, members)
+}
+
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
+	fullPath := r.PathValue("id")
+	namespace, name := path.Split(fullPath)
+	forgetest.WriteJSON(w, http.StatusOK, &gitlab.Project{
+		ID:                1,
+		Name:              name,
+		Path:              name,
+		PathWithNamespace: fullPath,
+		DefaultBranch:     "main",
+		Namespace:         gitlab.Namespace{FullPath: strings.TrimSuffix(namespace, "/")},
+	})
+}
+
+func (s *Server) handleMergeRequest(w http.ResponseWriter, r *http.Request) {
+	mr := s.mergeRequest(r)
+	if mr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+
+	forgetest.WriteJSON(w, http.StatusOK, &gitlab.MergeRequest{
+		IID:          mr.IID,
+		Title:        mr.Title,
+		Description:  mr.Description,
+		State:        "opened",
+		Draft:        mr.Draft,
+		SourceBranch: mr.SourceBranch,
+		TargetBranch: mr.TargetBranch,
+		SHA:          mr.HeadSHA,
+		Author:       gitlab.User{Username: mr.Author},
+		DiffRefs:     gitlab.DiffRefs{BaseSHA: mr.BaseSHA, StartSHA: mr.StartSHA, HeadSHA: mr.HeadSHA},
+	})
+}
+
+// handleDiffs serves the diffs one page at a time, like GitLab
+func (s *Server) handleDiffs(w http.ResponseWriter, r *http.Request) {
+	mr := s.mergeRequest(r)
+	if mr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	page, perPage = max(page, 1), max(perPage, 1)
+
+	start := min((page-1)*perPage, len(mr.Diffs))
+	end := min(start+perPage, len(mr.Diffs))
+	if end < len(mr.Diffs) {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+	forgetest.WriteJSON(w, http.StatusOK, mr.Diffs[start:end])
+}
+
+func (s *Server) handleCreateDiscussion(w http.ResponseWriter, r *http.Request) {
+	mr := s.mergeRequest(r)
+	if mr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+
+	var discussion gitlab.Discussion
+	if err := json.NewDecoder(r.Body).Decode(&discussion); err != nil {
+		forgetest.WriteError(w, http.StatusBadRequest, "400 Bad request")
+		return
+	}
+
+	s.mu.Lock()
+	failStatus := s.failStatus
+	s.mu.Unlock()
+	if failStatus != 0 {
+		forgetest.WriteError(w, failStatus, http.StatusText(failStatus))
+		return
+	}
+
+	// GitLab rejects positions that do not resolve to a line of the diff
+	if discussion.Position != nil {
+		if message := validatePosition(mr, discussion.Position); message != "" {
+			forgetest.WriteError(w, http.StatusBadRequest, message)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.discussions = append(s.discussions, Discussion{Namespace: mr.Namespace, Project: mr.Project, IID: mr.IID, Discussion: discussion})
+	s.listNote(mr.Namespace+"/"+mr.Project, mr.IID, &gitlab.ListedNote{Body: discussion.Body, Author: botUser, Position: discussion.Position})
+	id := fmt.Sprintf("%040x", len(s.discussions))
+	s.mu.Unlock()
+
+	forgetest.WriteJSON(w, http.StatusCreated, map[string]any{"id": id})
+}
+
+func (s *Server) handleCreateNote(w http.ResponseWriter, r *http.Request) {
+	mr := s.mergeRequest(r)
+	if mr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+
+	var note gitlab.Note
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil || note.Body == "" {
+		forgetest.WriteError(w, http.StatusBadRequest, "400 Bad request - body is missing")
+		return
+	}
+
+	s.mu.Lock()
+	s.notes = append(s.notes, Note{Namespace: mr.Namespace, Project: mr.Project, IID: mr.IID, Body: note.Body})
+	s.listNote(mr.Namespace+"/"+mr.Project, mr.IID, &gitlab.ListedNote{Body: note.Body, Author: botUser})
+	s.mu.Unlock()
+
+	forgetest.WriteJSON(w, http.StatusCreated, &note)
+}
+
+func (s *Server) handleListNotes(w http.ResponseWriter, r *http.Request) {
+	mr := s.mergeRequest(r)
+	if mr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notes := []*gitlab.ListedNote{}
+	notes = append(notes, s.listed[mrKey(r.PathValue("id"), mr.IID)]...)
+	forgetest.WriteJSON(w, http.StatusOK, notes)
+}
+
+func (s *Server) handleEditNote(w http.ResponseWriter, r *http.Request) {
+	var edit gitlab.Note
+	if err := json.NewDecoder(r.Body).Decode(&edit); err != nil || edit.Body == "" {
+		forgetest.WriteError(w, http.StatusBadRequest, "400 Bad request - body is missing")
+		return
+	}
+
+	note := s.listedNote(r)
+	if note == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	note.Body = edit.Body
+	forgetest.WriteJSON(w, http.StatusOK, note)
+}
+
+func (s *Server) handleAwardEmoji(w http.ResponseWriter, r *http.Request) {
+	note := s.listedNote(r)
+	if note == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	awards := []gitlab.AwardEmoji{}
+	awards = append(awards, s.awards[note.ID]...)
+	forgetest.WriteJSON(w, http.StatusOK, awards)
+}
+
+func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
+	mr := s.mergeRequest(r)
+	if mr == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Not found")
+		return
+	}
+
+	s.mu.Lock()
+	s.approvals = append(s.approvals, fmt.Sprintf("%s/%s!%d", mr.Namespace, mr.Project, mr.IID))
+	s.mu.Unlock()
+
+	forgetest.WriteJSON(w, http.StatusCreated, map[string]any{"approved": true})
+}
+
+func (s *Server) handleRawFile(w http.ResponseWriter, r *http.Request) {
+	tree := s.trees.Files(r.PathValue("id"), r.URL.Query().Get("ref"))
+	content, ok := tree[r.PathValue("path")]
+	if !ok {
+		forgetest.WriteError(w, http.StatusNotFound, "404 File Not Found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(content))
+}
+
+func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
+	tree := s.trees.Files(r.PathValue("id"), r.URL.Query().Get("ref"))
+	if tree == nil {
+		forgetest.WriteError(w, http.StatusNotFound, "404 Tree Not Found")
+		return
+	}
+
+	dir := strings.Trim(r.URL.Query().Get("path"), "/")
+	entries := []gitlab.TreeEntry{}
+	for _, p := range forgetest.DirPaths(tree, dir) {
+		entries = append(entries, gitlab.TreeEntry{Name: path.Base(p), Type: "blob", Path: p})
+	}
+	forgetest.WriteJSON(w, http.StatusOK, entries)
+}
+
+// mergeRequest returns the scripted merge request a request refers to
+func (s *Server) mergeRequest(r *http.Request) *MergeRequest {
+	iid, _ := strconv.Atoi(r.PathValue("iid"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mrs[mrKey(r.PathValue("id"), iid)]
+}
+
+// listedNote returns the note on a merge request a request refers to
+func (s *Server) listedNote(r *http.Request) *gitlab.ListedNote {
+	iid, _ := strconv.Atoi(r.PathValue("iid"))
+	id, _ := strconv.ParseInt(r.PathValue("note"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, note := range s.listed[mrKey(r.PathValue("id"), iid)] {
+		if note.ID == id {
+			return note
+		}
+	}
+	return nil
+}
+
+// listNote adds a note to those listed on a merge request, with the next ID.
+// The caller holds s.mu.
+func (s *Server) listNote(project string, iid int, note *gitlab.ListedNote) {
+	s.nextNoteID++
+	note.ID = s.nextNoteID
+	key := mrKey(project, iid)
+	s.listed[key] = append(s.listed[key], note)
+}
+
+// validatePosition returns why GitLab would reject a discussion position, if it would
+func validatePosition(mr *MergeRequest, position *gitlab.Position) string {
+	if position.BaseSHA != mr.BaseSHA || position.StartSHA != mr.StartSHA || position.HeadSHA != mr.HeadSHA {
+		return "400 Bad request - Note {:position=>[\"is incomplete\"]}"
+	}
+
+	for _, diff := range mr.Diffs {
+		if diff.NewPath != position.NewPath || diff.OldPath != position.OldPath {
+			continue
+		}
+
+		file := &github.CommitFile{Filename: github.Ptr(diff.NewPath), Patch: github.Ptr(diff.Diff)}
+		if position.NewLine > 0 && gh.IsLineInDiff(file, position.NewLine) {
+			return ""
+		}
+		break
+	}
+	return "400 Bad request - Note {:line_code=>[\"can't be blank\", \"must be a valid line code\"]}"
+}
+
+// mrKey identifies a merge request
+func mrKey(project string, iid int) string {
+	return fmt.Sprintf("%s!%d", project, iid)
+}
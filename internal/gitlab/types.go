@@ -0,0 +1,111 @@
+package gitlab
+
+// Project is a GitLab project
+type Project struct {
+	ID                int       `json:"id"`
+	Name              string    `json:"name"`
+	Path              string    `json:"path"`
+	PathWithNamespace string    `json:"path_with_namespace"`
+	DefaultBranch     string    `json:"default_branch"`
+	WebURL            string    `json:"web_url"`
+	Namespace         Namespace `json:"namespace"`
+}
+
+// Namespace is the group or user a project belongs to
+type Namespace struct {
+	FullPath string `json:"full_path"`
+}
+
+// User is a GitLab user
+type User struct {
+	Username string `json:"username"`
+}
+
+// MergeRequest is a GitLab merge request
+type MergeRequest struct {
+	ID           int      `json:"id"`
+	IID          int      `json:"iid"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	State        string   `json:"state"`
+	Draft        bool     `json:"draft"`
+	SourceBranch string   `json:"source_branch"`
+	TargetBranch string   `json:"target_branch"`
+	SHA          string   `json:"sha"`
+	WebURL       string   `json:"web_url"`
+	Author       User     `json:"author"`
+	DiffRefs     DiffRefs `json:"diff_refs"`
+}
+
+// DiffRefs are the commits a merge request's diff is computed between
+type DiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// Diff is the change to a single file in a merge request. Diff holds the
+// unified diff hunks without file headers.
+type Diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// TreeEntry is a file or directory in a repository tree
+type TreeEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+// Position anchors a discussion to a line of a merge request diff
+type Position struct {
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	PositionType string `json:"position_type"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	NewLine      int    `json:"new_line,omitempty"`
+	OldLine      int    `json:"old_line,omitempty"`
+}
+
+// Discussion is a new merge request discussion
+type Discussion struct {
+	Body     string    `json:"body"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// Note is a general merge request comment
+type Note struct {
+	Body string `json:"body"`
+}
+
+// ListedNote is a comment listed on a merge request. Diff notes have the
+// position they were anchored to.
+type ListedNote struct {
+	ID       int64     `json:"id"`
+	Body     string    `json:"body"`
+	Author   User      `json:"author"`
+	System   bool      `json:"system"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// AwardEmoji is an emoji reaction on a note
+type AwardEmoji struct {
+	Name string `json:"name"`
+	User User   `json:"user"`
+}
+
+// DeveloperAccess is the lowest access level that can push to a project
+const DeveloperAccess = 30
+
+// Member is a member of a project, directly or through its groups
+type Member struct {
+	Username    string `json:"username"`
+	AccessLevel int    `json:"access_level"`
+}
@@ -0,0 +1,39 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParseMergeRequestURL extracts the namespace, project and IID from a merge
+// request URL such as https://gitlab.com/group/project/-/merge_requests/12.
+// The URL must be served from host.
+func ParseMergeRequestURL(raw, host string) (owner, repo string, iid int, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid merge request URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", "", 0, fmt.Errorf("invalid merge request URL: %s", raw)
+	}
+	if !strings.EqualFold(u.Host, host) {
+		return "", "", 0, fmt.Errorf("merge request URL host %s is not the configured GitLab host %s", u.Host, host)
+	}
+
+	project, rest, found := strings.Cut(strings.Trim(u.Path, "/"), "/-/merge_requests/")
+	i := strings.LastIndex(project, "/")
+	if !found || i <= 0 {
+		return "", "", 0, fmt.Errorf("not a merge request URL: %s", raw)
+	}
+
+	// Trailing segments such as /diffs are ignored
+	number, _, _ := strings.Cut(rest, "/")
+	iid, err = strconv.Atoi(number)
+	if err != nil || iid <= 0 {
+		return "", "", 0, fmt.Errorf("invalid merge request number in %s", raw)
+	}
+
+	return project[:i], project[i+1:], iid, nil
+}
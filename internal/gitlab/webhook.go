@@ -0,0 +1,79 @@
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MergeRequestEvent is the X-Gitlab-Event value of merge request webhooks
+const MergeRequestEvent = "Merge Request Hook"
+
+// MergeRequestHook is the payload of a merge request webhook
+type MergeRequestHook struct {
+	ObjectKind string `json:"object_kind"`
+	Project    struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		Action string `json:"action"`
+		OldRev string `json:"oldrev"`
+	} `json:"object_attributes"`
+}
+
+// ReadWebhookPayload checks that a webhook delivery carries the configured
+// secret token and returns its payload
+func ReadWebhookPayload(r *http.Request, secret string) ([]byte, error) {
+	token := r.Header.Get("X-Gitlab-Token")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return nil, fmt.Errorf("invalid X-Gitlab-Token header")
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+	return payload, nil
+}
+
+// ParseMergeRequestHook parses a merge request webhook payload
+func ParseMergeRequestHook(r *http.Request, payload []byte) (*MergeRequestHook, error) {
+	if event := r.Header.Get("X-Gitlab-Event"); event != MergeRequestEvent {
+		return nil, fmt.Errorf("unsupported GitLab event: %q", event)
+	}
+
+	var hook MergeRequestHook
+	if err := json.Unmarshal(payload, &hook); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+	if hook.ObjectKind != "merge_request" || hook.ObjectAttributes.IID == 0 {
+		return nil, fmt.Errorf("not a merge request webhook payload")
+	}
+	return &hook, nil
+}
+
+// Repository returns the namespace and path of the hook's project
+func (h *MergeRequestHook) Repository() (owner, repo string) {
+	fullPath := h.Project.PathWithNamespace
+	i := strings.LastIndex(fullPath, "/")
+	return fullPath[:max(i, 0)], fullPath[i+1:]
+}
+
+// ShouldReview determines if the hook should trigger a review: merge requests
+// that are opened, reopened, or updated with new commits
+func (h *MergeRequestHook) ShouldReview() bool {
+	switch h.ObjectAttributes.Action {
+	case "open", "reopen":
+		return true
+	case "update":
+		// Updates without oldrev change only the title, labels and the like
+		return h.ObjectAttributes.OldRev != ""
+	default:
+		return false
+	}
+}
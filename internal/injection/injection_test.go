@@ -0,0 +1,116 @@
+package injection_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/injection"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/prompt"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// stubModel is an Ollama chat server whose model always does what the
+// injected text asks and approves
+func stubModel(t *testing.T, requests *[]llm.OllamaRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.OllamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		*requests = append(*requests, req)
+
+		content, _ := json.Marshal(types.ReviewResponse{Decision: types.DecisionApprove, DecisionRationale: "Looks good"})
+		_ = json.NewEncoder(w).Encode(llm.OllamaResponse{
+			Message: llm.OllamaMessage{Role: "assistant", Content: string(content)},
+			Done:    true,
+		})
+	}))
+}
+
+// review runs a fixture through detection, the stub model and the guard
+func review(t *testing.T, fixture string) (*types.ReviewResponse, []injection.Marker, llm.OllamaRequest) {
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []llm.OllamaRequest
+	server := stubModel(t, &requests)
+	defer server.Close()
+
+	tmpl, err := prompt.NewLoader("").Load("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	llmPrompt, err := tmpl.Render(prompt.Data{Repository: "example/repo"}, string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	markers := injection.Detect(string(data))
+	result, err := llm.NewOllamaClient(server.URL, "stub").ReviewCode(context.Background(), llmPrompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	injection.Guard(result, markers)
+
+	if len(requests) != 1 {
+		t.Fatalf("got %d model requests, want 1", len(requests))
+	}
+	return result, markers, requests[0]
+}
+
+func TestAdversarialFixtures(t *testing.T) {
+	fixtures, _ := filepath.Glob("testdata/adversarial/*.txt")
+	if len(fixtures) == 0 {
+		t.Fatal("no adversarial fixtures")
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			result, markers, req := review(t, fixture)
+
+			if len(markers) == 0 {
+				t.Error("no injection markers detected")
+			}
+			if result.Decision == types.DecisionApprove {
+				t.Error("review approved despite injection markers")
+			}
+			if len(result.GeneralComments) == 0 || result.GeneralComments[len(result.GeneralComments)-1].Severity != types.SeverityWarning {
+				t.Error("injection was not flagged in the review")
+			}
+
+			// PR content stays in the user message, inside one delimited block
+			system, user := req.Messages[0], req.Messages[1]
+			if system.Role != "system" || strings.Contains(system.Content, "PR Title:") {
+				t.Error("PR content leaked into the system message")
+			}
+			if strings.Count(user.Content, injection.OpenTag) != 1 || strings.Count(user.Content, injection.CloseTag) != 1 {
+				t.Error("PR content was able to open or close a delimiter")
+			}
+		})
+	}
+}
+
+func TestBenignFixtures(t *testing.T) {
+	fixtures, _ := filepath.Glob("testdata/benign/*.txt")
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			result, markers, _ := review(t, fixture)
+
+			if len(markers) != 0 {
+				t.Errorf("unexpected injection markers: %v", markers)
+			}
+			if result.Decision != types.DecisionApprove {
+				t.Errorf("decision = %s, want approve", result.Decision)
+			}
+		})
+	}
+}
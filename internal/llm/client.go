@@ -2,14 +2,26 @@ package llm
 
 import (
 	"context"
+	"errors"
 
 	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
 )
 
+// ErrInvalidResponse is returned when the model's response is not a valid
+// review
+var ErrInvalidResponse = errors.New("invalid model response")
+
+// Prompt is a review request. System holds the trusted instructions and User
+// the delimited, untrusted pull request content.
+type Prompt struct {
+	System string
+	User   string
+}
+
 // Client defines the interface for LLM providers
 type Client interface {
 	// ReviewCode sends code for review and returns structured feedback
-	ReviewCode(ctx context.Context, prompt string) (*types.ReviewResponse, error)
+	ReviewCode(ctx context.Context, prompt Prompt) (*types.ReviewResponse, error)
 
 	// GetModel returns the model being used
 	GetModel() string
@@ -17,3 +29,32 @@ type Client interface {
 	// Health checks if the LLM service is available
 	Health(ctx context.Context) error
 }
+
+// RawClient is implemented by clients that can return the model's response
+// before it is parsed, so it can be recorded as is
+type RawClient interface {
+	// Complete returns the model's unparsed response to a prompt
+	Complete(ctx context.Context, prompt Prompt) (string, error)
+}
+
+// UsageReporter is implemented by clients that track token usage
+type UsageReporter interface {
+	// Usage returns the usage accumulated since the client was created
+	Usage() Usage
+}
+
+// ProviderReporter is implemented by clients that know which provider they
+// send prompts to
+type ProviderReporter interface {
+	// Provider returns the provider prompts are sent to
+	Provider() ProviderType
+}
+
+// ProviderOf returns the provider a client sends prompts to. Clients that
+// don't report one are assumed to be remote.
+func ProviderOf(client Client) ProviderType {
+	if reporter, ok := client.(ProviderReporter); ok {
+		return reporter.Provider()
+	}
+	return ProviderUnknown
+}
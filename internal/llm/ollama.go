@@ -5,8 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
@@ -17,20 +20,31 @@ type OllamaClient struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
+
+	mu    sync.Mutex
+	usage Usage
+}
+
+// OllamaMessage is a single chat message
+type OllamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
 }
 
-// OllamaRequest represents a request to Ollama API
+// OllamaRequest represents a request to Ollama chat API
 type OllamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model    string          `json:"model"`
+	Messages []OllamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
 }
 
-// OllamaResponse represents a response from Ollama API
+// OllamaResponse represents a response from Ollama chat API
 type OllamaResponse struct {
-	Response string `json:"response"`
-	Done     bool   `json:"done"`
-	Error    string `json:"error,omitempty"`
+	Message         OllamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	Error           string        `json:"error,omitempty"`
+	PromptEvalCount int           `json:"prompt_eval_count,omitempty"`
+	EvalCount       int           `json:"eval_count,omitempty"`
 }
 
 // NewOllamaClient creates a new Ollama client
@@ -45,48 +59,79 @@ func NewOllamaClient(baseURL, model string) *OllamaClient {
 }
 
 // ReviewCode sends code for review to Ollama
-func (c *OllamaClient) ReviewCode(ctx context.Context, prompt string) (*types.ReviewResponse, error) {
-	// Create the request
+func (c *OllamaClient) ReviewCode(ctx context.Context, prompt Prompt) (*types.ReviewResponse, error) {
+	response, err := c.Complete(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(response)
+}
+
+// Complete sends a prompt to Ollama and returns the model's unparsed response
+func (c *OllamaClient) Complete(ctx context.Context, prompt Prompt) (string, error) {
+	// Instructions and untrusted PR content go in separate messages
 	reqBody := OllamaRequest{
-		Model:  c.model,
-		Prompt: c.buildReviewPrompt(prompt),
+		Model: c.model,
+		Messages: []OllamaMessage{
+			{Role: "system", Content: prompt.System},
+			{Role: "user", Content: prompt.User},
+		},
 		Stream: false,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Send request to Ollama
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+		return "", fmt.Errorf("ollama returned status %d", resp.StatusCode)
 	}
 
 	// Parse Ollama response
 	var ollamaResp OllamaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if ollamaResp.Error != "" {
-		return nil, fmt.Errorf("ollama error: %s", ollamaResp.Error)
+		return "", fmt.Errorf("ollama error: %s", ollamaResp.Error)
 	}
 
-	// Parse the review response
-	return c.parseReviewResponse(ollamaResp.Response)
+	c.recordUsage(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+	return ollamaResp.Message.Content, nil
+}
+
+// recordUsage adds the tokens used by one request to the running totals
+func (c *OllamaClient) recordUsage(input, output int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.usage.InputTokens += input
+	c.usage.OutputTokens += output
+	c.usage.TotalTokens += input + output
+	c.usage.RequestCount++
+}
+
+// Usage returns the tokens used by every request so far
+func (c *OllamaClient) Usage() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.usage
 }
 
 // GetModel returns the model being used
@@ -94,6 +139,29 @@ func (c *OllamaClient) GetModel() string {
 	return c.model
 }
 
+// Provider reports whether the Ollama server is on our own network: on this
+// machine, at a private address, or at a host name that only resolves inside
+// the network. Any other server is treated as remote.
+func (c *OllamaClient) Provider() ProviderType {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return ProviderOllamaRemote
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() {
+			return ProviderOllama
+		}
+		return ProviderOllamaRemote
+	}
+	if host == "localhost" || !strings.Contains(host, ".") ||
+		strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") || strings.HasSuffix(host, ".localhost") {
+		return ProviderOllama
+	}
+	return ProviderOllamaRemote
+}
+
 // Health checks if Ollama is available
 func (c *OllamaClient) Health(ctx context.Context) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
@@ -114,49 +182,18 @@ func (c *OllamaClient) Health(ctx context.Context) error {
 	return nil
 }
 
-// buildReviewPrompt creates the structured prompt for code review
-func (c *OllamaClient) buildReviewPrompt(context string) string {
-	return fmt.Sprintf(`You are a code reviewer. Review the following pull request and return ONLY valid, parsable JSON matching exactly the schema below. Do not include any additional text, comments, or code fences.
-
-Schema:
-{
-  "decision": "approve|request_changes|comment",
-  "decision_rationale": "Brief explanation of approval/rejection",
-  "general_comments": [
-    {
-      "body": "Overall feedback about the PR",
-      "severity": "info|warning|error"
-    }
-  ],
-  "file_comments": [
-    {
-      "path": "exact/file/path.ext",
-      "line": <integer, 1-based line number from NEW file version>,
-      "body": "Specific feedback for this line",
-      "severity": "info|warning|error",
-      "type": "bug|style|performance|security|maintainability"
-    }
-  ],
-  "summary": "Brief summary of the review"
-}
-
-Guidelines:
-- Use exact file paths from the PR.
-- Line numbers must match the new file content (after changes).
-- Only include file comments for lines that need feedback.
-- Use "error" severity for bugs or security issues, "warning" for best practices, "info" for suggestions.
-- Escape all quotes and special characters inside JSON strings.
-- Focus on: security vulnerabilities, bugs, performance issues, maintainability
-- Be constructive and specific in feedback
-
-Context:
-%s
-
-Respond ONLY with raw JSON matching the schema above. Do not wrap it in backticks or other formatting.`, context)
+// ParseReview parses a model's response into structured review data. Errors
+// wrap ErrInvalidResponse.
+func ParseReview(response string) (*types.ReviewResponse, error) {
+	review, err := parseReviewResponse(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	return review, nil
 }
 
 // parseReviewResponse parses the LLM response into structured review data
-func (c *OllamaClient) parseReviewResponse(response string) (*types.ReviewResponse, error) {
+func parseReviewResponse(response string) (*types.ReviewResponse, error) {
 	var reviewResp types.ReviewResponse
 
 	// Try to parse directly first
@@ -176,7 +213,7 @@ func (c *OllamaClient) parseReviewResponse(response string) (*types.ReviewRespon
 	}
 
 	// Validate the response
-	if err := c.validateReviewResponse(&reviewResp); err != nil {
+	if err := validateReviewResponse(&reviewResp); err != nil {
 		return nil, fmt.Errorf("invalid review response: %w", err)
 	}
 
@@ -184,7 +221,7 @@ func (c *OllamaClient) parseReviewResponse(response string) (*types.ReviewRespon
 }
 
 // validateReviewResponse ensures the response has valid values
-func (c *OllamaClient) validateReviewResponse(review *types.ReviewResponse) error {
+func validateReviewResponse(review *types.ReviewResponse) error {
 	// Validate decision
 	switch review.Decision {
 	case types.DecisionApprove, types.DecisionRequestChanges, types.DecisionComment:
@@ -195,7 +232,7 @@ func (c *OllamaClient) validateReviewResponse(review *types.ReviewResponse) erro
 
 	// Validate general comments
 	for i, comment := range review.GeneralComments {
-		if err := c.validateSeverity(comment.Severity); err != nil {
+		if err := validateSeverity(comment.Severity); err != nil {
 			return fmt.Errorf("general comment %d: %w", i, err)
 		}
 	}
@@ -208,10 +245,10 @@ func (c *OllamaClient) validateReviewResponse(review *types.ReviewResponse) erro
 		if comment.Line <= 0 {
 			return fmt.Errorf("file comment %d: invalid line number %d", i, comment.Line)
 		}
-		if err := c.validateSeverity(comment.Severity); err != nil {
+		if err := validateSeverity(comment.Severity); err != nil {
 			return fmt.Errorf("file comment %d: %w", i, err)
 		}
-		if err := c.validateCommentType(comment.Type); err != nil {
+		if err := validateCommentType(comment.Type); err != nil {
 			return fmt.Errorf("file comment %d: %w", i, err)
 		}
 	}
@@ -220,7 +257,7 @@ func (c *OllamaClient) validateReviewResponse(review *types.ReviewResponse) erro
 }
 
 // validateSeverity checks if severity is valid
-func (c *OllamaClient) validateSeverity(severity types.Severity) error {
+func validateSeverity(severity types.Severity) error {
 	switch severity {
 	case types.SeverityInfo, types.SeverityWarning, types.SeverityError:
 		return nil
@@ -230,7 +267,7 @@ func (c *OllamaClient) validateSeverity(severity types.Severity) error {
 }
 
 // validateCommentType checks if comment type is valid
-func (c *OllamaClient) validateCommentType(commentType types.CommentType) error {
+func validateCommentType(commentType types.CommentType) error {
 	switch commentType {
 	case types.TypeBug, types.TypeStyle, types.TypePerformance, types.TypeSecurity, types.TypeMaintainability:
 		return nil
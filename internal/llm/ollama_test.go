@@ -0,0 +1,39 @@
+package llm_test
+
+import (
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+)
+
+func TestOllamaProvider(t *testing.T) {
+	tests := []struct {
+		url  string
+		want llm.ProviderType
+	}{
+		{"http://localhost:11434", llm.ProviderOllama},
+		{"http://127.0.0.1:11434", llm.ProviderOllama},
+		{"http://[::1]:11434", llm.ProviderOllama},
+		{"http://10.0.4.12:11434", llm.ProviderOllama},
+		{"http://ollama:11434", llm.ProviderOllama},
+		{"http://gpu.internal:11434", llm.ProviderOllama},
+		{"https://ollama.com", llm.ProviderOllamaRemote},
+		{"http://8.8.8.8:11434", llm.ProviderOllamaRemote},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			client := llm.NewOllamaClient(tt.url, "model")
+			if got := llm.ProviderOf(client); got != tt.want {
+				t.Errorf("got provider %s, want %s", got, tt.want)
+			}
+			recorder, err := llm.NewRecordingClient(client, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := llm.ProviderOf(recorder); got != tt.want {
+				t.Errorf("recording client reports %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// ErrNoRecording is returned by the replay client for prompts it has no
+// recording of
+var ErrNoRecording = errors.New("no recording for prompt")
+
+// Recording is a prompt and what the model returned for it: its raw
+// response when the client exposes one, the parsed review, and the error if
+// the request or parsing failed
+type Recording struct {
+	Model    string                `json:"model"`
+	Prompt   Prompt                `json:"prompt"`
+	Raw      string                `json:"raw,omitempty"`
+	Response *types.ReviewResponse `json:"response,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// PromptHash returns the key recordings of a prompt are stored under
+func PromptHash(prompt Prompt) string {
+	hash := sha256.New()
+	hash.Write([]byte(prompt.System))
+	hash.Write([]byte{0})
+	hash.Write([]byte(prompt.User))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// RecordingClient wraps a client, saving every request to disk, including
+// failed ones
+type RecordingClient struct {
+	next Client
+	dir  string
+}
+
+// NewRecordingClient creates a client that records next's reviews in dir
+func NewRecordingClient(next Client, dir string) (*RecordingClient, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return &RecordingClient{next: next, dir: dir}, nil
+}
+
+// ReviewCode reviews with the wrapped client and records the result. The raw
+// response is recorded when the wrapped client exposes it, so replays parse
+// it again.
+func (c *RecordingClient) ReviewCode(ctx context.Context, prompt Prompt) (*types.ReviewResponse, error) {
+	recording := Recording{Model: c.next.GetModel(), Prompt: prompt}
+
+	var review *types.ReviewResponse
+	var err error
+	if raw, ok := c.next.(RawClient); ok {
+		recording.Raw, err = raw.Complete(ctx, prompt)
+		if err == nil {
+			review, err = ParseReview(recording.Raw)
+		}
+	} else {
+		review, err = c.next.ReviewCode(ctx, prompt)
+	}
+
+	recording.Response = review
+	if err != nil {
+		recording.Error = err.Error()
+	}
+	c.save(prompt, recording)
+
+	return review, err
+}
+
+// save writes a recording through a temporary file, so replays never see a
+// partial one. Failures are logged; they never fail the review.
+func (c *RecordingClient) save(prompt Prompt, recording Recording) {
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		log.Printf("Failed to encode recording: %v", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "recording-*")
+	if err != nil {
+		log.Printf("Failed to write recording: %v", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		log.Printf("Failed to write recording: %v", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		log.Printf("Failed to write recording: %v", err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), recordingPath(c.dir, prompt)); err != nil {
+		log.Printf("Failed to write recording: %v", err)
+	}
+}
+
+// GetModel returns the wrapped client's model
+func (c *RecordingClient) GetModel() string {
+	return c.next.GetModel()
+}
+
+// Health checks the wrapped client
+func (c *RecordingClient) Health(ctx context.Context) error {
+	return c.next.Health(ctx)
+}
+
+// Provider returns the wrapped client's provider
+func (c *RecordingClient) Provider() ProviderType {
+	return ProviderOf(c.next)
+}
+
+// Usage returns the wrapped client's usage, if it tracks any
+func (c *RecordingClient) Usage() Usage {
+	if reporter, ok := c.next.(UsageReporter); ok {
+		return reporter.Usage()
+	}
+	return Usage{}
+}
+
+// ReplayClient serves reviews recorded by a RecordingClient
+type ReplayClient struct {
+	dir string
+}
+
+// NewReplayClient creates a client that replays the recordings in dir
+func NewReplayClient(dir string) *ReplayClient {
+	return &ReplayClient{dir: dir}
+}
+
+// ReviewCode returns the recorded review for the prompt, parsing the raw
+// response again if one was recorded
+func (c *ReplayClient) ReviewCode(ctx context.Context, prompt Prompt) (*types.ReviewResponse, error) {
+	data, err := os.ReadFile(recordingPath(c.dir, prompt))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w %s", ErrNoRecording, PromptHash(prompt))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", PromptHash(prompt), err)
+	}
+	switch {
+	case recording.Raw != "":
+		return ParseReview(recording.Raw)
+	case recording.Error != "":
+		return nil, fmt.Errorf("recorded error: %s", recording.Error)
+	case recording.Response == nil:
+		return nil, fmt.Errorf("invalid recording %s: no response", PromptHash(prompt))
+	}
+	return recording.Response, nil
+}
+
+// GetModel identifies the replay client
+func (c *ReplayClient) GetModel() string {
+	return "replay"
+}
+
+// Provider identifies the replay client
+func (c *ReplayClient) Provider() ProviderType {
+	return ProviderReplay
+}
+
+// Health checks that the recording directory exists
+func (c *ReplayClient) Health(ctx context.Context) error {
+	if _, err := os.Stat(c.dir); err != nil {
+		return fmt.Errorf("recording directory unavailable: %w", err)
+	}
+	return nil
+}
+
+// recordingPath returns the file a prompt's recording is stored in
+func recordingPath(dir string, prompt Prompt) string {
+	return filepath.Join(dir, PromptHash(prompt)+".json")
+}
@@ -0,0 +1,134 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// fakeClient answers every prompt with a fixed response or error
+type fakeClient struct {
+	raw    string
+	review *types.ReviewResponse
+	err    error
+}
+
+func (c *fakeClient) ReviewCode(ctx context.Context, prompt llm.Prompt) (*types.ReviewResponse, error) {
+	return c.review, c.err
+}
+
+func (c *fakeClient) GetModel() string                 { return "fake" }
+func (c *fakeClient) Health(ctx context.Context) error { return nil }
+
+// fakeRawClient also exposes the response before it is parsed
+type fakeRawClient struct {
+	fakeClient
+}
+
+func (c *fakeRawClient) Complete(ctx context.Context, prompt llm.Prompt) (string, error) {
+	return c.raw, c.err
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	requestErr := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		client   llm.Client
+		decision types.ReviewDecision
+		invalid  bool
+		err      string
+	}{
+		{
+			name:     "raw response",
+			client:   &fakeRawClient{fakeClient{raw: `Here you go: {"decision": "comment", "summary": "ok"}`}},
+			decision: types.DecisionComment,
+		},
+		{
+			name:    "invalid raw response",
+			client:  &fakeRawClient{fakeClient{raw: "I cannot review this"}},
+			invalid: true,
+		},
+		{
+			name:   "failed raw request",
+			client: &fakeRawClient{fakeClient{err: requestErr}},
+			err:    requestErr.Error(),
+		},
+		{
+			name:     "parsed response",
+			client:   &fakeClient{review: &types.ReviewResponse{Decision: types.DecisionApprove}},
+			decision: types.DecisionApprove,
+		},
+		{
+			name:   "failed request",
+			client: &fakeClient{err: requestErr},
+			err:    requestErr.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			prompt := llm.Prompt{System: "review", User: tt.name}
+
+			recorder, err := llm.NewRecordingClient(tt.client, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorded, recordErr := recorder.ReviewCode(t.Context(), prompt)
+			replayed, replayErr := llm.NewReplayClient(dir).ReviewCode(t.Context(), prompt)
+
+			for name, result := range map[string]struct {
+				review *types.ReviewResponse
+				err    error
+			}{"recorded": {recorded, recordErr}, "replayed": {replayed, replayErr}} {
+				switch {
+				case tt.invalid:
+					if !errors.Is(result.err, llm.ErrInvalidResponse) {
+						t.Errorf("%s: got error %v, want an invalid response", name, result.err)
+					}
+				case tt.err != "":
+					if result.err == nil || !strings.Contains(result.err.Error(), tt.err) {
+						t.Errorf("%s: got error %v, want %q", name, result.err, tt.err)
+					}
+				case result.err != nil:
+					t.Errorf("%s: %v", name, result.err)
+				case result.review.Decision != tt.decision:
+					t.Errorf("%s: got decision %s, want %s", name, result.review.Decision, tt.decision)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordingWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := llm.NewRecordingClient(&fakeClient{review: &types.ReviewResponse{Decision: types.DecisionApprove}}, dir+"/recordings")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recording into a missing directory is logged without failing the review
+	if err := os.RemoveAll(dir + "/recordings"); err != nil {
+		t.Fatal(err)
+	}
+	review, err := recorder.ReviewCode(t.Context(), llm.Prompt{User: "diff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review.Decision != types.DecisionApprove {
+		t.Errorf("got decision %s", review.Decision)
+	}
+}
+
+func TestReplayMissingRecording(t *testing.T) {
+	_, err := llm.NewReplayClient(t.TempDir()).ReviewCode(t.Context(), llm.Prompt{User: "diff"})
+	if !errors.Is(err, llm.ErrNoRecording) {
+		t.Errorf("got error %v, want %v", err, llm.ErrNoRecording)
+	}
+}
@@ -7,8 +7,26 @@ const (
 	ProviderOllama    ProviderType = "ollama"
 	ProviderOpenAI    ProviderType = "openai"
 	ProviderAnthropic ProviderType = "anthropic"
+
+	// ProviderOllamaRemote is an Ollama server outside our network, such as
+	// a hosted one
+	ProviderOllamaRemote ProviderType = "ollama-remote"
+
+	// ProviderReplay serves recorded reviews. Prompts are redacted as for a
+	// remote provider, so they match recordings of either kind of provider.
+	ProviderReplay ProviderType = "replay"
+
+	// ProviderUnknown is reported for clients that don't say where they
+	// send prompts
+	ProviderUnknown ProviderType = "unknown"
 )
 
+// IsLocal reports whether the provider runs on our own infrastructure, so
+// content sent to it does not leave the network
+func (p ProviderType) IsLocal() bool {
+	return p == ProviderOllama
+}
+
 // Config holds LLM client configuration
 type Config struct {
 	Provider ProviderType
@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"slices"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/repoconfig"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// Rule names reported when a rule changes the decision
+const (
+	RuleDraftCommentOnly        = "draft_comment_only"
+	RuleNeverApprove            = "never_approve"
+	RuleApproveIfClean          = "approve_if_clean"
+	RuleDowngradeRequestChanges = "downgrade_request_changes"
+)
+
+// PullRequest holds the facts about a pull request the rules depend on
+type PullRequest struct {
+	Draft        bool
+	LinesChanged int
+}
+
+// RecordModelDecision starts the review's policy outcome with the decision
+// the model made. It must be called before anything else changes the
+// decision, so the outcome shows what the model actually decided.
+func RecordModelDecision(review *types.ReviewResponse) {
+	review.Policy = &types.PolicyOutcome{ModelDecision: review.Decision}
+}
+
+// Apply gates the review's decision through the repository's policy rules.
+// The outcome is recorded on the review so it can be shown when posted.
+func Apply(cfg repoconfig.Policy, review *types.ReviewResponse, pr PullRequest) {
+	if review.Policy == nil {
+		RecordModelDecision(review)
+	}
+	outcome := review.Policy
+
+	change := func(rule string, decision types.ReviewDecision) {
+		if review.Decision == decision {
+			return
+		}
+		review.Decision = decision
+		outcome.Rules = append(outcome.Rules, rule)
+	}
+
+	if cfg.DraftCommentOnly && pr.Draft {
+		change(RuleDraftCommentOnly, types.DecisionComment)
+	}
+
+	if review.Decision == types.DecisionApprove {
+		switch {
+		case cfg.NeverApprove:
+			change(RuleNeverApprove, types.DecisionComment)
+		case cfg.ApproveIfClean && !isClean(review, pr, cfg.ApproveMaxLines):
+			change(RuleApproveIfClean, types.DecisionComment)
+		}
+	}
+
+	if cfg.DowngradeRequestChanges && review.Decision == types.DecisionRequestChanges && !hasBlockingFinding(review) {
+		change(RuleDowngradeRequestChanges, types.DecisionComment)
+	}
+}
+
+// isClean reports whether a review has no warnings or errors and the pull
+// request is smaller than maxLines, if set
+func isClean(review *types.ReviewResponse, pr PullRequest, maxLines int) bool {
+	if maxLines > 0 && pr.LinesChanged >= maxLines {
+		return false
+	}
+	for _, comment := range review.GeneralComments {
+		if comment.Severity != types.SeverityInfo {
+			return false
+		}
+	}
+	for _, comment := range slices.Concat(review.FileComments, review.OutsideDiff) {
+		if comment.Severity != types.SeverityInfo {
+			return false
+		}
+	}
+	return true
+}
+
+// hasBlockingFinding reports whether a review has an error-severity bug or
+// security comment
+func hasBlockingFinding(review *types.ReviewResponse) bool {
+	for _, comment := range slices.Concat(review.FileComments, review.OutsideDiff) {
+		if comment.Severity.IsError() && (comment.Type == types.TypeBug || comment.Type.IsSecurityIssue()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package policy_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/injection"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/policy"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/repoconfig"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+func TestApply(t *testing.T) {
+	warning := types.FileComment{Path: "main.go", Line: 3, Severity: types.SeverityWarning, Type: types.TypeStyle}
+	bug := types.FileComment{Path: "main.go", Line: 3, Severity: types.SeverityError, Type: types.TypeBug}
+
+	tests := []struct {
+		name     string
+		cfg      repoconfig.Policy
+		pr       policy.PullRequest
+		decision types.ReviewDecision
+		comments []types.FileComment
+		want     types.ReviewDecision
+		rules    []string
+	}{
+		{
+			name:     "no rules",
+			decision: types.DecisionApprove,
+			want:     types.DecisionApprove,
+		},
+		{
+			name:     "draft",
+			cfg:      repoconfig.Policy{DraftCommentOnly: true},
+			pr:       policy.PullRequest{Draft: true},
+			decision: types.DecisionRequestChanges,
+			comments: []types.FileComment{bug},
+			want:     types.DecisionComment,
+			rules:    []string{policy.RuleDraftCommentOnly},
+		},
+		{
+			name:     "never approve",
+			cfg:      repoconfig.Policy{NeverApprove: true, ApproveIfClean: true},
+			decision: types.DecisionApprove,
+			want:     types.DecisionComment,
+			rules:    []string{policy.RuleNeverApprove},
+		},
+		{
+			name:     "approval with warnings",
+			cfg:      repoconfig.Policy{ApproveIfClean: true},
+			decision: types.DecisionApprove,
+			comments: []types.FileComment{warning},
+			want:     types.DecisionComment,
+			rules:    []string{policy.RuleApproveIfClean},
+		},
+		{
+			name:     "approval of a large change",
+			cfg:      repoconfig.Policy{ApproveIfClean: true, ApproveMaxLines: 100},
+			pr:       policy.PullRequest{LinesChanged: 100},
+			decision: types.DecisionApprove,
+			want:     types.DecisionComment,
+			rules:    []string{policy.RuleApproveIfClean},
+		},
+		{
+			name:     "clean approval",
+			cfg:      repoconfig.Policy{ApproveIfClean: true, ApproveMaxLines: 100},
+			pr:       policy.PullRequest{LinesChanged: 99},
+			decision: types.DecisionApprove,
+			want:     types.DecisionApprove,
+		},
+		{
+			name:     "request changes without blocking findings",
+			cfg:      repoconfig.Policy{DowngradeRequestChanges: true},
+			decision: types.DecisionRequestChanges,
+			comments: []types.FileComment{warning},
+			want:     types.DecisionComment,
+			rules:    []string{policy.RuleDowngradeRequestChanges},
+		},
+		{
+			name:     "request changes for a bug",
+			cfg:      repoconfig.Policy{DowngradeRequestChanges: true},
+			decision: types.DecisionRequestChanges,
+			comments: []types.FileComment{bug},
+			want:     types.DecisionRequestChanges,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := &types.ReviewResponse{Decision: tt.decision, FileComments: tt.comments}
+			policy.Apply(tt.cfg, review, tt.pr)
+
+			if review.Decision != tt.want {
+				t.Errorf("got decision %s, want %s", review.Decision, tt.want)
+			}
+			if review.Policy.ModelDecision != tt.decision {
+				t.Errorf("got model decision %s, want %s", review.Policy.ModelDecision, tt.decision)
+			}
+			if !reflect.DeepEqual(review.Policy.Rules, tt.rules) {
+				t.Errorf("got rules %v, want %v", review.Policy.Rules, tt.rules)
+			}
+		})
+	}
+}
+
+func TestApplyAfterInjectionGuard(t *testing.T) {
+	review := &types.ReviewResponse{Decision: types.DecisionApprove}
+	policy.RecordModelDecision(review)
+	injection.Guard(review, []injection.Marker{{Name: "instruction", Text: "ignore previous instructions"}})
+	policy.Apply(repoconfig.Policy{}, review, policy.PullRequest{})
+
+	if review.Decision != types.DecisionComment {
+		t.Errorf("got decision %s, want %s", review.Decision, types.DecisionComment)
+	}
+	if review.Policy.ModelDecision != types.DecisionApprove {
+		t.Errorf("got model decision %s, want %s", review.Policy.ModelDecision, types.DecisionApprove)
+	}
+	if !reflect.DeepEqual(review.Policy.Rules, []string{injection.Rule}) {
+		t.Errorf("got rules %v", review.Policy.Rules)
+	}
+}
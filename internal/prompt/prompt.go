@@ -0,0 +1,184 @@
+package prompt
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/injection"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+)
+
+// baseTemplate is the file holding the review instructions
+const baseTemplate = "review.tmpl"
+
+//go:embed templates
+var embedded embed.FS
+
+// Data is available to prompt templates. Free text written by the PR author,
+// such as the title, is deliberately left out: it belongs in the untrusted
+// user message, not the instructions.
+type Data struct {
+	Repository string
+	Author     string
+	BaseBranch string
+	HeadBranch string
+	Draft      bool
+
+	// Language is the language with the most changed lines, and Languages
+	// every language the pull request touches
+	Language  string
+	Languages []string
+
+	// Focus lists the areas the review should concentrate on
+	Focus []string
+
+	// Accepted and Rejected are earlier findings engineers on the repository
+	// found useful or rejected, as examples of the feedback to give
+	Accepted []Example
+	Rejected []Example
+
+	// InjectionNotice explains how untrusted content is delimited
+	InjectionNotice string
+}
+
+// Example is an earlier finding shown to the model
+type Example struct {
+	Path string
+	Type string
+	Body string
+}
+
+// formatExamples lists earlier findings for the instructions. Findings can
+// quote pull request content, so the list is delimited as untrusted.
+func formatExamples(examples []Example) string {
+	lines := make([]string, 0, len(examples))
+	for _, example := range examples {
+		lines = append(lines, fmt.Sprintf("- %s in %s: %s", example.Type, example.Path, example.Body))
+	}
+	return injection.Delimit(strings.Join(lines, "\n"))
+}
+
+// Template is a parsed review prompt with the version it was built from
+type Template struct {
+	// Name identifies the sources, e.g. "review.tmpl+go.tmpl"
+	Name string
+
+	// Hash is the SHA-256 of the template sources
+	Hash string
+
+	tmpl *template.Template
+}
+
+// Version returns the template name and a short content hash
+func (t *Template) Version() string {
+	return fmt.Sprintf("%s (sha256:%s)", t.Name, t.Hash[:12])
+}
+
+// Render builds a prompt from the instructions and the untrusted context
+func (t *Template) Render(data Data, context string) (llm.Prompt, error) {
+	data.InjectionNotice = injection.Notice
+
+	var system strings.Builder
+	if err := t.tmpl.Execute(&system, data); err != nil {
+		return llm.Prompt{}, fmt.Errorf("failed to render prompt template %s: %w", t.Name, err)
+	}
+
+	return llm.Prompt{
+		System: system.String(),
+		User:   injection.Delimit(context),
+	}, nil
+}
+
+// Loader finds prompt templates in an override directory, falling back to
+// the embedded defaults
+type Loader struct {
+	dir string
+}
+
+// NewLoader creates a loader. Templates in dir, if set, take precedence over
+// the embedded ones.
+func NewLoader(dir string) *Loader {
+	return &Loader{dir: dir}
+}
+
+// Load returns the review template for a language. A repository template,
+// if given, replaces the base instructions; language guidelines still apply.
+func (l *Loader) Load(language, repoName, repoSource string) (*Template, error) {
+	name, source := repoName, repoSource
+	if source == "" {
+		var err error
+		source, err = l.read(baseTemplate)
+		if err != nil {
+			return nil, err
+		}
+		name = baseTemplate
+	}
+
+	names := []string{name}
+	sources := []string{source}
+	if language != "" {
+		file := "languages/" + slug(language) + ".tmpl"
+		languageSource, err := l.read(file)
+		switch {
+		case err == nil:
+			names = append(names, filepath.Base(file))
+			sources = append(sources, languageSource)
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+
+	return parse(strings.Join(names, "+"), sources)
+}
+
+// read returns a template file from the override directory or the embedded
+// defaults
+func (l *Loader) read(name string) (string, error) {
+	if l.dir != "" {
+		data, err := os.ReadFile(filepath.Join(l.dir, filepath.FromSlash(name)))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to read prompt template %s: %w", name, err)
+		}
+	}
+
+	data, err := embedded.ReadFile("templates/" + name)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// parse parses template sources in order, so later ones can redefine blocks
+func parse(name string, sources []string) (*Template, error) {
+	tmpl := template.New(name).Funcs(template.FuncMap{"join": strings.Join, "examples": formatExamples})
+	hash := sha256.New()
+	for _, source := range sources {
+		if _, err := tmpl.Parse(source); err != nil {
+			return nil, fmt.Errorf("failed to parse prompt template %s: %w", name, err)
+		}
+		hash.Write([]byte(source))
+		hash.Write([]byte{0})
+	}
+
+	return &Template{Name: name, Hash: hex.EncodeToString(hash.Sum(nil)), tmpl: tmpl}, nil
+}
+
+// nonSlug matches characters not used in template file names
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug converts a language name into a template file name
+func slug(language string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(language), "-"), "-")
+}
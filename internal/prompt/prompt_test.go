@@ -0,0 +1,157 @@
+package prompt_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/prompt"
+)
+
+// writeTemplates writes prompt templates into a new override directory
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// render loads and renders a template for octo/demo
+func render(t *testing.T, loader *prompt.Loader, language, repoName, repoSource string) (*prompt.Template, string) {
+	t.Helper()
+	tmpl, err := loader.Load(language, repoName, repoSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := tmpl.Render(prompt.Data{Repository: "octo/demo", Language: language}, "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmpl, p.System
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	configDir := writeTemplates(t, map[string]string{
+		"review.tmpl": `Config review of {{.Repository}}.{{block "language" .}}{{end}}`,
+	})
+	repoSource := `Repository review of {{.Repository}}.{{block "language" .}}{{end}}`
+
+	tests := []struct {
+		name       string
+		dir        string
+		repoSource string
+		wantName   string
+		wantPrefix string
+	}{
+		{"built-in", "", "", "review.tmpl+go.tmpl", "You are a code reviewer for octo/demo."},
+		{"config over built-in", configDir, "", "review.tmpl+go.tmpl", "Config review of octo/demo."},
+		{"repository over config", configDir, repoSource, ".github/review.tmpl+go.tmpl", "Repository review of octo/demo."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoName := ""
+			if tt.repoSource != "" {
+				repoName = ".github/review.tmpl"
+			}
+			tmpl, system := render(t, prompt.NewLoader(tt.dir), "Go", repoName, tt.repoSource)
+			if tmpl.Name != tt.wantName {
+				t.Errorf("got template %s, want %s", tmpl.Name, tt.wantName)
+			}
+			if !strings.HasPrefix(system, tt.wantPrefix) {
+				t.Errorf("got prompt starting %.60q, want %q", system, tt.wantPrefix)
+			}
+
+			// Language guidelines apply whichever base instructions are used
+			if !strings.Contains(system, "Go guidelines:") {
+				t.Error("Go guidelines missing from the prompt")
+			}
+		})
+	}
+}
+
+func TestLoadLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		wantName string
+		want     string
+	}{
+		{"Go", "review.tmpl+go.tmpl", "Go guidelines:"},
+		{"Python", "review.tmpl+python.tmpl", "Python guidelines:"},
+		{"PHP", "review.tmpl+php.tmpl", "PHP guidelines:"},
+		{"Shell", "review.tmpl+shell.tmpl", "Shell guidelines:"},
+		{"COBOL", "review.tmpl", ""},
+		{"", "review.tmpl", ""},
+	}
+
+	loader := prompt.NewLoader("")
+	for _, tt := range tests {
+		tmpl, system := render(t, loader, tt.language, "", "")
+		if tmpl.Name != tt.wantName {
+			t.Errorf("%q: got template %s, want %s", tt.language, tmpl.Name, tt.wantName)
+		}
+		if tt.want != "" && !strings.Contains(system, tt.want) {
+			t.Errorf("%q: prompt is missing %q", tt.language, tt.want)
+		}
+		if tt.want == "" && strings.Contains(system, " guidelines:\n") {
+			t.Errorf("%q: prompt has language guidelines", tt.language)
+		}
+	}
+
+	// A language template in the override directory replaces the built-in one
+	dir := writeTemplates(t, map[string]string{
+		"languages/go.tmpl": `{{define "language"}}Local Go rules.{{end}}`,
+	})
+	if _, system := render(t, prompt.NewLoader(dir), "Go", "", ""); !strings.Contains(system, "Local Go rules.") || strings.Contains(system, "Go guidelines:") {
+		t.Errorf("override language template not used: %q", system)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"review.tmpl":       `Review {{.Repository}}.{{block "language" .}}{{end}}`,
+		"languages/go.tmpl": `{{define "language"}} Go.{{end}}`,
+	})
+	loader := prompt.NewLoader(dir)
+	version := func() string {
+		t.Helper()
+		tmpl, err := loader.Load("Go", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tmpl.Version()
+	}
+
+	first := version()
+	if !strings.HasPrefix(first, "review.tmpl+go.tmpl (sha256:") {
+		t.Errorf("unexpected version %s", first)
+	}
+	if again := version(); again != first {
+		t.Errorf("unchanged templates got version %s, then %s", first, again)
+	}
+
+	// Changing either template changes the version
+	seen := map[string]bool{first: true}
+	for name, content := range map[string]string{
+		"review.tmpl":       `Review {{.Repository}} carefully.{{block "language" .}}{{end}}`,
+		"languages/go.tmpl": `{{define "language"}} Go, gofmt'd.{{end}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		v := version()
+		if seen[v] {
+			t.Errorf("version %s unchanged after editing %s", v, name)
+		}
+		seen[v] = true
+	}
+}
@@ -0,0 +1,64 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditLog records what was redacted from each prompt, as JSON lines
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// auditRecord is a single line in the audit log
+type auditRecord struct {
+	Time     time.Time `json:"time"`
+	Repo     string    `json:"repo"`
+	PR       int       `json:"pr"`
+	Provider string    `json:"provider"`
+	Entry
+}
+
+// NewAuditLog creates an audit log appending to path. With an empty path,
+// records are written to the standard logger.
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Record appends the entries of a report to the audit log
+func (a *AuditLog) Record(repo string, pr int, provider string, report *Report) error {
+	if len(report.Entries) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	if a.path == "" {
+		for _, entry := range report.Entries {
+			log.Printf("Redacted from %s#%d for %s: %s %s %s (%d)", repo, pr, provider, entry.Kind, entry.Name, entry.Path, entry.Count)
+		}
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open redaction audit log: %w", err)
+	}
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for _, entry := range report.Entries {
+		record := auditRecord{Time: now, Repo: repo, PR: pr, Provider: provider, Entry: entry}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write redaction audit log: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/repoconfig"
+)
+
+// builtinPatterns are the scrubbers that can be enabled by name
+var builtinPatterns = map[string]string{
+	"email":  `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	"ipv4":   `\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`,
+	"ipv6":   `\b(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}\b`,
+	"jwt":    `\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\b`,
+	"bearer": `(?i)\bbearer\s+[A-Za-z0-9._~+/-]{16,}=*`,
+}
+
+// Kinds of redaction recorded in a Report
+const (
+	KindMaskedPath   = "masked_path"
+	KindConfidential = "confidential_withheld"
+	KindPattern      = "pattern"
+)
+
+// Entry records one kind of redaction applied to a prompt. Entries never
+// contain the redacted values.
+type Entry struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Path  string `json:"path,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Report lists the redactions applied to a prompt
+type Report struct {
+	Entries []Entry
+}
+
+// pattern is a named scrubber
+type pattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Redactor decides which files are withheld from an LLM prompt and scrubs
+// sensitive values from it. A Redactor serves a single review, and records
+// the files it withheld for its report.
+type Redactor struct {
+	maskPaths         []string
+	confidentialPaths []string
+	local             bool
+	patterns          []pattern
+
+	mu       sync.Mutex
+	withheld map[string]string
+	counts   map[string]int
+}
+
+// New creates a redactor from the enabled builtin scrubbers and a
+// repository's redaction configuration. Files marked confidential are
+// withheld unless the provider the prompt is sent to is local.
+func New(builtins []string, cfg repoconfig.Redaction, local bool) (*Redactor, error) {
+	r := &Redactor{
+		maskPaths:         cfg.MaskPaths,
+		confidentialPaths: cfg.ConfidentialPaths,
+		local:             local,
+		withheld:          make(map[string]string),
+		counts:            make(map[string]int),
+	}
+
+	for _, name := range builtins {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		expr, ok := builtinPatterns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction pattern: %s", name)
+		}
+		r.patterns = append(r.patterns, pattern{name: name, re: regexp.MustCompile(expr)})
+	}
+
+	// Sort custom patterns so placeholders are stable across runs
+	names := make([]string, 0, len(cfg.Patterns))
+	for name := range cfg.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		re, err := regexp.Compile(cfg.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", name, err)
+		}
+		r.patterns = append(r.patterns, pattern{name: name, re: re})
+	}
+
+	return r, nil
+}
+
+// Withhold reports whether nothing from a file may be sent, and the kind of
+// redaction that applies. Prompt builders must ask before including anything
+// read from a file. A nil Redactor withholds nothing.
+func (r *Redactor) Withhold(filePath string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	var kind string
+	switch {
+	case repoconfig.MatchPath(r.maskPaths, filePath):
+		kind = KindMaskedPath
+	case !r.local && repoconfig.MatchPath(r.confidentialPaths, filePath):
+		kind = KindConfidential
+	default:
+		return "", false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.withheld[filePath] = kind
+	r.counts[filePath]++
+	return kind, true
+}
+
+// Redact scrubs pattern matches from a prompt and reports them, along with
+// the files withheld while the prompt was built
+func (r *Redactor) Redact(prompt string) (string, *Report) {
+	report := &Report{}
+
+	r.mu.Lock()
+	paths := make([]string, 0, len(r.withheld))
+	for filePath := range r.withheld {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	for _, filePath := range paths {
+		report.Entries = append(report.Entries, Entry{Kind: r.withheld[filePath], Name: "path", Path: filePath, Count: r.counts[filePath]})
+	}
+	r.mu.Unlock()
+
+	prompt = r.scrub(prompt, report)
+	return prompt, report
+}
+
+// scrub replaces pattern matches with placeholders that are stable within
+// the prompt, so repeated values stay recognizable to the model
+func (r *Redactor) scrub(prompt string, report *Report) string {
+	for _, p := range r.patterns {
+		placeholders := make(map[string]string)
+		count := 0
+		prompt = p.re.ReplaceAllStringFunc(prompt, func(value string) string {
+			count++
+			placeholder, ok := placeholders[value]
+			if !ok {
+				placeholder = fmt.Sprintf("<%s_%d>", strings.ToUpper(p.name), len(placeholders)+1)
+				placeholders[value] = placeholder
+			}
+			return placeholder
+		})
+
+		if count > 0 {
+			report.Entries = append(report.Entries, Entry{Kind: KindPattern, Name: p.name, Count: count})
+		}
+	}
+	return prompt
+}
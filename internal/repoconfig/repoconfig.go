@@ -0,0 +1,110 @@
+package repoconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Path is where a repository keeps its reviewer configuration. It is read
+// from the base branch so a pull request cannot change its own review rules.
+const Path = ".github/mountain-hawk.json"
+
+// Config holds per-repository review configuration
+type Config struct {
+	Secrets   Secrets   `json:"secrets"`
+	Redaction Redaction `json:"redaction"`
+	Policy    Policy    `json:"policy"`
+	Prompt    Prompt    `json:"prompt"`
+}
+
+// Secrets configures the secret scanner
+type Secrets struct {
+	// Allowlist holds regular expressions matching values that are known not
+	// to be secrets, such as test fixtures
+	Allowlist []string `json:"allowlist"`
+
+	// IgnorePaths holds path globs that are not scanned
+	IgnorePaths []string `json:"ignore_paths"`
+}
+
+// Redaction configures what is removed from the context sent to the LLM
+type Redaction struct {
+	// MaskPaths holds path globs whose content is never sent
+	MaskPaths []string `json:"mask_paths"`
+
+	// ConfidentialPaths holds path globs whose content is only sent to
+	// providers running on our own infrastructure
+	ConfidentialPaths []string `json:"confidential_paths"`
+
+	// Patterns maps placeholder names to regular expressions whose matches
+	// are replaced, in addition to the server's builtin patterns
+	Patterns map[string]string `json:"patterns"`
+}
+
+// Policy configures the rules that gate the model's review decision
+type Policy struct {
+	// NeverApprove turns approvals into comments
+	NeverApprove bool `json:"never_approve"`
+
+	// ApproveIfClean only allows approvals without warnings or errors
+	ApproveIfClean bool `json:"approve_if_clean"`
+
+	// ApproveMaxLines further limits clean approvals to pull requests
+	// changing fewer lines, if set
+	ApproveMaxLines int `json:"approve_max_lines"`
+
+	// DowngradeRequestChanges turns requested changes into comments unless
+	// there is an error-severity bug or security finding
+	DowngradeRequestChanges bool `json:"downgrade_request_changes"`
+
+	// DraftCommentOnly only comments on draft pull requests
+	DraftCommentOnly bool `json:"draft_comment_only"`
+}
+
+// Prompt configures the review prompt
+type Prompt struct {
+	// Template is the path of a prompt template in the repository, read
+	// from the base branch, that replaces the default instructions
+	Template string `json:"template"`
+
+	// Focus lists the areas the review should concentrate on
+	Focus []string `json:"focus"`
+}
+
+// Default returns the configuration used when a repository has none
+func Default() *Config {
+	return &Config{
+		Policy: Policy{DraftCommentOnly: true},
+	}
+}
+
+// Parse parses a repository configuration file
+func Parse(data []byte) (*Config, error) {
+	cfg := Default()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", Path, err)
+	}
+	return cfg, nil
+}
+
+// MatchPath reports whether a file path matches any of the globs. Globs
+// without a slash also match against the file's base name.
+func MatchPath(globs []string, filePath string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, filePath); ok {
+			return true
+		}
+		if !strings.Contains(glob, "/") {
+			if ok, _ := path.Match(glob, path.Base(filePath)); ok {
+				return true
+			}
+		}
+		// A trailing "/**" matches everything below a directory
+		if dir, ok := strings.CutSuffix(glob, "/**"); ok && strings.HasPrefix(filePath, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// defaultBaseBudget is the number of bytes of base version content included per review
+const defaultBaseBudget = 16000
+
+// removal records public API surface that a pull request deletes
+type removal struct {
+	path    string
+	note    string
+	symbols []string
+}
+
+// mergeBase returns the commit a pull request's files are diffed from. Forges
+// that report the base branch's latest commit are asked for the merge base
+// of it and the head, which differ once the base branch moves on or the head
+// is rebased; if that fails, the base commit is used.
+func (cb *ContextBuilder) mergeBase(ctx context.Context, owner, repo string, pr *github.PullRequest) string {
+	base, head := pr.GetBase().GetSHA(), pr.GetHead().GetSHA()
+	resolver, ok := cb.forge.(forge.MergeBaser)
+	if !ok || base == "" || head == "" {
+		return base
+	}
+
+	sha, err := resolver.MergeBase(ctx, owner, repo, base, head)
+	if err != nil {
+		log.Printf("Failed to find the merge base of %s and %s, reading base versions at %s: %v", base, head, base, err)
+		return base
+	}
+	return sha
+}
+
+// readBaseContent returns the base version of a modified or renamed file, or
+// an empty string if the file has no base version
+func (cb *ContextBuilder) readBaseContent(ctx context.Context, file *github.CommitFile, base Revision) string {
+	var basePath string
+	switch file.GetStatus() {
+	case "modified", "changed":
+		basePath = file.GetFilename()
+	case "renamed":
+		basePath = file.GetPreviousFilename()
+	default:
+		return ""
+	}
+
+	content, err := base.ReadFile(ctx, basePath)
+	if err != nil {
+		log.Printf("Error getting base version of %s: %v", basePath, err)
+		return ""
+	}
+	return content
+}
+
+// writeRename notes where a renamed file came from and how much of it changed
+func (cb *ContextBuilder) writeRename(context *strings.Builder, file *github.CommitFile, baseContent, headContent string) {
+	similarity := 100
+	if file.GetChanges() > 0 {
+		similarity = lineSimilarity(baseContent, headContent)
+	}
+	context.WriteString(fmt.Sprintf("Renamed from: %s (%d%% similar)\n", file.GetPreviousFilename(), similarity))
+}
+
+// writeBaseContent adds the base version of the lines around each hunk,
+// charging what it writes against the review's remaining budget
+func (cb *ContextBuilder) writeBaseContent(context *strings.Builder, file *github.CommitFile, baseContent string, budget *int) {
+	hunks := githubpkg.GetBaseHunkRanges(file)
+	if baseContent == "" || len(hunks) == 0 {
+		return
+	}
+	if *budget <= 0 {
+		context.WriteString("Base version: (omitted - context budget exhausted)\n")
+		return
+	}
+
+	var excerpt strings.Builder
+	writeWindowContext(&excerpt, &SourceFile{
+		Path:    file.GetFilename(),
+		Content: baseContent,
+		Hunks:   hunks,
+	}, windowLines)
+
+	text := excerpt.String()
+	if len(text) > *budget {
+		text = text[:*budget] + "\n... (base version truncated - context budget exhausted)\n"
+	}
+	*budget -= len(text)
+
+	context.WriteString("Base version (before changes):\n")
+	context.WriteString(text)
+}
+
+// removedFile records a file deleted by the pull request along with the
+// public symbols it declared in the base version
+func (cb *ContextBuilder) removedFile(ctx context.Context, file *github.CommitFile, base Revision) removal {
+	filename := file.GetFilename()
+	r := removal{
+		path: filename,
+		note: fmt.Sprintf("file removed, %d lines", file.GetDeletions()),
+	}
+
+	if cb.isBinaryFile(filename) || cb.isGeneratedFile(filename) {
+		return r
+	}
+
+	content, err := base.ReadFile(ctx, filename)
+	if err != nil {
+		log.Printf("Error getting removed file %s: %v", filename, err)
+		return r
+	}
+
+	r.symbols = cb.publicSymbols(filename, content)
+	return r
+}
+
+// removedSymbols returns the public symbols declared in the base version of a
+// file that no longer exist in the head version
+func (cb *ContextBuilder) removedSymbols(filename, baseContent, headContent string) []string {
+	if baseContent == "" {
+		return nil
+	}
+
+	remaining := make(map[string]bool)
+	for _, symbol := range cb.publicSymbols(filename, headContent) {
+		remaining[symbol] = true
+	}
+
+	var removed []string
+	for _, symbol := range cb.publicSymbols(filename, baseContent) {
+		if !remaining[symbol] {
+			removed = append(removed, symbol)
+		}
+	}
+	return removed
+}
+
+// publicSymbols lists a file's public symbols if its language provider supports it
+func (cb *ContextBuilder) publicSymbols(filename, content string) []string {
+	provider, ok := cb.providers[cb.detectLanguage(filename)].(SymbolProvider)
+	if !ok {
+		return nil
+	}
+	return provider.PublicSymbols(filename, content)
+}
+
+// addRemovals summarizes removed files and public symbols so the model can
+// flag breaking deletions
+func (cb *ContextBuilder) addRemovals(context *strings.Builder, removals []removal) {
+	if len(removals) == 0 {
+		return
+	}
+
+	context.WriteString("Removed files and public symbols:\n")
+	for _, r := range removals {
+		context.WriteString(fmt.Sprintf("- %s (%s)", r.path, r.note))
+		if len(r.symbols) > 0 {
+			context.WriteString(": " + strings.Join(r.symbols, ", "))
+		}
+		context.WriteString("\n")
+	}
+	context.WriteString("\n")
+}
+
+// lineSimilarity returns the percentage of lines shared by two versions of a file
+func lineSimilarity(a, b string) int {
+	aLines := strings.Split(a, "\n")
+	bLines := strings.Split(b, "\n")
+
+	counts := make(map[string]int)
+	for _, line := range aLines {
+		counts[line]++
+	}
+
+	common := 0
+	for _, line := range bLines {
+		if counts[line] > 0 {
+			counts[line]--
+			common++
+		}
+	}
+
+	return common * 100 / max(len(aLines), len(bLines))
+}
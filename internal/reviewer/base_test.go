@@ -0,0 +1,112 @@
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+)
+
+// refForge serves files at commits and the merge bases of pairs of commits,
+// and records the files it reads
+type refForge struct {
+	forge.Forge
+	files      map[string]map[string]string
+	mergeBases map[string]string
+	reads      []string
+}
+
+func (f *refForge) ReadFile(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	f.reads = append(f.reads, ref+":"+path)
+	content, ok := f.files[ref][path]
+	if !ok {
+		return "", forge.ErrNotFound
+	}
+	return content, nil
+}
+
+func (f *refForge) ListDirectory(ctx context.Context, owner, repo, dir, ref string) ([]string, error) {
+	return nil, forge.ErrNotFound
+}
+
+func (f *refForge) MergeBase(ctx context.Context, owner, repo, base, head string) (string, error) {
+	mergeBase, ok := f.mergeBases[base+"..."+head]
+	if !ok {
+		return "", errors.New("no common ancestor")
+	}
+	return mergeBase, nil
+}
+
+// withoutMergeBase hides the MergeBase method of a forge
+type withoutMergeBase struct {
+	forge.Forge
+}
+
+func TestBuildContextReadsMergeBase(t *testing.T) {
+	// The pull request changed "two" on the commit it forked from. Main has
+	// changed the same line since, and the head was later rebased onto a
+	// main commit that changed it differently.
+	files := map[string]map[string]string{
+		"fork":    {"notes.txt": "one\ntwo from fork\nthree\n"},
+		"main":    {"notes.txt": "one\ntwo on main\nthree\n"},
+		"rebased": {"notes.txt": "one\ntwo when rebased\nthree\n"},
+		"head":    {"notes.txt": "one\n2\nthree\n"},
+		"pushed":  {"notes.txt": "one\n2\nthree\n"},
+		"orphan":  {"notes.txt": "one\n2\nthree\n"},
+	}
+	mergeBases := map[string]string{
+		"main...head":   "fork",
+		"main...pushed": "rebased",
+	}
+
+	tests := []struct {
+		name     string
+		head     string
+		resolves bool
+		wantBase string
+		wantLine string
+	}{
+		{name: "diverged base", head: "head", resolves: true, wantBase: "fork", wantLine: "two from fork"},
+		{name: "force-pushed head", head: "pushed", resolves: true, wantBase: "rebased", wantLine: "two when rebased"},
+		{name: "no merge base", head: "orphan", resolves: true, wantBase: "main", wantLine: "two on main"},
+		{name: "forge without merge bases", head: "head", wantBase: "main", wantLine: "two on main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &refForge{files: files, mergeBases: mergeBases}
+			var f forge.Forge = fake
+			if !tt.resolves {
+				f = withoutMergeBase{fake}
+			}
+
+			repo := &github.Repository{Name: github.Ptr("demo"), Owner: &github.User{Login: github.Ptr("octo")}}
+			pr := &github.PullRequest{
+				Base: &github.PullRequestBranch{Ref: github.Ptr("main"), SHA: github.Ptr("main")},
+				Head: &github.PullRequestBranch{Ref: github.Ptr("feature"), SHA: github.Ptr(tt.head)},
+			}
+			changed := []*github.CommitFile{{
+				Filename: github.Ptr("notes.txt"),
+				Status:   github.Ptr("modified"),
+				Patch:    github.Ptr("@@ -1,3 +1,3 @@\n one\n-two\n+2\n three"),
+			}}
+
+			context, err := NewContextBuilder(f).BuildContext(t.Context(), repo, pr, changed, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Contains(fake.reads, tt.wantBase+":notes.txt") {
+				t.Errorf("read %v, want the base version at %s", fake.reads, tt.wantBase)
+			}
+			_, baseVersion, _ := strings.Cut(context, "Base version (before changes):")
+			if !strings.Contains(baseVersion, tt.wantLine) {
+				t.Errorf("base version is missing %q:\n%s", tt.wantLine, context)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/redact"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/workspace"
+)
+
+const (
+	// maxIndexedGoFiles caps how many files are searched for call sites
+	maxIndexedGoFiles = 5000
+	// maxCallSitesPerFunc caps the call-site excerpts shown per changed function
+	maxCallSitesPerFunc = 5
+	// maxCallSites caps the call-site excerpts shown per review
+	maxCallSites = 30
+)
+
+// changedFunc is a Go function or method whose lines a pull request touches
+type changedFunc struct {
+	path       string
+	dir        string
+	pkgName    string
+	importPath string
+
+	name string
+	recv string
+
+	params   int
+	variadic bool
+
+	signature    string
+	oldSignature string
+
+	// paramTypes and oldParamTypes list the type of each parameter
+	paramTypes    []string
+	oldParamTypes []string
+}
+
+// label returns the qualified name of the function
+func (f *changedFunc) label() string {
+	if f.recv != "" {
+		return fmt.Sprintf("(%s).%s", f.recv, f.name)
+	}
+	return f.name
+}
+
+// signatureChanged reports whether the function existed in the base with a different signature
+func (f *changedFunc) signatureChanged() bool {
+	return f.oldSignature != "" && f.oldSignature != f.signature
+}
+
+// paramsChanged reports whether the function existed in the base with
+// different parameter types, which callers have to be updated for
+func (f *changedFunc) paramsChanged() bool {
+	return f.oldSignature != "" && !slices.Equal(f.oldParamTypes, f.paramTypes)
+}
+
+// callSite is a call to a changed function
+type callSite struct {
+	fn       *changedFunc
+	path     string
+	line     int
+	text     string
+	caller   string
+	args     int
+	resolved bool
+}
+
+// addCallers finds call sites of the changed Go functions in the snapshot and
+// notes callers outside the PR that were not updated for signature changes
+func (cb *ContextBuilder) addCallers(ctx context.Context, context *strings.Builder, snapshot *workspace.Snapshot, files []*github.CommitFile, base Revision, redactor *redact.Redactor) {
+	modulePath := goModulePath(ctx, snapshot)
+
+	inPR := make(map[string]bool)
+	var funcs []*changedFunc
+	for _, file := range files {
+		inPR[file.GetFilename()] = true
+		if file.GetStatus() == "removed" || cb.detectLanguage(file.GetFilename()) != "Go" {
+			continue
+		}
+		if _, ok := withheldFile(file, redactor); ok {
+			continue
+		}
+
+		head, err := snapshot.ReadFile(ctx, file.GetFilename())
+		if err != nil {
+			continue
+		}
+		funcs = append(funcs, changedFuncs(file, head, cb.readBaseContent(ctx, file, base), modulePath)...)
+	}
+	if len(funcs) == 0 {
+		return
+	}
+
+	paths, err := snapshot.Walk(func(rel string) bool {
+		if !strings.HasSuffix(rel, ".go") || cb.isGeneratedFile(rel) || strings.Contains(rel, "testdata/") {
+			return false
+		}
+		_, withheld := redactor.Withhold(rel)
+		return !withheld
+	})
+	if err != nil {
+		log.Printf("Error walking snapshot: %v", err)
+		return
+	}
+	if len(paths) > maxIndexedGoFiles {
+		log.Printf("Searching the first %d of %d Go files for callers", maxIndexedGoFiles, len(paths))
+		paths = paths[:maxIndexedGoFiles]
+	}
+
+	perFunc := make(map[*changedFunc][]callSite)
+	for _, rel := range paths {
+		content, err := snapshot.ReadFile(ctx, rel)
+		if err != nil {
+			continue
+		}
+		for _, site := range findCallSites(rel, content, funcs) {
+			perFunc[site.fn] = append(perFunc[site.fn], site)
+		}
+	}
+
+	context.WriteString("Callers of changed Go functions:\n")
+	shown := 0
+	var notes []string
+	for _, fn := range funcs {
+		sites := perFunc[fn]
+		if fn.signatureChanged() {
+			context.WriteString(fmt.Sprintf("- %s (%s) signature changed:\n    was: %s\n    now: %s\n", fn.label(), fn.path, fn.oldSignature, fn.signature))
+		} else {
+			context.WriteString(fmt.Sprintf("- %s (%s):\n", fn.label(), fn.path))
+		}
+		if len(sites) == 0 {
+			context.WriteString("    (no callers found)\n")
+			continue
+		}
+
+		for i, site := range sites {
+			if i >= maxCallSitesPerFunc || shown >= maxCallSites {
+				context.WriteString(fmt.Sprintf("    ... and %d more call sites\n", len(sites)-i))
+				break
+			}
+			shown++
+
+			qualifier := ""
+			if !site.resolved {
+				qualifier = " (method name match, receiver not resolved)"
+			}
+			context.WriteString(fmt.Sprintf("    %s:%d in %s%s: %s\n", site.path, site.line, site.caller, qualifier, site.text))
+		}
+
+		if fn.paramsChanged() {
+			notes = append(notes, staleCallerNotes(fn, sites, inPR)...)
+		}
+	}
+
+	if len(notes) > 0 {
+		context.WriteString("Callers outside this PR not updated for signature changes:\n")
+		for _, note := range notes {
+			context.WriteString("- " + note + "\n")
+		}
+	}
+	context.WriteString("\n")
+}
+
+// staleCallerNotes describes resolved callers of fn in files the PR does not touch
+func staleCallerNotes(fn *changedFunc, sites []callSite, inPR map[string]bool) []string {
+	var notes []string
+	for _, site := range sites {
+		if inPR[site.path] || !site.resolved {
+			continue
+		}
+
+		detail := fmt.Sprintf("parameter types changed from (%s) to (%s)", strings.Join(fn.oldParamTypes, ", "), strings.Join(fn.paramTypes, ", "))
+		if !fn.variadic && site.args != fn.params {
+			detail = fmt.Sprintf("passes %d arguments, %s now takes %d", site.args, fn.label(), fn.params)
+		}
+		notes = append(notes, fmt.Sprintf("%s:%d calls %s: %s", site.path, site.line, fn.label(), detail))
+	}
+	return notes
+}
+
+// changedFuncs returns the functions in a Go file whose lines overlap the
+// file's hunks, with their base signatures when the file existed before
+func changedFuncs(file *github.CommitFile, head, base, modulePath string) []*changedFunc {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file.GetFilename(), head, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	baseSignatures := make(map[string]string)
+	baseParamTypes := make(map[string][]string)
+	if base != "" {
+		baseFset := token.NewFileSet()
+		if baseFile, err := parser.ParseFile(baseFset, file.GetFilename(), base, parser.SkipObjectResolution); err == nil {
+			for _, decl := range baseFile.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					baseSignatures[funcKey(fn)] = funcSignature(baseFset, fn)
+					baseParamTypes[funcKey(fn)] = paramTypes(fn)
+				}
+			}
+		}
+	}
+
+	dir := path.Dir(file.GetFilename())
+	importPath := ""
+	if modulePath != "" {
+		importPath = modulePath
+		if dir != "." {
+			importPath = modulePath + "/" + dir
+		}
+	}
+
+	hunks := githubpkg.GetHunkRanges(file)
+	var funcs []*changedFunc
+	for _, decl := range parsed.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name == "main" || fn.Name.Name == "init" {
+			continue
+		}
+
+		declRange := githubpkg.LineRange{Start: fset.Position(fn.Pos()).Line, End: fset.Position(fn.End()).Line}
+		if !overlapsAny(declRange, hunks) {
+			continue
+		}
+
+		changed := &changedFunc{
+			path:          file.GetFilename(),
+			dir:           dir,
+			pkgName:       parsed.Name.Name,
+			importPath:    importPath,
+			name:          fn.Name.Name,
+			signature:     funcSignature(fset, fn),
+			oldSignature:  baseSignatures[funcKey(fn)],
+			paramTypes:    paramTypes(fn),
+			oldParamTypes: baseParamTypes[funcKey(fn)],
+		}
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			changed.recv = receiverTypeName(fn.Recv.List[0].Type)
+		}
+		for _, field := range fn.Type.Params.List {
+			changed.params += max(1, len(field.Names))
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				changed.variadic = true
+			}
+		}
+		funcs = append(funcs, changed)
+	}
+
+	return funcs
+}
+
+// findCallSites returns the calls in a Go file to any of the changed functions
+func findCallSites(filename, content string, funcs []*changedFunc) []callSite {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	aliases := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath := strings.Trim(spec.Path.Value, `"`)
+		alias := path.Base(importPath)
+		if spec.Name != nil {
+			alias = spec.Name.Name
+		}
+		aliases[alias] = importPath
+	}
+
+	dir := path.Dir(filename)
+	lines := strings.Split(content, "\n")
+	var sites []callSite
+
+	for _, decl := range file.Decls {
+		caller, ok := decl.(*ast.FuncDecl)
+		if !ok || caller.Body == nil {
+			continue
+		}
+
+		ast.Inspect(caller.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			for _, fn := range funcs {
+				resolved, matched := matchCall(call, fn, dir, file.Name.Name, aliases)
+				if !matched {
+					continue
+				}
+
+				line := fset.Position(call.Pos()).Line
+				sites = append(sites, callSite{
+					fn:       fn,
+					path:     filename,
+					line:     line,
+					text:     strings.TrimSpace(lines[line-1]),
+					caller:   caller.Name.Name,
+					args:     len(call.Args),
+					resolved: resolved,
+				})
+			}
+			return true
+		})
+	}
+
+	return sites
+}
+
+// matchCall reports whether a call may target fn. resolved is false for
+// method calls, whose receiver type cannot be known without type checking.
+func matchCall(call *ast.CallExpr, fn *changedFunc, dir, pkgName string, aliases map[string]string) (resolved, matched bool) {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		// Unqualified call from the same package
+		return true, fn.recv == "" && fun.Name == fn.name && dir == fn.dir && pkgName == fn.pkgName
+	case *ast.SelectorExpr:
+		if fun.Sel.Name != fn.name {
+			return false, false
+		}
+		if x, ok := fun.X.(*ast.Ident); ok {
+			if importPath, ok := aliases[x.Name]; ok {
+				// Qualified call through an import of the function's package
+				return true, fn.recv == "" && fn.importPath != "" && importPath == fn.importPath
+			}
+		}
+		return false, fn.recv != ""
+	}
+	return false, false
+}
+
+// funcKey identifies a function by receiver and name
+func funcKey(fn *ast.FuncDecl) string {
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		return receiverTypeName(fn.Recv.List[0].Type) + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// paramTypes returns the type of each parameter of a function. Types are
+// formatted from the syntax tree alone, so they compare equal across files
+// regardless of layout and comments.
+func paramTypes(fn *ast.FuncDecl) []string {
+	var params []string
+	for _, field := range fn.Type.Params.List {
+		for range max(1, len(field.Names)) {
+			params = append(params, types.ExprString(field.Type))
+		}
+	}
+	return params
+}
+
+// funcSignature formats a function declaration without its body or doc comment
+func funcSignature(fset *token.FileSet, fn *ast.FuncDecl) string {
+	signature := *fn
+	signature.Body = nil
+	signature.Doc = nil
+	return printNode(fset, &signature, 0)
+}
+
+// goModulePath returns the module path declared in the snapshot's go.mod
+func goModulePath(ctx context.Context, snapshot *workspace.Snapshot) string {
+	goMod, err := snapshot.ReadFile(ctx, "go.mod")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(goMod, "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
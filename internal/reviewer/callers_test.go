@@ -0,0 +1,72 @@
+package reviewer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+)
+
+func TestStaleCallerNotes(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  string
+		head  string
+		notes []string
+	}{
+		{
+			name: "body changed",
+			base: "func Load(path string) error {\n\treturn nil\n}",
+			head: "func Load(path string) error {\n\treturn open(path)\n}",
+		},
+		{
+			name: "parameters renamed and regrouped",
+			base: "func Load(path string, n int) error {\n\treturn nil\n}",
+			head: "func Load(p string, count int) error { // count limits reads\n\treturn nil\n}",
+		},
+		{
+			name: "results changed",
+			base: "func Load(path string) error {\n\treturn nil\n}",
+			head: "func Load(path string) (int, error) {\n\treturn 0, nil\n}",
+		},
+		{
+			name:  "parameter type changed",
+			base:  "func Load(path string) error {\n\treturn nil\n}",
+			head:  "func Load(path []byte) error {\n\treturn nil\n}",
+			notes: []string{"cmd/main.go:7 calls Load: parameter types changed from (string) to ([]byte)"},
+		},
+		{
+			name:  "parameter added",
+			base:  "func Load(path string) error {\n\treturn nil\n}",
+			head:  "func Load(path string, n int) error {\n\treturn nil\n}",
+			notes: []string{"cmd/main.go:7 calls Load: passes 1 arguments, Load now takes 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &github.CommitFile{
+				Filename: github.Ptr("load/load.go"),
+				Patch:    github.Ptr("@@ -3,3 +3,3 @@\n-old\n+new\n context\n context"),
+			}
+			funcs := changedFuncs(file, "package load\n\n"+tt.head+"\n", "package load\n\n"+tt.base+"\n", "example.com/app")
+			if len(funcs) != 1 {
+				t.Fatalf("got %d changed functions", len(funcs))
+			}
+
+			fn := funcs[0]
+			var notes []string
+			if fn.paramsChanged() {
+				sites := []callSite{
+					{fn: fn, path: "cmd/main.go", line: 7, args: 1, resolved: true},
+					{fn: fn, path: "load/load_test.go", line: 9, args: 1, resolved: true},
+					{fn: fn, path: "cmd/other.go", line: 3, args: 1},
+				}
+				notes = staleCallerNotes(fn, sites, map[string]bool{"load/load_test.go": true})
+			}
+			if !reflect.DeepEqual(notes, tt.notes) {
+				t.Errorf("got notes %q, want %q", notes, tt.notes)
+			}
+		})
+	}
+}
@@ -6,32 +6,59 @@ import (
 	"log"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
 	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/redact"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/workspace"
 )
 
 // ContextBuilder builds context for LLM review
 type ContextBuilder struct {
-	githubClient *githubpkg.Client
+	forge      forge.Forge
+	providers  map[string]LanguageContextProvider
+	baseBudget int
 }
 
 // NewContextBuilder creates a new context builder
-func NewContextBuilder(githubClient *githubpkg.Client) *ContextBuilder {
+func NewContextBuilder(f forge.Forge) *ContextBuilder {
 	return &ContextBuilder{
-		githubClient: githubClient,
+		forge:      f,
+		providers:  defaultProviders(),
+		baseBudget: defaultBaseBudget,
 	}
 }
 
-// BuildContext creates a comprehensive context string for LLM review
-func (cb *ContextBuilder) BuildContext(ctx context.Context, repo *github.Repository, pr *github.PullRequest, files []*github.CommitFile) (string, error) {
+// BuildContext creates a comprehensive context string for LLM review. Head
+// files are read from snapshot when one is given, otherwise from the forge.
+// Nothing is read from files the redactor withholds, which may be nil.
+func (cb *ContextBuilder) BuildContext(ctx context.Context, repo *github.Repository, pr *github.PullRequest, files []*github.CommitFile, snapshot *workspace.Snapshot, redactor *redact.Redactor) (string, error) {
+	owner := repo.GetOwner().GetLogin()
+	repoName := repo.GetName()
+
+	var head Revision = newForgeRevision(cb.forge, owner, repoName, pr.GetHead().GetSHA())
+	if snapshot != nil {
+		head = snapshot
+	}
+	base := newForgeRevision(cb.forge, owner, repoName, cb.mergeBase(ctx, owner, repoName, pr))
+
+	return cb.BuildContextAt(ctx, pr, files, head, base, snapshot, redactor)
+}
+
+// BuildContextAt creates the review context reading files from the given head
+// and base revisions. Callers of changed functions are searched in snapshot,
+// if one is given. Nothing is read from files the redactor withholds.
+func (cb *ContextBuilder) BuildContextAt(ctx context.Context, pr *github.PullRequest, files []*github.CommitFile, head, base Revision, snapshot *workspace.Snapshot, redactor *redact.Redactor) (string, error) {
 	var context strings.Builder
+	head, base = withhold(head, redactor), withhold(base, redactor)
 
 	// Add PR metadata
 	cb.addPRMetadata(&context, pr)
 
 	// Add file changes
-	if err := cb.addFileChanges(ctx, &context, repo, pr, files); err != nil {
+	if err := cb.addFileChanges(ctx, &context, files, head, base, snapshot, redactor); err != nil {
 		return "", fmt.Errorf("failed to add file changes: %w", err)
 	}
 
@@ -49,19 +76,34 @@ func (cb *ContextBuilder) addPRMetadata(context *strings.Builder, pr *github.Pul
 }
 
 // addFileChanges adds file content and changes to context
-func (cb *ContextBuilder) addFileChanges(ctx context.Context, context *strings.Builder, repo *github.Repository, pr *github.PullRequest, files []*github.CommitFile) error {
+func (cb *ContextBuilder) addFileChanges(ctx context.Context, context *strings.Builder, files []*github.CommitFile, revision, baseRevision Revision, snapshot *workspace.Snapshot, redactor *redact.Redactor) error {
 	context.WriteString("Files changed:\n\n")
 
-	owner := repo.GetOwner().GetLogin()
-	repoName := repo.GetName()
-	headSHA := pr.GetHead().GetSHA()
+	budget := cb.baseBudget
+	var removals []removal
+
+	cb.prefetch(ctx, files, revision, baseRevision)
+	cache := NewReviewCache()
 
 	for _, file := range files {
 		filename := file.GetFilename()
 		status := file.GetStatus()
 
-		// Skip removed files
+		// Withheld files are named, but neither their diff nor their symbols
+		// are included
+		if kind, ok := withheldFile(file, redactor); ok {
+			if status == "removed" {
+				removals = append(removals, removal{path: filename, note: "file removed, content withheld by redaction policy"})
+				continue
+			}
+			context.WriteString(fmt.Sprintf("=== %s ===\n", filename))
+			context.WriteString(fmt.Sprintf("Status: %s (content withheld by redaction policy: %s)\n\n", status, kind))
+			continue
+		}
+
+		// Removed files are summarized after the changes
 		if status == "removed" {
+			removals = append(removals, cb.removedFile(ctx, file, baseRevision))
 			continue
 		}
 
@@ -73,17 +115,21 @@ func (cb *ContextBuilder) addFileChanges(ctx context.Context, context *strings.B
 		}
 
 		// Get file content
-		content, err := cb.githubClient.GetFileContent(ctx, owner, repoName, filename, headSHA)
+		content, err := revision.ReadFile(ctx, filename)
 		if err != nil {
 			log.Printf("Error getting file %s: %v", filename, err)
 			context.WriteString(fmt.Sprintf("=== %s ===\n", filename))
 			context.WriteString(fmt.Sprintf("Status: %s (error reading file)\n\n", status))
 			continue
 		}
+		baseContent := cb.readBaseContent(ctx, file, baseRevision)
 
 		// Add file information
 		context.WriteString(fmt.Sprintf("=== %s ===\n", filename))
 		context.WriteString(fmt.Sprintf("Status: %s\n", status))
+		if status == "renamed" {
+			cb.writeRename(context, file, baseContent, content)
+		}
 		context.WriteString(fmt.Sprintf("Language: %s\n", cb.detectLanguage(filename)))
 		context.WriteString(fmt.Sprintf("Additions: %d, Deletions: %d\n", file.GetAdditions(), file.GetDeletions()))
 
@@ -94,16 +140,85 @@ func (cb *ContextBuilder) addFileChanges(ctx context.Context, context *strings.B
 			context.WriteString("\n")
 		}
 
-		// Add file content (truncated if too long)
-		context.WriteString("Content:\n")
-		truncatedContent := cb.truncateContent(content, 2000) // Limit content length
-		context.WriteString(truncatedContent)
-		context.WriteString("\n\n")
+		// Add syntactic context around the changes
+		cb.writeSourceContext(ctx, context, &SourceFile{
+			Path:     filename,
+			Content:  content,
+			Hunks:    githubpkg.GetHunkRanges(file),
+			Revision: revision,
+			Cache:    cache,
+		})
+
+		// Add the base version of the changed regions
+		cb.writeBaseContent(context, file, baseContent, &budget)
+		if symbols := cb.removedSymbols(filename, baseContent, content); len(symbols) > 0 {
+			removals = append(removals, removal{path: filename, note: "public symbols removed", symbols: symbols})
+		}
+		context.WriteString("\n")
 	}
 
+	// Cross-reference callers when the whole repository is available locally
+	if snapshot != nil {
+		cb.addCallers(ctx, context, snapshot, files, baseRevision, redactor)
+	}
+
+	cb.addRemovals(context, removals)
+
 	return nil
 }
 
+// withheldFile reports whether the redactor withholds a changed file under
+// its current or previous name, and the kind of redaction that applies
+func withheldFile(file *github.CommitFile, redactor *redact.Redactor) (string, bool) {
+	if kind, ok := redactor.Withhold(file.GetFilename()); ok {
+		return kind, true
+	}
+	if previous := file.GetPreviousFilename(); previous != "" {
+		return redactor.Withhold(previous)
+	}
+	return "", false
+}
+
+// prefetch fetches the head and base versions of the reviewed files concurrently
+func (cb *ContextBuilder) prefetch(ctx context.Context, files []*github.CommitFile, head, base Revision) {
+	var headPaths, basePaths []string
+	for _, file := range files {
+		if file.GetStatus() == "removed" {
+			if !cb.isBinaryFile(file.GetFilename()) && !cb.isGeneratedFile(file.GetFilename()) {
+				basePaths = append(basePaths, file.GetFilename())
+			}
+			continue
+		}
+
+		if cb.shouldSkipFile(file) {
+			continue
+		}
+
+		headPaths = append(headPaths, file.GetFilename())
+		switch file.GetStatus() {
+		case "modified", "changed":
+			basePaths = append(basePaths, file.GetFilename())
+		case "renamed":
+			basePaths = append(basePaths, file.GetPreviousFilename())
+		}
+	}
+
+	var wg sync.WaitGroup
+	for revision, paths := range map[Revision][]string{head: headPaths, base: basePaths} {
+		// Local snapshots have nothing to prefetch
+		p, ok := revision.(prefetcher)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Prefetch(ctx, paths)
+		}()
+	}
+	wg.Wait()
+}
+
 // shouldSkipFile determines if a file should be skipped from review
 func (cb *ContextBuilder) shouldSkipFile(file *github.CommitFile) bool {
 	filename := file.GetFilename()
@@ -171,30 +286,37 @@ func (cb *ContextBuilder) detectLanguage(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
 
 	languageMap := map[string]string{
-		".go":    "Go",
-		".js":    "JavaScript",
-		".ts":    "TypeScript",
-		".py":    "Python",
-		".java":  "Java",
-		".cpp":   "C++",
-		".c":     "C",
-		".cs":    "C#",
-		".rb":    "Ruby",
-		".php":   "PHP",
-		".rs":    "Rust",
-		".kt":    "Kotlin",
-		".swift": "Swift",
-		".scala": "Scala",
-		".sh":    "Shell",
-		".sql":   "SQL",
-		".html":  "HTML",
-		".css":   "CSS",
-		".scss":  "SCSS",
-		".yaml":  "YAML",
-		".yml":   "YAML",
-		".json":  "JSON",
-		".xml":   "XML",
-		".md":    "Markdown",
+		".go":      "Go",
+		".js":      "JavaScript",
+		".mjs":     "JavaScript",
+		".jsx":     "JavaScript",
+		".ts":      "TypeScript",
+		".py":      "Python",
+		".java":    "Java",
+		".cpp":     "C++",
+		".c":       "C",
+		".cs":      "C#",
+		".rb":      "Ruby",
+		".php":     "PHP",
+		".module":  "PHP",
+		".inc":     "PHP",
+		".install": "PHP",
+		".theme":   "PHP",
+		".rs":      "Rust",
+		".kt":      "Kotlin",
+		".swift":   "Swift",
+		".scala":   "Scala",
+		".sh":      "Shell",
+		".bash":    "Shell",
+		".sql":     "SQL",
+		".html":    "HTML",
+		".css":     "CSS",
+		".scss":    "SCSS",
+		".yaml":    "YAML",
+		".yml":     "YAML",
+		".json":    "JSON",
+		".xml":     "XML",
+		".md":      "Markdown",
 	}
 
 	if lang, exists := languageMap[ext]; exists {
@@ -216,13 +338,3 @@ func (cb *ContextBuilder) detectLanguage(filename string) string {
 
 	return "Unknown"
 }
-
-// truncateContent truncates content to a maximum length
-func (cb *ContextBuilder) truncateContent(content string, maxLength int) string {
-	if len(content) <= maxLength {
-		return content
-	}
-
-	truncated := content[:maxLength]
-	return truncated + "\n... (content truncated)"
-}
@@ -0,0 +1,155 @@
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// commentTypes are the types a finding listed in a review body may have
+var commentTypes = []types.CommentType{
+	types.TypeBug,
+	types.TypeStyle,
+	types.TypePerformance,
+	types.TypeSecurity,
+	types.TypeMaintainability,
+}
+
+// fingerprintComments sets the fingerprint of every file comment from its
+// path, type and the code on its line. Lines the patch doesn't show are read
+// from the head revision; comments on blank or unreadable lines use their
+// body instead of the code.
+func fingerprintComments(ctx context.Context, review *types.ReviewResponse, files []*github.CommitFile, head Revision) {
+	patches := make(map[string]*gh.Patch)
+	for _, file := range files {
+		patches[file.GetFilename()] = gh.FilePatch(file)
+	}
+	lines := headLines(ctx, head)
+
+	fingerprint := func(comments []types.FileComment) {
+		for i := range comments {
+			comment := &comments[i]
+			snippet := comment.Body
+			if patch, ok := patches[comment.Path]; ok {
+				if line, ok := patch.NewLine(comment.Line); ok && strings.TrimSpace(line.Text) != "" {
+					comment.Fingerprint = forge.Fingerprint(comment.Path, line.Text, comment.Type)
+					continue
+				}
+			}
+			if content := lines(comment.Path); comment.Line > 0 && comment.Line <= len(content) && strings.TrimSpace(content[comment.Line-1]) != "" {
+				snippet = content[comment.Line-1]
+			}
+			comment.Fingerprint = forge.Fingerprint(comment.Path, snippet, comment.Type)
+		}
+	}
+	fingerprint(review.FileComments)
+	fingerprint(review.OutsideDiff)
+}
+
+// headLines returns a function giving the lines of a file at the head
+// revision, reading each file once. Files that can't be read have no lines.
+func headLines(ctx context.Context, head Revision) func(path string) []string {
+	read := make(map[string][]string)
+	return func(path string) []string {
+		if head == nil {
+			return nil
+		}
+		if lines, ok := read[path]; ok {
+			return lines
+		}
+		content, err := head.ReadFile(ctx, path)
+		if err != nil {
+			if !errors.Is(err, forge.ErrNotFound) && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("Failed to read %s to fingerprint findings: %v", path, err)
+			}
+			content = ""
+		}
+		read[path] = strings.Split(content, "\n")
+		return read[path]
+	}
+}
+
+// listedFindingOpen returns a function reporting whether the code a finding
+// with a path refers to is still in its file at the head
+// revision, which is when some line of the file has the finding's
+// fingerprint
+func listedFindingOpen(lines func(path string) []string) func(finding forge.PostedFinding) bool {
+	fingerprints := make(map[string]map[string]bool)
+	return func(finding forge.PostedFinding) bool {
+		if fingerprints[finding.Path] == nil {
+			fingerprints[finding.Path] = make(map[string]bool)
+			for _, line := range lines(finding.Path) {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				for _, commentType := range commentTypes {
+					fingerprints[finding.Path][forge.Fingerprint(finding.Path, line, commentType)] = true
+				}
+			}
+		}
+		return fingerprints[finding.Path][finding.Fingerprint]
+	}
+}
+
+// skipPostedFindings drops the findings already open on a change request
+// from an earlier review, and marks earlier inline findings whose code has
+// changed as outdated. Findings whose forge doesn't track their code, such
+// as those listed in review bodies, are outdated once their code is gone
+// from the head revision. Reactions on the earlier
+// findings are recorded as feedback. Forges that can't list earlier findings
+// get every finding again.
+func (s *Service) skipPostedFindings(ctx context.Context, owner, repo string, number int, review *types.ReviewResponse, head Revision) {
+	history, ok := s.forge.(forge.FindingHistory)
+	if !ok {
+		return
+	}
+
+	posted, err := history.ListFindings(ctx, owner, repo, number)
+	if err != nil {
+		log.Printf("Failed to list earlier findings on #%d: %v", number, err)
+		return
+	}
+	s.recordReactions(owner, repo, posted)
+
+	listedOpen := listedFindingOpen(headLines(ctx, head))
+	open := make(map[string]bool)
+	for _, finding := range posted {
+		if finding.Path != "" && head != nil {
+			finding.Outdated = !listedOpen(finding)
+		}
+		if !finding.Outdated {
+			open[finding.Fingerprint] = true
+			continue
+		}
+		if finding.CommentID != 0 && !finding.Marked {
+			if err := history.MarkOutdated(ctx, owner, repo, number, finding); err != nil {
+				log.Printf("Failed to mark finding %s outdated: %v", finding.Fingerprint, err)
+			}
+		}
+	}
+
+	before := len(review.FileComments) + len(review.OutsideDiff)
+	review.FileComments = unpostedComments(review.FileComments, open)
+	review.OutsideDiff = unpostedComments(review.OutsideDiff, open)
+	if skipped := before - len(review.FileComments) - len(review.OutsideDiff); skipped > 0 {
+		log.Printf("Skipped %d findings already open on #%d", skipped, number)
+	}
+}
+
+// unpostedComments returns the comments whose fingerprints aren't open
+func unpostedComments(comments []types.FileComment, open map[string]bool) []types.FileComment {
+	var unposted []types.FileComment
+	for _, comment := range comments {
+		if !open[comment.Fingerprint] {
+			unposted = append(unposted, comment)
+		}
+	}
+	return unposted
+}
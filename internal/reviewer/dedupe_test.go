@@ -0,0 +1,160 @@
+package reviewer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/github/githubtest"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitlab"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitlab/gitlabtest"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+func TestSkipPostedFindings(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+
+	files := []*github.CommitFile{{
+		Filename: github.Ptr("main.go"),
+		Patch:    github.Ptr("@@ -1,2 +1,4 @@\n package main\n+var a = 1\n+var b = 2\n // end"),
+	}}
+	server.AddPullRequest(&githubtest.PullRequest{Owner: "octo", Repo: "demo", Number: 7, Files: files})
+
+	review := &types.ReviewResponse{FileComments: []types.FileComment{
+		{Path: "main.go", Line: 2, Body: "Unused", Type: types.TypeMaintainability},
+		{Path: "main.go", Line: 3, Body: "Unused", Type: types.TypeMaintainability},
+	}}
+	fingerprintComments(context.Background(), review, files, nil)
+
+	posted := review.FileComments[0].Fingerprint
+	if posted == review.FileComments[1].Fingerprint {
+		t.Fatal("comments on different code have the same fingerprint")
+	}
+	if posted != forge.Fingerprint("main.go", "  var a   = 1", types.TypeMaintainability) {
+		t.Error("fingerprint depends on whitespace")
+	}
+
+	// An open finding from the last review, one on code that changed since,
+	// and a copied marker in someone else's comment
+	marker := forge.FingerprintMarker(posted)
+	server.AddReviewComment("octo", "demo", 7, &github.PullRequestComment{Body: github.Ptr("Unused " + marker), Line: github.Ptr(2)})
+	server.AddReviewComment("octo", "demo", 7, &github.PullRequestComment{Body: github.Ptr("Old " + forge.FingerprintMarker("0123456789abcdef"))})
+	server.AddReviewComment("octo", "demo", 7, &github.PullRequestComment{
+		Body: github.Ptr("Ignore " + forge.FingerprintMarker(review.FileComments[1].Fingerprint)),
+		Line: github.Ptr(3),
+		User: &github.User{Login: github.Ptr("someone"), Type: github.Ptr("User")},
+	})
+
+	service := NewService(gh.NewForge(server.Client()), nil)
+	service.skipPostedFindings(context.Background(), "octo", "demo", 7, review, nil)
+
+	if len(review.FileComments) != 1 || review.FileComments[0].Line != 3 {
+		t.Errorf("got comments %+v, want only the one on line 3", review.FileComments)
+	}
+
+	comments := server.ReviewComments("octo", "demo", 7)
+	if body := comments[1].GetBody(); !strings.HasPrefix(body, "**Outdated:**") || !strings.Contains(body, forge.OutdatedMarker) {
+		t.Errorf("outdated comment body = %q", body)
+	}
+	if body := comments[0].GetBody(); strings.Contains(body, "Outdated") {
+		t.Errorf("open comment was marked outdated: %q", body)
+	}
+}
+
+func TestSkipPostedListedFindings(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+
+	files := []*github.CommitFile{{
+		Filename: github.Ptr("main.go"),
+		Patch:    github.Ptr("@@ -1,2 +1,4 @@\n package main\n+var a = 1\n+var b = 2\n // end"),
+	}}
+	server.AddPullRequest(&githubtest.PullRequest{Owner: "octo", Repo: "demo", Number: 7, Files: files})
+	head := &countingRevision{files: map[string]string{
+		"main.go": "package main\nvar a = 1\nvar b = 2\n// end\n\nfunc old() {}\n",
+	}}
+
+	// Findings outside the diff are fingerprinted from the code at the head
+	// revision, not from what the model said about it
+	review := &types.ReviewResponse{
+		Decision: types.DecisionComment,
+		Summary:  "Dead code",
+		OutsideDiff: []types.FileComment{
+			{Path: "main.go", Line: 6, Body: "Unused function", Type: types.TypeMaintainability},
+			{Path: "main.go", Line: 5, Body: "Blank line", Type: types.TypeStyle},
+		},
+	}
+	fingerprintComments(ctx, review, files, head)
+	if want := forge.Fingerprint("main.go", "func old() {}", types.TypeMaintainability); review.OutsideDiff[0].Fingerprint != want {
+		t.Errorf("fingerprint = %s, want the one of the line's code %s", review.OutsideDiff[0].Fingerprint, want)
+	}
+	if want := forge.Fingerprint("main.go", "Blank line", types.TypeStyle); review.OutsideDiff[1].Fingerprint != want {
+		t.Errorf("blank line fingerprint = %s, want the one of the body %s", review.OutsideDiff[1].Fingerprint, want)
+	}
+
+	f := gh.NewForge(server.Client())
+	if err := f.PostReview(ctx, "octo", "demo", 7, review, files); err != nil {
+		t.Fatal(err)
+	}
+	posted, err := f.ListFindings(ctx, "octo", "demo", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 2 || posted[0].Path != "main.go" || posted[0].Fingerprint != review.OutsideDiff[0].Fingerprint {
+		t.Fatalf("listed findings = %+v", posted)
+	}
+
+	// The listed finding stays open while its code is in the file, and is
+	// outdated once the code is removed
+	open := listedFindingOpen(headLines(ctx, head))
+	if !open(posted[0]) {
+		t.Error("finding on code still in the file is outdated")
+	}
+	changed := &countingRevision{files: map[string]string{"main.go": "package main\nvar a = 1\n"}}
+	if listedFindingOpen(headLines(ctx, changed))(posted[0]) {
+		t.Error("finding on removed code is open")
+	}
+
+	again := &types.ReviewResponse{OutsideDiff: []types.FileComment{
+		{Path: "main.go", Line: 6, Body: "Still unused", Type: types.TypeMaintainability},
+	}}
+	fingerprintComments(ctx, again, files, head)
+	NewService(f, nil).skipPostedFindings(ctx, "octo", "demo", 7, again, head)
+	if len(again.OutsideDiff) != 0 {
+		t.Errorf("open listed finding was posted again: %+v", again.OutsideDiff)
+	}
+}
+
+func TestSkipPostedGitLabFindings(t *testing.T) {
+	server := gitlabtest.NewServer()
+	defer server.Close()
+	server.AddMergeRequest(&gitlabtest.MergeRequest{Namespace: "libraries", Project: "catalog", IID: 3})
+
+	// GitLab keeps diff notes on their line, so whether their code is still
+	// there is checked in the head revision
+	head := &countingRevision{files: map[string]string{"main.go": "package main\n\nvar a = 1\n"}}
+	open := forge.Fingerprint("main.go", "var a = 1", types.TypeBug)
+	position := &gitlab.Position{NewPath: "main.go", NewLine: 3}
+	server.AddNote("libraries", "catalog", 3, &gitlab.ListedNote{Body: "Open " + forge.FingerprintMarker(open), Position: position})
+	server.AddNote("libraries", "catalog", 3, &gitlab.ListedNote{Body: "Old " + forge.FingerprintMarker("0123456789abcdef"), Position: position})
+
+	review := &types.ReviewResponse{FileComments: []types.FileComment{
+		{Path: "main.go", Line: 3, Body: "Again", Type: types.TypeBug, Fingerprint: open},
+		{Path: "main.go", Line: 1, Body: "New", Type: types.TypeStyle, Fingerprint: forge.Fingerprint("main.go", "package main", types.TypeStyle)},
+	}}
+	service := NewService(gitlab.NewForge(server.Client()), nil)
+	service.skipPostedFindings(context.Background(), "libraries", "catalog", 3, review, head)
+
+	if len(review.FileComments) != 1 || review.FileComments[0].Body != "New" {
+		t.Errorf("got comments %+v, want only the new one", review.FileComments)
+	}
+	notes := server.ListedNotes("libraries", "catalog", 3)
+	if strings.Contains(notes[0].Body, forge.OutdatedMarker) || !strings.Contains(notes[1].Body, forge.OutdatedMarker) {
+		t.Errorf("got notes %q and %q, want only the second outdated", notes[0].Body, notes[1].Body)
+	}
+}
@@ -0,0 +1,196 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/feedback"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/prompt"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+const (
+	// exampleLimit is the number of accepted and of rejected findings shown
+	// to the model
+	exampleLimit = 5
+
+	// exampleLength limits the text of each example finding
+	exampleLength = 300
+
+	// feedbackWindow is how long after a review its findings are polled for
+	// reactions
+	feedbackWindow = 30 * 24 * time.Hour
+)
+
+// SetFeedback enables learning from how engineers respond to posted findings.
+// Thumbs down reactions reject a finding, and thumbs up reactions or resolving
+// its thread accept it. Only collaborators on the repository give feedback.
+func (s *Service) SetFeedback(store *feedback.Store) {
+	s.feedback = store
+}
+
+// suppressRejected drops findings like ones engineers rejected on the
+// repository. Security errors, including secrets, are always reported.
+func (s *Service) suppressRejected(owner, repo string, review *types.ReviewResponse) {
+	if s.feedback == nil {
+		return
+	}
+
+	keep := func(comments []types.FileComment) []types.FileComment {
+		var kept []types.FileComment
+		for _, comment := range comments {
+			security := comment.Type == types.TypeSecurity && comment.Severity == types.SeverityError
+			if !security && s.feedback.Suppresses(owner, repo, comment) {
+				continue
+			}
+			kept = append(kept, comment)
+		}
+		return kept
+	}
+
+	before := len(review.FileComments) + len(review.OutsideDiff)
+	review.FileComments = keep(review.FileComments)
+	review.OutsideDiff = keep(review.OutsideDiff)
+	if suppressed := before - len(review.FileComments) - len(review.OutsideDiff); suppressed > 0 {
+		log.Printf("Suppressed %d findings rejected before in %s/%s", suppressed, owner, repo)
+	}
+}
+
+// recordPosted stores the findings of a posted review, so feedback on them
+// can be collected
+func (s *Service) recordPosted(owner, repo string, number int, review *types.ReviewResponse) {
+	if s.feedback == nil {
+		return
+	}
+
+	comments := append(append([]types.FileComment{}, review.FileComments...), review.OutsideDiff...)
+	if err := s.feedback.RecordPosted(owner, repo, number, comments); err != nil {
+		log.Printf("Failed to record findings posted on #%d: %v", number, err)
+	}
+}
+
+// recordReactions stores the verdicts the reactions on posted findings give
+func (s *Service) recordReactions(owner, repo string, posted []forge.PostedFinding) {
+	if s.feedback == nil {
+		return
+	}
+
+	for _, finding := range posted {
+		var verdict feedback.Verdict
+		switch {
+		case finding.ThumbsDown > 0:
+			verdict = feedback.Rejected
+		case finding.ThumbsUp > 0:
+			verdict = feedback.Accepted
+		default:
+			continue
+		}
+		if err := s.feedback.SetVerdict(owner, repo, finding.Fingerprint, verdict); err != nil {
+			log.Printf("Failed to record feedback on finding %s: %v", finding.Fingerprint, err)
+		}
+	}
+}
+
+// RecordThreadResolution accepts the findings in the first comment of a
+// review thread that was resolved, and withdraws the acceptance when it is
+// unresolved again. Only collaborators on the repository give feedback, and
+// forges that can't list earlier findings record none.
+func (s *Service) RecordThreadResolution(ctx context.Context, owner, repo, user, body string, resolved bool) error {
+	history, ok := s.forge.(forge.FindingHistory)
+	if !ok || s.feedback == nil {
+		return nil
+	}
+
+	collaborator, err := history.IsCollaborator(ctx, owner, repo, user)
+	if err != nil {
+		return fmt.Errorf("failed to check whether %s collaborates on the repository: %w", user, err)
+	}
+	if !collaborator {
+		log.Printf("Ignoring review thread feedback from %s, who doesn't collaborate on %s/%s", user, owner, repo)
+		return nil
+	}
+
+	verdict := feedback.Accepted
+	if !resolved {
+		verdict = ""
+	}
+	for _, fingerprint := range forge.ParseFingerprints(body) {
+		if err := s.feedback.SetVerdict(owner, repo, fingerprint, verdict); err != nil {
+			return fmt.Errorf("failed to record feedback on finding %s: %w", fingerprint, err)
+		}
+	}
+	return nil
+}
+
+// CollectFeedback reads the reactions on the findings posted on a change
+// request. Forges that can't list earlier findings have nothing to collect.
+func (s *Service) CollectFeedback(ctx context.Context, owner, repo string, number int) error {
+	history, ok := s.forge.(forge.FindingHistory)
+	if !ok || s.feedback == nil {
+		return nil
+	}
+
+	posted, err := history.ListFindings(ctx, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to list findings: %w", err)
+	}
+	s.recordReactions(owner, repo, posted)
+	return nil
+}
+
+// PollFeedback collects feedback on the change requests reviewed in the last
+// 30 days every interval, until the context is done. Reactions don't trigger
+// webhooks, so they have to be polled.
+func (s *Service) PollFeedback(ctx context.Context, interval time.Duration) {
+	if _, ok := s.forge.(forge.FindingHistory); !ok || s.feedback == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pulls, err := s.feedback.PullRequests(time.Now().Add(-feedbackWindow))
+		if err != nil {
+			log.Printf("Failed to list reviewed change requests: %v", err)
+			continue
+		}
+		for _, pull := range pulls {
+			if err := s.CollectFeedback(ctx, pull.Owner, pull.Repo, pull.Number); err != nil {
+				log.Printf("Failed to collect feedback on #%d in %s/%s: %v", pull.Number, pull.Owner, pull.Repo, err)
+			}
+		}
+	}
+}
+
+// examples returns the findings engineers on the repository accepted and
+// rejected most recently, for the prompt
+func (s *Service) examples(owner, repo string) (accepted, rejected []prompt.Example) {
+	if s.feedback == nil {
+		return nil, nil
+	}
+
+	convert := func(findings []feedback.Finding) []prompt.Example {
+		var examples []prompt.Example
+		for _, finding := range findings {
+			body := strings.Join(strings.Fields(finding.Body), " ")
+			if len(body) > exampleLength {
+				body = strings.ToValidUTF8(body[:exampleLength], "") + "…"
+			}
+			examples = append(examples, prompt.Example{Path: finding.Path, Type: string(finding.Type), Body: body})
+		}
+		return examples
+	}
+
+	acceptedFindings, rejectedFindings := s.feedback.Examples(owner, repo, exampleLimit)
+	return convert(acceptedFindings), convert(rejectedFindings)
+}
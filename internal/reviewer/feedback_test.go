@@ -0,0 +1,69 @@
+package reviewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/feedback"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/github/githubtest"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+func TestFeedbackFromCollaborators(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	server.AddPullRequest(&githubtest.PullRequest{Owner: "octo", Repo: "demo", Number: 7})
+	server.AddCollaborator("octo", "demo", "maintainer")
+	ctx := context.Background()
+
+	store, err := feedback.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewService(gh.NewForge(server.Client()), nil)
+	service.SetFeedback(store)
+
+	comments := []types.FileComment{
+		{Path: "main.go", Line: 2, Body: "Unused", Type: types.TypeMaintainability, Fingerprint: "0000000000000001"},
+		{Path: "main.go", Line: 3, Body: "Shadowed", Type: types.TypeBug, Fingerprint: "0000000000000002"},
+	}
+	service.recordPosted("octo", "demo", 7, &types.ReviewResponse{FileComments: comments})
+
+	var ids []int64
+	for _, comment := range comments {
+		posted := &github.PullRequestComment{Body: github.Ptr(comment.Body + " " + forge.FingerprintMarker(comment.Fingerprint)), Line: github.Ptr(comment.Line)}
+		server.AddReviewComment("octo", "demo", 7, posted)
+		ids = append(ids, posted.GetID())
+	}
+
+	// The author's thumbs down doesn't count, a maintainer's does
+	server.AddReaction(ids[0], "author", "-1")
+	server.AddReaction(ids[1], "maintainer", "-1")
+	if err := service.CollectFeedback(ctx, "octo", "demo", 7); err != nil {
+		t.Fatal(err)
+	}
+	if store.Suppresses("octo", "demo", comments[0]) {
+		t.Error("finding rejected by the author is suppressed")
+	}
+	if !store.Suppresses("octo", "demo", comments[1]) {
+		t.Error("finding rejected by a collaborator isn't suppressed")
+	}
+
+	// Neither does resolving a thread
+	body := comments[0].Body + " " + forge.FingerprintMarker(comments[0].Fingerprint)
+	if err := service.RecordThreadResolution(ctx, "octo", "demo", "author", body, true); err != nil {
+		t.Fatal(err)
+	}
+	if accepted, _ := store.Examples("octo", "demo", exampleLimit); len(accepted) != 0 {
+		t.Errorf("thread resolved by the author accepted %+v", accepted)
+	}
+	if err := service.RecordThreadResolution(ctx, "octo", "demo", "maintainer", body, true); err != nil {
+		t.Fatal(err)
+	}
+	if accepted, _ := store.Examples("octo", "demo", exampleLimit); len(accepted) != 1 || accepted[0].Fingerprint != comments[0].Fingerprint {
+		t.Errorf("thread resolved by a collaborator accepted %+v", accepted)
+	}
+}
@@ -0,0 +1,377 @@
+package reviewer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+const (
+	// maxDeclLines caps how much of a single enclosing declaration is included
+	maxDeclLines = 200
+	// maxTypeLines caps how much of a referenced type definition is included
+	maxTypeLines = 15
+	// maxReferencedDecls caps the number of referenced signatures per file
+	maxReferencedDecls = 40
+)
+
+// goDecl is a top-level declaration found in a Go package
+type goDecl struct {
+	file string
+	line int
+	text string
+}
+
+// goPackageIndex maps top-level identifiers in a Go package to their declarations
+type goPackageIndex map[string][]goDecl
+
+// goProvider is the LanguageContextProvider for Go, built on go/parser
+type goProvider struct{}
+
+// WriteContext adds the enclosing declarations of each hunk and the signatures
+// of the same-package declarations they reference
+func (g *goProvider) WriteContext(ctx context.Context, context *strings.Builder, src *SourceFile) bool {
+	filename, content, hunks := src.Path, src.Content, src.Hunks
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.ParseComments)
+	if err != nil {
+		log.Printf("Error parsing Go file %s: %v", filename, err)
+		return false
+	}
+
+	lines := strings.Split(content, "\n")
+
+	// Enclosing declarations
+	var enclosing []githubpkg.LineRange
+	context.WriteString("Enclosing declarations:\n")
+	for _, decl := range file.Decls {
+		declRange := g.declRange(fset, decl)
+		if declRange.Start == 0 || !overlapsAny(declRange, hunks) {
+			continue
+		}
+		enclosing = append(enclosing, declRange)
+		writeNumberedLines(context, lines, declRange, maxDeclLines)
+	}
+	if len(enclosing) == 0 {
+		context.WriteString("(none - changes are outside functions and types)\n")
+	}
+
+	var outside []githubpkg.LineRange
+	for _, hunk := range hunks {
+		if !overlapsAny(hunk, enclosing) {
+			outside = append(outside, hunk)
+		}
+	}
+	writeOutsideContext(context, src, outside)
+
+	// Referenced same-package declarations
+	names := g.referencedNames(fset, file, hunks)
+	if len(names) == 0 {
+		return true
+	}
+
+	index := g.packageIndex(ctx, src, file.Name.Name)
+	var referenced []string
+	for _, name := range names {
+		for _, decl := range index[name] {
+			if decl.file == filename && containsLine(enclosing, decl.line) {
+				continue
+			}
+			referenced = append(referenced, fmt.Sprintf("// %s:%d\n%s", decl.file, decl.line, decl.text))
+		}
+	}
+
+	if len(referenced) > 0 {
+		if len(referenced) > maxReferencedDecls {
+			referenced = referenced[:maxReferencedDecls]
+		}
+		context.WriteString("Referenced declarations:\n")
+		for _, text := range referenced {
+			context.WriteString(text)
+			context.WriteString("\n")
+		}
+	}
+
+	return true
+}
+
+// declRange returns the line range of a function or type declaration, or a
+// zero range for other kinds of declarations
+func (g *goProvider) declRange(fset *token.FileSet, decl ast.Decl) githubpkg.LineRange {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		start := d.Pos()
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+		return githubpkg.LineRange{Start: fset.Position(start).Line, End: fset.Position(d.End()).Line}
+	case *ast.GenDecl:
+		if d.Tok != token.TYPE {
+			return githubpkg.LineRange{}
+		}
+		start := d.Pos()
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+		return githubpkg.LineRange{Start: fset.Position(start).Line, End: fset.Position(d.End()).Line}
+	}
+	return githubpkg.LineRange{}
+}
+
+// referencedNames returns the identifiers used within the hunks that may name
+// a same-package function or type. Selected fields and methods are left out,
+// as which type they belong to needs type information, and so are the keys
+// of composite literals and selectors on imported packages.
+func (g *goProvider) referencedNames(fset *token.FileSet, file *ast.File, hunks []githubpkg.LineRange) []string {
+	imports := make(map[string]bool)
+	for _, spec := range file.Imports {
+		name := path.Base(strings.Trim(spec.Path.Value, `"`))
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = true
+	}
+
+	skip := make(map[*ast.Ident]bool)
+	seen := make(map[string]bool)
+	var names []string
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			skip[node.Sel] = true
+			if x, ok := node.X.(*ast.Ident); ok && imports[x.Name] {
+				skip[x] = true
+			}
+		case *ast.CompositeLit:
+			for _, elt := range node.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					if key, ok := kv.Key.(*ast.Ident); ok {
+						skip[key] = true
+					}
+				}
+			}
+		case *ast.Ident:
+			if skip[node] || seen[node.Name] || node.Name == "_" {
+				return true
+			}
+			if containsLine(hunks, fset.Position(node.Pos()).Line) {
+				seen[node.Name] = true
+				names = append(names, node.Name)
+			}
+		}
+		return true
+	})
+
+	return names
+}
+
+// packageIndex returns an index of the top-level declarations in the package
+// that contains the source file. Files in the same package share the index.
+func (g *goProvider) packageIndex(ctx context.Context, src *SourceFile, pkgName string) goPackageIndex {
+	test := strings.HasSuffix(src.Path, "_test.go")
+	key := fmt.Sprintf("go package %s %s test=%t", path.Dir(src.Path), pkgName, test)
+	return src.Cache.Get(key, func() any {
+		return g.buildPackageIndex(ctx, src, pkgName)
+	}).(goPackageIndex)
+}
+
+// buildPackageIndex indexes the package that contains the source file,
+// reading sibling files from its revision
+func (g *goProvider) buildPackageIndex(ctx context.Context, src *SourceFile, pkgName string) goPackageIndex {
+	index := make(goPackageIndex)
+
+	dir := path.Dir(src.Path)
+	if dir == "." {
+		dir = ""
+	}
+
+	siblings, err := src.Revision.ListDirectory(ctx, dir)
+	if err != nil {
+		log.Printf("Error listing package %s: %v", dir, err)
+		siblings = []string{src.Path}
+	}
+	sort.Strings(siblings)
+
+	for _, sibling := range siblings {
+		if !strings.HasSuffix(sibling, ".go") {
+			continue
+		}
+		// Test files only see the package through the file under review
+		if strings.HasSuffix(sibling, "_test.go") && !strings.HasSuffix(src.Path, "_test.go") {
+			continue
+		}
+
+		content := src.Content
+		if sibling != src.Path {
+			content, err = src.Revision.ReadFile(ctx, sibling)
+			if err != nil {
+				log.Printf("Error getting file %s: %v", sibling, err)
+				continue
+			}
+		}
+
+		g.indexFile(index, sibling, content, pkgName)
+	}
+
+	return index
+}
+
+// indexFile adds the top-level functions and types in a file to the index.
+// Methods are only referenced through selectors, so they are left out.
+func (g *goProvider) indexFile(index goPackageIndex, filename, content, pkgName string) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.SkipObjectResolution)
+	if err != nil || file.Name.Name != pkgName {
+		return
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil {
+				continue
+			}
+			index[d.Name.Name] = append(index[d.Name.Name], goDecl{
+				file: filename,
+				line: fset.Position(d.Pos()).Line,
+				text: funcSignature(fset, d),
+			})
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				index[typeSpec.Name.Name] = append(index[typeSpec.Name.Name], goDecl{
+					file: filename,
+					line: fset.Position(typeSpec.Pos()).Line,
+					text: "type " + printNode(fset, typeSpec, maxTypeLines),
+				})
+			}
+		}
+	}
+}
+
+// printNode formats an AST node, keeping at most maxLines lines when maxLines is positive
+func printNode(fset *token.FileSet, node any, maxLines int) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+
+	text := buf.String()
+	if maxLines > 0 {
+		lines := strings.Split(text, "\n")
+		if len(lines) > maxLines {
+			text = strings.Join(lines[:maxLines], "\n") + "\n\t// ... (truncated)"
+		}
+	}
+	return text
+}
+
+// writeNumberedLines writes a range of lines prefixed with their line numbers
+func writeNumberedLines(context *strings.Builder, lines []string, lineRange githubpkg.LineRange, maxLines int) {
+	end := min(lineRange.End, len(lines))
+	truncated := false
+	if maxLines > 0 && end-lineRange.Start+1 > maxLines {
+		end = lineRange.Start + maxLines - 1
+		truncated = true
+	}
+
+	context.WriteString(fmt.Sprintf("--- lines %d-%d ---\n", lineRange.Start, lineRange.End))
+	for i := lineRange.Start; i <= end; i++ {
+		context.WriteString(fmt.Sprintf("%5d | %s\n", i, lines[i-1]))
+	}
+	if truncated {
+		context.WriteString("... (declaration truncated)\n")
+	}
+}
+
+// overlapsAny reports whether r overlaps any of the given ranges
+func overlapsAny(r githubpkg.LineRange, ranges []githubpkg.LineRange) bool {
+	for _, other := range ranges {
+		if r.Start <= other.End && other.Start <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// containsLine reports whether any of the ranges contains the line
+func containsLine(ranges []githubpkg.LineRange, line int) bool {
+	for _, r := range ranges {
+		if r.Contains(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicSymbols returns the exported top-level declarations in a Go file,
+// qualifying methods with their receiver type
+func (g *goProvider) PublicSymbols(filename, content string) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	var symbols []string
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverTypeName(d.Recv.List[0].Type) + "." + name
+			}
+			symbols = append(symbols, name)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						symbols = append(symbols, s.Name.Name)
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							symbols = append(symbols, name.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return symbols
+}
+
+// receiverTypeName returns the base type name of a method receiver
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package reviewer
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/grafana/sobek/ast"
+	"github.com/grafana/sobek/file"
+	"github.com/grafana/sobek/parser"
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// astPackage is the import path of the JavaScript syntax tree types
+var astPackage = reflect.TypeOf(ast.Program{}).PkgPath()
+
+// parseJavaScript outlines a JavaScript file, parsing it as a module and
+// falling back to a script for CommonJS code that isn't valid in strict mode
+func parseJavaScript(content string) (*outline, error) {
+	program, err := parser.ParseFile(nil, "", content, parser.IgnoreRegExpErrors, parser.WithDisableSourceMaps, parser.IsModule)
+	if err != nil {
+		var scriptErr error
+		program, scriptErr = parser.ParseFile(nil, "", content, parser.IgnoreRegExpErrors, parser.WithDisableSourceMaps)
+		if scriptErr != nil {
+			return nil, err
+		}
+	}
+
+	index := newLineIndex(content)
+	// Positions are 1-based offsets, and the end of a node is just past it
+	span := func(from, to file.Idx) githubpkg.LineRange {
+		return githubpkg.LineRange{Start: index.line(int(from) - 1), End: index.line(max(int(from), int(to)-1) - 1)}
+	}
+	// Import and export declarations only know where their keyword ends, so
+	// they end at the quoted module specifier
+	statement := func(from file.Idx, specifier string) githubpkg.LineRange {
+		start := int(from) - 1
+		end := start
+		for _, quote := range []string{`'`, `"`} {
+			if i := strings.Index(content[start:], quote+specifier+quote); i >= 0 && (end == start || start+i < end) {
+				end = start + i
+			}
+		}
+		return githubpkg.LineRange{Start: index.line(start), End: index.line(end)}
+	}
+
+	result := &outline{}
+	for _, entry := range program.ImportEntries {
+		specifier := entry.ModuleSpecifier
+		if entry.FromClause != nil {
+			specifier = entry.FromClause.ModuleSpecifier
+		}
+		result.imports = append(result.imports, statement(entry.Idx, specifier.String()))
+	}
+
+	for _, node := range program.Body {
+		switch node := node.(type) {
+		case *ast.ExportDeclaration:
+			if node.FromClause != nil {
+				result.imports = append(result.imports, statement(node.Idx, node.FromClause.ModuleSpecifier.String()))
+			}
+			result.public = append(result.public, exportedNames(node)...)
+		case *ast.VariableStatement:
+			if requires(node.List) {
+				result.imports = append(result.imports, span(node.Idx0(), node.Idx1()))
+			}
+		case *ast.LexicalDeclaration:
+			if requires(node.List) {
+				result.imports = append(result.imports, span(node.Idx0(), node.Idx1()))
+			}
+		}
+	}
+
+	// Functions also list their variables, so nodes can be reached twice
+	seen := make(map[githubpkg.LineRange]bool)
+	decl := func(block githubpkg.LineRange) {
+		if !seen[block] {
+			seen[block] = true
+			result.decls = append(result.decls, block)
+		}
+	}
+	walkJavaScript(reflect.ValueOf(program.Body), func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.FunctionLiteral, *ast.ClassLiteral:
+			decl(span(node.Idx0(), node.Idx1()))
+		case *ast.MethodDefinition:
+			// The method starts at its key, before the function literal
+			decl(span(node.Idx0(), node.Idx1()))
+		case *ast.Binding:
+			// Arrow functions are declarations only when they are named
+			if arrow, ok := node.Initializer.(*ast.ArrowFunctionLiteral); ok {
+				if _, block := arrow.Body.(*ast.BlockStatement); block {
+					decl(span(node.Idx0(), node.Idx1()))
+				}
+			}
+		}
+	})
+
+	return result, nil
+}
+
+// walkJavaScript calls visit for every syntax tree node reachable from v. The
+// syntax tree has no walker of its own, so it is walked by reflection.
+func walkJavaScript(v reflect.Value, visit func(ast.Node)) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if node, ok := v.Interface().(ast.Node); ok && v.Kind() == reflect.Pointer {
+			visit(node)
+		}
+		walkJavaScript(v.Elem(), visit)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkJavaScript(v.Index(i), visit)
+		}
+	case reflect.Struct:
+		if v.Type().PkgPath() != astPackage {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				walkJavaScript(v.Field(i), visit)
+			}
+		}
+	}
+}
+
+// requires reports whether a declaration binds the result of a require call
+func requires(bindings []*ast.Binding) bool {
+	for _, binding := range bindings {
+		expression := binding.Initializer
+		// require("x").y binds a member of the module
+		for {
+			if dot, ok := expression.(*ast.DotExpression); ok {
+				expression = dot.Left
+				continue
+			}
+			break
+		}
+		call, ok := expression.(*ast.CallExpression)
+		if !ok {
+			continue
+		}
+		if callee, ok := call.Callee.(*ast.Identifier); ok && callee.Name == "require" {
+			return true
+		}
+	}
+	return false
+}
+
+// exportedNames returns the names an export declaration makes public
+func exportedNames(export *ast.ExportDeclaration) []string {
+	var names []string
+	bindings := func(list []*ast.Binding) {
+		for _, binding := range list {
+			if name, ok := binding.Target.(*ast.Identifier); ok {
+				names = append(names, name.Name.String())
+			}
+		}
+	}
+
+	switch {
+	case export.HoistableDeclaration != nil && export.HoistableDeclaration.FunctionDeclaration != nil:
+		if name := export.HoistableDeclaration.FunctionDeclaration.Function.Name; name != nil {
+			names = append(names, name.Name.String())
+		}
+	case export.ClassDeclaration != nil:
+		if name := export.ClassDeclaration.Class.Name; name != nil {
+			names = append(names, name.Name.String())
+		}
+	case export.LexicalDeclaration != nil:
+		bindings(export.LexicalDeclaration.List)
+	case export.Variable != nil:
+		bindings(export.Variable.List)
+	case export.NamedExports != nil && export.FromClause == nil:
+		for _, specifier := range export.NamedExports.ExportsList {
+			name := specifier.Alias
+			if name == "" {
+				name = specifier.IdentifierName
+			}
+			names = append(names, name.String())
+		}
+	}
+	return names
+}
@@ -0,0 +1,145 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// windowLines is the number of lines shown around each hunk for languages
+// without a context provider
+const windowLines = 10
+
+// Revision reads files from the repository at the revision under review
+type Revision interface {
+	// ReadFile returns the content of a file
+	ReadFile(ctx context.Context, path string) (string, error)
+
+	// ListDirectory returns the paths of the files in a directory ("" is the root)
+	ListDirectory(ctx context.Context, dir string) ([]string, error)
+}
+
+// SourceFile is a changed file handed to a LanguageContextProvider
+type SourceFile struct {
+	Path     string
+	Content  string
+	Hunks    []githubpkg.LineRange
+	Revision Revision
+
+	// Cache is shared by the files of a review; it may be nil
+	Cache *ReviewCache
+}
+
+// ReviewCache holds what providers derive from a revision, such as package
+// indexes, so the files of a review can share it
+type ReviewCache struct {
+	mu     sync.Mutex
+	values map[string]any
+}
+
+// NewReviewCache creates an empty cache for a single review
+func NewReviewCache() *ReviewCache {
+	return &ReviewCache{values: make(map[string]any)}
+}
+
+// Get returns the value cached under key, building it on first use. A nil
+// cache builds the value every time.
+func (c *ReviewCache) Get(key string, build func() any) any {
+	if c == nil {
+		return build()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value, ok := c.values[key]; ok {
+		return value
+	}
+	value := build()
+	c.values[key] = value
+	return value
+}
+
+// LanguageContextProvider extracts syntactic context for the changed hunks of a file
+type LanguageContextProvider interface {
+	// WriteContext adds the imports and enclosing declarations of each hunk.
+	// It returns false if the file could not be parsed, in which case nothing
+	// is written and the caller falls back to a line window.
+	WriteContext(ctx context.Context, context *strings.Builder, file *SourceFile) bool
+}
+
+// SymbolProvider is implemented by providers that can list the public symbols
+// a file exposes, so removed API surface can be reported
+type SymbolProvider interface {
+	// PublicSymbols returns the names of the file's public declarations
+	PublicSymbols(filename, content string) []string
+}
+
+// defaultProviders returns the built-in providers keyed by detectLanguage names
+func defaultProviders() map[string]LanguageContextProvider {
+	// TypeScript files without type annotations parse as JavaScript; others
+	// fall back to the window of lines around each hunk
+	ecmascript := &outlineProvider{language: "JavaScript", parse: parseJavaScript}
+
+	return map[string]LanguageContextProvider{
+		"Go":         &goProvider{},
+		"PHP":        &outlineProvider{language: "PHP", parse: parsePHP},
+		"JavaScript": ecmascript,
+		"TypeScript": ecmascript,
+		"Python":     &outlineProvider{language: "Python", parse: parsePython},
+		"Shell":      &outlineProvider{language: "Shell", parse: parseShell},
+	}
+}
+
+// RegisterProvider sets the context provider for a language, replacing any existing one
+func (cb *ContextBuilder) RegisterProvider(language string, provider LanguageContextProvider) {
+	cb.providers[language] = provider
+}
+
+// writeSourceContext adds syntactic context for a file, falling back to a
+// window of lines around each hunk for unsupported languages
+func (cb *ContextBuilder) writeSourceContext(ctx context.Context, context *strings.Builder, file *SourceFile) {
+	if provider, ok := cb.providers[cb.detectLanguage(file.Path)]; ok {
+		if provider.WriteContext(ctx, context, file) {
+			return
+		}
+	}
+
+	writeWindowContext(context, file, windowLines)
+}
+
+// writeWindowContext writes ±n lines around each hunk, merging overlapping windows
+func writeWindowContext(context *strings.Builder, file *SourceFile, n int) {
+	lines := strings.Split(file.Content, "\n")
+
+	var windows []githubpkg.LineRange
+	for _, hunk := range file.Hunks {
+		window := githubpkg.LineRange{Start: max(1, hunk.Start-n), End: min(len(lines), hunk.End+n)}
+		if len(windows) > 0 && window.Start <= windows[len(windows)-1].End+1 {
+			windows[len(windows)-1].End = max(windows[len(windows)-1].End, window.End)
+			continue
+		}
+		windows = append(windows, window)
+	}
+
+	context.WriteString(fmt.Sprintf("Context (±%d lines around changes):\n", n))
+	if len(windows) == 0 {
+		context.WriteString("(no changed lines)\n")
+	}
+	for _, window := range windows {
+		writeNumberedLines(context, lines, window, 0)
+	}
+}
+
+// writeOutsideContext writes a line window around hunks that fall outside any declaration
+func writeOutsideContext(context *strings.Builder, file *SourceFile, hunks []githubpkg.LineRange) {
+	if len(hunks) == 0 {
+		return
+	}
+
+	outside := *file
+	outside.Hunks = hunks
+	writeWindowContext(context, &outside, windowLines)
+}
@@ -0,0 +1,390 @@
+package reviewer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+func TestParseOutline(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(string) (*outline, error)
+		source  string
+		decls   []gh.LineRange
+		imports []gh.LineRange
+		public  []string
+	}{
+		{
+			name:  "PHP",
+			parse: parsePHP,
+			source: `<?php
+namespace App;
+use Foo\Bar;
+/* function fake() { */
+# class Fake {
+class Widget {
+    private $s = "}";
+    public function render() {
+        $html = <<<HTML
+        <div>{$this->name}</div> }
+        HTML;
+        return $html;
+    }
+    private static function helper() { return '{'; }
+}
+?>
+<p>{</p>
+<?php function tail() {}
+`,
+			decls:   []gh.LineRange{{Start: 6, End: 15}, {Start: 8, End: 13}, {Start: 14, End: 14}, {Start: 18, End: 18}},
+			imports: []gh.LineRange{{Start: 2, End: 2}, {Start: 3, End: 3}},
+			public:  []string{"Widget", "render", "tail"},
+		},
+		{
+			name:  "PHP closures and member names",
+			parse: parsePHP,
+			source: `<?php
+$f = function ($x) use ($y) {
+    return $x->class;
+};
+$o = new class {
+    function run() {}
+};
+`,
+			decls:  []gh.LineRange{{Start: 6, End: 6}},
+			public: []string{"run"},
+		},
+		{
+			name:  "Python",
+			parse: parsePython,
+			source: `import os
+from typing import (
+    List,
+)
+
+@decorator
+def handler(x):
+    doc = """
+def fake():
+"""
+    return f"{x['a']}"
+
+# def comment():
+class _Private:
+    async def run(self): pass
+`,
+			decls:   []gh.LineRange{{Start: 6, End: 11}, {Start: 14, End: 15}, {Start: 15, End: 15}},
+			imports: []gh.LineRange{{Start: 1, End: 1}, {Start: 2, End: 4}},
+			public:  []string{"handler"},
+		},
+		{
+			name:  "PHP nowdoc",
+			parse: parsePHP,
+			source: `<?php
+function render() {
+    $s = <<<'EOT'
+    } function fake() { $x
+    EOT;
+    $t = <<<"EOT"
+    {$s} }
+    EOT;
+    return $s . $t;
+}
+`,
+			decls:  []gh.LineRange{{Start: 2, End: 10}},
+			public: []string{"render"},
+		},
+		{
+			name:  "Python strings and continuations",
+			parse: parsePython,
+			source: "def total(a):\n" +
+				"    n = a + \\\n" +
+				"1\n" +
+				"    s = '''\n" +
+				"def fake():\n" +
+				"'''\n" +
+				"    return n\n" +
+				"x = \"\"\"\n" +
+				"class Fake:\n" +
+				"\"\"\"\n",
+			decls:  []gh.LineRange{{Start: 1, End: 7}},
+			public: []string{"total"},
+		},
+		{
+			name:  "Python tabs",
+			parse: parsePython,
+			source: "class Mixed:\n" +
+				"        def a(self):\n" +
+				"\t    return 1\n" +
+				"\tdef b(self): pass\n",
+			decls:  []gh.LineRange{{Start: 1, End: 4}, {Start: 2, End: 3}, {Start: 4, End: 4}},
+			public: []string{"Mixed"},
+		},
+		{
+			name:  "JavaScript module",
+			parse: parseJavaScript,
+			source: "import { a } from './a.js';\n" +
+				"import {\n" +
+				"  b,\n" +
+				"} from \"./b.js\";\n" +
+				"/* function fake() { */\n" +
+				"const tpl = `\n" +
+				"}\n" +
+				"`;\n" +
+				"export function render(x) {\n" +
+				"  return `${x.map(y => { return y; })}`;\n" +
+				"}\n" +
+				"export class Widget {\n" +
+				"  draw() {\n" +
+				"    return '}';\n" +
+				"  }\n" +
+				"}\n" +
+				"export const handler = async () => {\n" +
+				"};\n" +
+				"export { tpl as template };\n",
+			decls:   []gh.LineRange{{Start: 9, End: 11}, {Start: 12, End: 16}, {Start: 13, End: 15}, {Start: 17, End: 18}},
+			imports: []gh.LineRange{{Start: 1, End: 1}, {Start: 2, End: 4}},
+			public:  []string{"render", "Widget", "handler", "template"},
+		},
+		{
+			name:  "JavaScript CommonJS",
+			parse: parseJavaScript,
+			source: `const fs = require('fs');
+var join = require('path').join;
+with (fs) {
+  function read() {
+    return readFileSync;
+  }
+}
+`,
+			decls:   []gh.LineRange{{Start: 4, End: 6}},
+			imports: []gh.LineRange{{Start: 1, End: 1}, {Start: 2, End: 2}},
+		},
+		{
+			name:  "Shell",
+			parse: parseShell,
+			source: `#!/bin/bash
+source ./lib.sh
+. "$DIR/env.sh"
+# fake() {
+deploy() {
+  cat <<EOF
+}
+EOF
+  echo "}"
+}
+function cleanup {
+  rm -rf "$tmp"
+}
+`,
+			decls:   []gh.LineRange{{Start: 5, End: 10}, {Start: 11, End: 13}},
+			imports: []gh.LineRange{{Start: 2, End: 2}, {Start: 3, End: 3}},
+			public:  []string{"deploy", "cleanup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.decls, tt.decls) {
+				t.Errorf("got declarations %v, want %v", got.decls, tt.decls)
+			}
+			if !reflect.DeepEqual(got.imports, tt.imports) {
+				t.Errorf("got imports %v, want %v", got.imports, tt.imports)
+			}
+			if !reflect.DeepEqual(got.public, tt.public) {
+				t.Errorf("got public symbols %v, want %v", got.public, tt.public)
+			}
+		})
+	}
+}
+
+func TestParseOutlineErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parse  func(string) (*outline, error)
+		source string
+	}{
+		{"PHP unterminated comment", parsePHP, "<?php\n/* function f() {}\n"},
+		{"PHP unbalanced braces", parsePHP, "<?php\nfunction f() {\n"},
+		{"PHP unterminated heredoc", parsePHP, "<?php\n$s = <<<EOT\ntext\n"},
+		{"Python unterminated string", parsePython, "def f():\n    return 'x\n"},
+		{"Python unterminated f-string", parsePython, "s = f\"{x\n"},
+		{"JavaScript syntax error", parseJavaScript, "function f( {\n"},
+		{"Shell syntax error", parseShell, "f() {\n  echo\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.parse(tt.source); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// countingRevision is a Revision that counts directory listings
+type countingRevision struct {
+	files    map[string]string
+	listings int
+}
+
+func (r *countingRevision) ReadFile(ctx context.Context, path string) (string, error) {
+	return r.files[path], nil
+}
+
+func (r *countingRevision) ListDirectory(ctx context.Context, dir string) ([]string, error) {
+	r.listings++
+	var paths []string
+	for path := range r.files {
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func TestGoPackageIndexCache(t *testing.T) {
+	revision := &countingRevision{files: map[string]string{
+		"a.go":      "package app\n\nfunc A() { B() }\n",
+		"b.go":      "package app\n\nfunc B() { A() }\n",
+		"a_test.go": "package app\n\nfunc TestA() { A() }\n",
+	}}
+
+	cache := NewReviewCache()
+	provider := &goProvider{}
+	for _, path := range []string{"a.go", "b.go", "a_test.go"} {
+		var context strings.Builder
+		file := &SourceFile{
+			Path:     path,
+			Content:  revision.files[path],
+			Hunks:    []gh.LineRange{{Start: 3, End: 3}},
+			Revision: revision,
+			Cache:    cache,
+		}
+		if !provider.WriteContext(t.Context(), &context, file) {
+			t.Fatalf("failed to write context for %s", path)
+		}
+	}
+
+	// Test files see a different package index than the files they test
+	if revision.listings != 2 {
+		t.Errorf("listed the package %d times, want 2", revision.listings)
+	}
+}
+
+func TestGoContextForHunk(t *testing.T) {
+	revision := &countingRevision{files: map[string]string{
+		"conn.go": "package app\n" +
+			"\n" +
+			"// Conn is a connection\n" +
+			"type Conn struct{ name string }\n" +
+			"\n" +
+			"// Close closes the connection\n" +
+			"func (c *Conn) Close() error { return nil }\n" +
+			"\n" +
+			"// Open opens a connection\n" +
+			"func Open(name string) *Conn { return &Conn{name: name} }\n",
+		"file.go": "package app\n" +
+			"\n" +
+			"import \"os\"\n" +
+			"\n" +
+			"// File is a file\n" +
+			"type File struct{ name string }\n" +
+			"\n" +
+			"func (f *File) Close() error { return nil }\n" +
+			"\n" +
+			"func (f *File) String() string { return f.name }\n" +
+			"\n" +
+			"func use() {\n" +
+			"\tc := Open(\"db\")\n" +
+			"\tdefer c.Close()\n" +
+			"\tos.Exit(len(File{name: c.name}.String()))\n" +
+			"}\n",
+	}}
+
+	var context strings.Builder
+	file := &SourceFile{
+		Path:     "file.go",
+		Content:  revision.files["file.go"],
+		Hunks:    []gh.LineRange{{Start: 13, End: 15}},
+		Revision: revision,
+		Cache:    NewReviewCache(),
+	}
+	if !(&goProvider{}).WriteContext(t.Context(), &context, file) {
+		t.Fatal("failed to write context")
+	}
+
+	// Close, String and the name field are selected, so neither method is
+	// listed whichever type they're called on
+	want := "Enclosing declarations:\n" +
+		"--- lines 12-16 ---\n" +
+		"   12 | func use() {\n" +
+		"   13 | \tc := Open(\"db\")\n" +
+		"   14 | \tdefer c.Close()\n" +
+		"   15 | \tos.Exit(len(File{name: c.name}.String()))\n" +
+		"   16 | }\n" +
+		"Referenced declarations:\n" +
+		"// conn.go:10\n" +
+		"func Open(name string) *Conn\n" +
+		"// file.go:6\n" +
+		"type File struct{ name string }\n"
+	if context.String() != want {
+		t.Errorf("got context:\n%s\nwant:\n%s", context.String(), want)
+	}
+}
+
+func TestEnclosingBlocks(t *testing.T) {
+	// A class with two methods, the second holding a closure, and a function
+	blocks := []gh.LineRange{{Start: 1, End: 20}, {Start: 2, End: 6}, {Start: 8, End: 19}, {Start: 10, End: 12}, {Start: 22, End: 25}}
+
+	tests := []struct {
+		name      string
+		hunk      gh.LineRange
+		inner     []gh.LineRange
+		uncovered []gh.LineRange
+	}{
+		{"inside a method", gh.LineRange{Start: 3, End: 4}, []gh.LineRange{{Start: 2, End: 6}}, nil},
+		{"sibling methods", gh.LineRange{Start: 5, End: 9}, []gh.LineRange{{Start: 2, End: 6}, {Start: 8, End: 19}}, []gh.LineRange{{Start: 7, End: 7}}},
+		{"method and its closure", gh.LineRange{Start: 9, End: 14}, []gh.LineRange{{Start: 10, End: 12}}, []gh.LineRange{{Start: 9, End: 9}, {Start: 13, End: 14}}},
+		{"class and function", gh.LineRange{Start: 20, End: 22}, []gh.LineRange{{Start: 1, End: 20}, {Start: 22, End: 25}}, []gh.LineRange{{Start: 21, End: 21}}},
+		{"outside every block", gh.LineRange{Start: 21, End: 21}, nil, []gh.LineRange{{Start: 21, End: 21}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, uncovered := enclosingBlocks(blocks, tt.hunk)
+			var inner []gh.LineRange
+			for _, block := range found {
+				inner = append(inner, block.inner)
+			}
+			if !reflect.DeepEqual(inner, tt.inner) {
+				t.Errorf("got blocks %v, want %v", inner, tt.inner)
+			}
+			if !reflect.DeepEqual(uncovered, tt.uncovered) {
+				t.Errorf("got uncovered lines %v, want %v", uncovered, tt.uncovered)
+			}
+		})
+	}
+}
+
+func TestOutlineWritesSiblingDeclarations(t *testing.T) {
+	source := "def first():\n    return 1\n\ndef second():\n    return 2\n"
+	file := &SourceFile{Path: "app.py", Content: source, Hunks: []gh.LineRange{{Start: 2, End: 5}}}
+
+	var context strings.Builder
+	provider := &outlineProvider{language: "Python", parse: parsePython}
+	if !provider.WriteContext(t.Context(), &context, file) {
+		t.Fatal("context not written")
+	}
+	for _, want := range []string{"    1 | def first():", "    4 | def second():", "    3 | "} {
+		if !strings.Contains(context.String(), want) {
+			t.Errorf("context does not contain %q:\n%s", want, context.String())
+		}
+	}
+}
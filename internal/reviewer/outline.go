@@ -0,0 +1,198 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// maxImportLines caps the size of the imports block
+const maxImportLines = 50
+
+// outline is the structure of a source file found by a language parser
+type outline struct {
+	// decls are the functions and classes that have a body
+	decls []githubpkg.LineRange
+
+	// imports are the import statements
+	imports []githubpkg.LineRange
+
+	// public are the names of the file's public declarations
+	public []string
+}
+
+// outlineProvider is a LanguageContextProvider for a language whose files are
+// outlined by a parser
+type outlineProvider struct {
+	language string
+	parse    func(content string) (*outline, error)
+}
+
+// WriteContext adds the imports block and the innermost enclosing
+// declarations of each hunk. Changed lines outside them get a line window.
+func (p *outlineProvider) WriteContext(ctx context.Context, context *strings.Builder, file *SourceFile) bool {
+	outline, err := p.parse(file.Content)
+	if err != nil {
+		log.Printf("Error parsing %s file %s: %v", p.language, file.Path, err)
+		return false
+	}
+
+	lines := strings.Split(file.Content, "\n")
+	writeImports(context, lines, outline.imports)
+
+	context.WriteString("Enclosing declarations:\n")
+	written := make(map[githubpkg.LineRange]bool)
+	var outside []githubpkg.LineRange
+	for _, hunk := range file.Hunks {
+		enclosing, uncovered := enclosingBlocks(outline.decls, hunk)
+		outside = append(outside, uncovered...)
+		for _, block := range enclosing {
+			if written[block.inner] {
+				continue
+			}
+			written[block.inner] = true
+
+			// Show the class or outer function the declaration belongs to
+			if block.outer != nil {
+				writeNumberedLines(context, lines, githubpkg.LineRange{Start: block.outer.Start, End: block.outer.Start}, 0)
+			}
+			writeNumberedLines(context, lines, block.inner, maxDeclLines)
+		}
+	}
+
+	if len(written) == 0 {
+		context.WriteString("(none - changes are outside functions and classes)\n")
+	}
+	writeOutsideContext(context, file, outside)
+
+	return true
+}
+
+// PublicSymbols returns the names of the file's public declarations, or none
+// if it can't be parsed
+func (p *outlineProvider) PublicSymbols(filename, content string) []string {
+	outline, err := p.parse(content)
+	if err != nil {
+		return nil
+	}
+	return outline.public
+}
+
+// writeImports writes the lines of the import statements
+func writeImports(context *strings.Builder, lines []string, imports []githubpkg.LineRange) {
+	var written []string
+	seen := make(map[int]bool)
+	for _, statement := range imports {
+		for line := statement.Start; line <= statement.End && line <= len(lines); line++ {
+			if seen[line] || len(written) >= maxImportLines {
+				continue
+			}
+			seen[line] = true
+			written = append(written, fmt.Sprintf("%5d | %s", line, lines[line-1]))
+		}
+	}
+
+	if len(written) > 0 {
+		context.WriteString("Imports:\n")
+		context.WriteString(strings.Join(written, "\n"))
+		context.WriteString("\n")
+	}
+}
+
+// enclosing is a block overlapping a hunk, with the smallest block that
+// contains it, if any
+type enclosing struct {
+	inner githubpkg.LineRange
+	outer *githubpkg.LineRange
+}
+
+// enclosingBlocks returns the innermost blocks overlapping the hunk, those
+// that contain no other overlapping block, and the ranges of the hunk's
+// lines none of them cover. A hunk over two sibling functions gets both,
+// and one over a function and a closure inside it gets the closure, with the
+// function's own changed lines left uncovered.
+func enclosingBlocks(blocks []githubpkg.LineRange, hunk githubpkg.LineRange) ([]enclosing, []githubpkg.LineRange) {
+	var overlapping []githubpkg.LineRange
+	for _, block := range blocks {
+		if overlapsAny(block, []githubpkg.LineRange{hunk}) {
+			overlapping = append(overlapping, block)
+		}
+	}
+
+	var found []enclosing
+	for i, block := range overlapping {
+		innermost := true
+		for j, other := range overlapping {
+			if i != j && other != block && contains(block, other) {
+				innermost = false
+				break
+			}
+		}
+		if innermost {
+			found = append(found, enclosing{inner: block, outer: smallestContaining(blocks, block)})
+		}
+	}
+
+	var uncovered []githubpkg.LineRange
+	for line := hunk.Start; line <= hunk.End; line++ {
+		covered := false
+		for _, block := range found {
+			if line >= block.inner.Start && line <= block.inner.End {
+				covered = true
+				break
+			}
+		}
+		switch {
+		case covered:
+		case len(uncovered) > 0 && uncovered[len(uncovered)-1].End == line-1:
+			uncovered[len(uncovered)-1].End = line
+		default:
+			uncovered = append(uncovered, githubpkg.LineRange{Start: line, End: line})
+		}
+	}
+	return found, uncovered
+}
+
+// smallestContaining returns the smallest block that contains inner and is
+// larger than it, or nil if there is none
+func smallestContaining(blocks []githubpkg.LineRange, inner githubpkg.LineRange) *githubpkg.LineRange {
+	var outer *githubpkg.LineRange
+	for i := range blocks {
+		block := &blocks[i]
+		if *block == inner || !contains(*block, inner) {
+			continue
+		}
+		if outer == nil || block.End-block.Start < outer.End-outer.Start {
+			outer = block
+		}
+	}
+	return outer
+}
+
+// contains reports whether block covers every line of other
+func contains(block, other githubpkg.LineRange) bool {
+	return block.Start <= other.Start && other.End <= block.End
+}
+
+// lineIndex converts byte offsets in a source file to 1-based line numbers
+type lineIndex []int
+
+// newLineIndex indexes the starts of the lines in content
+func newLineIndex(content string) lineIndex {
+	starts := lineIndex{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// line returns the line the byte at offset is on
+func (index lineIndex) line(offset int) int {
+	return sort.Search(len(index), func(i int) bool { return index[i] > offset })
+}
@@ -0,0 +1,389 @@
+package reviewer
+
+import (
+	"errors"
+	"strings"
+
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// phpToken is a token of PHP code. Strings, comments and inline HTML are
+// dropped, so braces and keywords inside them are never seen.
+type phpToken struct {
+	text string
+	line int
+}
+
+// phpLexer tokenizes PHP source
+type phpLexer struct {
+	src    string
+	pos    int
+	line   int
+	tokens []phpToken
+}
+
+// phpModifiers may precede a function or class declaration
+var phpModifiers = map[string]bool{
+	"abstract": true, "final": true, "public": true, "protected": true,
+	"private": true, "static": true, "readonly": true,
+}
+
+// parsePHP outlines a PHP file from its tokens. Declarations start at their
+// first modifier and end at the brace matching the one that opens their body.
+func parsePHP(content string) (*outline, error) {
+	tokens, err := phpTokens(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Match braces, remembering which belong to namespaces
+	closing := make(map[int]int)
+	namespaces := make(map[int]bool)
+	var open []int
+	for i, token := range tokens {
+		switch token.text {
+		case "{":
+			open = append(open, i)
+		case "}":
+			if len(open) == 0 {
+				return nil, errors.New("unbalanced braces")
+			}
+			closing[open[len(open)-1]] = i
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return nil, errors.New("unbalanced braces")
+	}
+
+	result := &outline{}
+	var enclosing []int
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.text {
+		case "{":
+			enclosing = append(enclosing, i)
+			continue
+		case "}":
+			enclosing = enclosing[:len(enclosing)-1]
+			continue
+		}
+
+		keyword := strings.ToLower(token.text)
+		if i > 0 {
+			switch tokens[i-1].text {
+			case "::", "->", "?->", "new":
+				// Member names and anonymous classes aren't declarations
+				continue
+			}
+		}
+
+		// Statements outside any function or class, possibly in a namespace
+		topLevel := true
+		for _, brace := range enclosing {
+			topLevel = topLevel && namespaces[brace]
+		}
+
+		switch keyword {
+		case "namespace", "use", "require", "require_once", "include", "include_once":
+			// Closures import variables with use too
+			if !topLevel || (keyword == "use" && i > 0 && tokens[i-1].text == ")") {
+				continue
+			}
+			end := phpStatementEnd(tokens, i)
+			if keyword == "namespace" && end < len(tokens) && tokens[end].text == "{" {
+				namespaces[end] = true
+			}
+			result.imports = append(result.imports, githubpkg.LineRange{Start: token.line, End: tokens[min(end, len(tokens)-1)].line})
+
+		case "function", "class", "interface", "trait", "enum":
+			name := i + 1
+			if name < len(tokens) && tokens[name].text == "&" {
+				name++
+			}
+			if name >= len(tokens) || !isPHPName(tokens[name].text) {
+				continue
+			}
+
+			start := i
+			private := false
+			for start > 0 && phpModifiers[strings.ToLower(tokens[start-1].text)] {
+				start--
+				private = private || strings.EqualFold(tokens[start].text, "private") || strings.EqualFold(tokens[start].text, "protected")
+			}
+			if !private {
+				result.public = append(result.public, tokens[name].text)
+			}
+
+			// Abstract and interface methods end before a body opens
+			body := phpStatementEnd(tokens, name)
+			if body >= len(tokens) || tokens[body].text != "{" {
+				continue
+			}
+			result.decls = append(result.decls, githubpkg.LineRange{Start: tokens[start].line, End: tokens[closing[body]].line})
+		}
+	}
+
+	return result, nil
+}
+
+// phpStatementEnd returns the index of the semicolon ending the statement
+// starting at i, or of the brace opening its block
+func phpStatementEnd(tokens []phpToken, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case ";", "{":
+			if depth <= 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// phpTokens returns the tokens of the PHP code in a file
+func phpTokens(content string) ([]phpToken, error) {
+	l := &phpLexer{src: content, line: 1}
+	for l.pos < len(l.src) {
+		l.skipHTML()
+		if err := l.code(false); err != nil {
+			return nil, err
+		}
+	}
+	return l.tokens, nil
+}
+
+// skipHTML skips inline HTML up to the next opening tag
+func (l *phpLexer) skipHTML() {
+	for l.pos < len(l.src) {
+		if strings.HasPrefix(l.src[l.pos:], "<?=") {
+			l.pos += 3
+			return
+		}
+		if len(l.src)-l.pos >= 5 && strings.EqualFold(l.src[l.pos:l.pos+5], "<?php") {
+			l.pos += 5
+			return
+		}
+		l.advance()
+	}
+}
+
+// code tokenizes PHP code up to a closing tag, or to the brace that closes an
+// interpolation if interpolated is set
+func (l *phpLexer) code(interpolated bool) error {
+	depth := 0
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		c := rest[0]
+		switch {
+		case strings.HasPrefix(rest, "?>") && !interpolated:
+			// A closing tag also ends the statement
+			l.emit(";")
+			l.pos += 2
+			return nil
+		case c == '#' && !strings.HasPrefix(rest, "#["), strings.HasPrefix(rest, "//"):
+			// Line comments end at the line or a closing tag
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && !strings.HasPrefix(l.src[l.pos:], "?>") {
+				l.pos++
+			}
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return errors.New("unterminated comment")
+			}
+			l.skip(end + 4)
+		case c == '\'':
+			if err := l.singleQuoted(); err != nil {
+				return err
+			}
+		case c == '"' || c == '`':
+			if err := l.doubleQuoted(c); err != nil {
+				return err
+			}
+		case strings.HasPrefix(rest, "<<<"):
+			if err := l.heredoc(); err != nil {
+				return err
+			}
+		case c == '\n':
+			l.advance()
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '$' || c == '\\' || isIdentStart(c):
+			start := l.pos
+			l.pos++
+			for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || l.src[l.pos] == '\\' || (l.src[l.pos] >= '0' && l.src[l.pos] <= '9')) {
+				l.pos++
+			}
+			l.emit(l.src[start:l.pos])
+		case strings.HasPrefix(rest, "?->"):
+			l.emit("?->")
+			l.pos += 3
+		case strings.HasPrefix(rest, "::"), strings.HasPrefix(rest, "->"):
+			l.emit(rest[:2])
+			l.pos += 2
+		case c >= '0' && c <= '9':
+			for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || (l.src[l.pos] >= '0' && l.src[l.pos] <= '9') || l.src[l.pos] == '.') {
+				l.pos++
+			}
+		default:
+			if interpolated {
+				switch c {
+				case '{':
+					depth++
+				case '}':
+					if depth == 0 {
+						l.pos++
+						return nil
+					}
+					depth--
+				}
+			}
+			if !interpolated {
+				l.emit(string(c))
+			}
+			l.pos++
+		}
+	}
+
+	if interpolated {
+		return errors.New("unterminated interpolation")
+	}
+	return nil
+}
+
+// emit appends a token on the current line
+func (l *phpLexer) emit(text string) {
+	l.tokens = append(l.tokens, phpToken{text: text, line: l.line})
+}
+
+// singleQuoted skips a single quoted string
+func (l *phpLexer) singleQuoted() error {
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos++
+			if l.pos < len(l.src) {
+				l.advance()
+			}
+		case '\'':
+			l.pos++
+			return nil
+		default:
+			l.advance()
+		}
+	}
+	return errors.New("unterminated string")
+}
+
+// doubleQuoted skips a double quoted or backtick string, including
+// interpolated expressions that may contain strings of their own
+func (l *phpLexer) doubleQuoted(quote byte) error {
+	l.pos++
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		switch {
+		case rest[0] == '\\':
+			l.pos++
+			if l.pos < len(l.src) {
+				l.advance()
+			}
+		case rest[0] == quote:
+			l.pos++
+			return nil
+		case strings.HasPrefix(rest, "{$"):
+			// The expression starts at the variable
+			if err := l.interpolation(1); err != nil {
+				return err
+			}
+		case strings.HasPrefix(rest, "${"):
+			if err := l.interpolation(2); err != nil {
+				return err
+			}
+		default:
+			l.advance()
+		}
+	}
+	return errors.New("unterminated string")
+}
+
+// interpolation skips an interpolated expression after its n byte opening,
+// discarding its tokens
+func (l *phpLexer) interpolation(n int) error {
+	l.pos += n
+	tokens := len(l.tokens)
+	err := l.code(true)
+	l.tokens = l.tokens[:tokens]
+	return err
+}
+
+// heredoc skips a heredoc or nowdoc string up to its closing identifier
+func (l *phpLexer) heredoc() error {
+	l.pos += 3
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+
+	header := l.src[l.pos:]
+	newline := strings.IndexByte(header, '\n')
+	if newline < 0 {
+		return errors.New("unterminated heredoc")
+	}
+	label := strings.Trim(strings.TrimSpace(header[:newline]), `'"`)
+	if label == "" {
+		return errors.New("heredoc without a label")
+	}
+	l.skip(newline + 1)
+
+	// The closing label may be indented since PHP 7.3
+	for l.pos < len(l.src) {
+		end := strings.IndexByte(l.src[l.pos:], '\n')
+		if end < 0 {
+			end = len(l.src) - l.pos
+		}
+		text := strings.TrimLeft(l.src[l.pos:l.pos+end], " \t")
+		if strings.HasPrefix(text, label) && (len(text) == len(label) || !isPHPNameByte(text[len(label)])) {
+			l.pos += end - len(text) + len(label)
+			return nil
+		}
+		l.skip(min(end+1, len(l.src)-l.pos))
+	}
+	return errors.New("unterminated heredoc")
+}
+
+// advance steps over one byte, counting lines
+func (l *phpLexer) advance() {
+	if l.src[l.pos] == '\n' {
+		l.line++
+	}
+	l.pos++
+}
+
+// skip steps over n bytes, counting lines
+func (l *phpLexer) skip(n int) {
+	l.line += strings.Count(l.src[l.pos:l.pos+n], "\n")
+	l.pos += n
+}
+
+// isPHPName reports whether a token is a plain identifier
+func isPHPName(text string) bool {
+	if text == "" || !isIdentStart(text[0]) {
+		return false
+	}
+	for i := 1; i < len(text); i++ {
+		if !isPHPNameByte(text[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPHPNameByte reports whether c can appear in an identifier
+func isPHPNameByte(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
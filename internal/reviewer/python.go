@@ -0,0 +1,285 @@
+package reviewer
+
+import (
+	"errors"
+	"strings"
+
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+)
+
+// pythonLine is a logical line of Python source: a statement that may span
+// several physical lines through brackets, backslashes or strings
+type pythonLine struct {
+	start, end int
+	indent     int
+
+	// code is the line without comments, with strings replaced by ""
+	code string
+}
+
+// pythonLexer splits Python source into logical lines the way the Python
+// tokenizer does, so blocks can be found from their indentation
+type pythonLexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+// parsePython outlines a Python file. Blocks extend over the logical lines
+// indented deeper than their def or class statement, and start at their first
+// decorator.
+func parsePython(content string) (*outline, error) {
+	lines, err := pythonLogicalLines(content)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &outline{}
+	decorated := 0
+	for i, line := range lines {
+		words := strings.Fields(line.code)
+		if len(words) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(words[0], "@") {
+			if decorated == 0 {
+				decorated = line.start
+			}
+			continue
+		}
+		start := line.start
+		if decorated != 0 {
+			start = decorated
+			decorated = 0
+		}
+
+		switch words[0] {
+		case "import", "from":
+			result.imports = append(result.imports, githubpkg.LineRange{Start: line.start, End: line.end})
+			continue
+		case "async":
+			words = words[1:]
+		}
+		if len(words) < 2 || (words[0] != "def" && words[0] != "class") {
+			continue
+		}
+
+		// A body on the same line as the header ends with the header
+		end := line.end
+		if strings.HasSuffix(line.code, ":") {
+			for _, body := range lines[i+1:] {
+				if body.indent <= line.indent {
+					break
+				}
+				end = body.end
+			}
+		}
+		result.decls = append(result.decls, githubpkg.LineRange{Start: start, End: end})
+
+		name := strings.FieldsFunc(words[1], func(r rune) bool {
+			return r == '(' || r == ':' || r == '['
+		})
+		if line.indent == 0 && len(name) > 0 && !strings.HasPrefix(name[0], "_") {
+			result.public = append(result.public, name[0])
+		}
+	}
+
+	return result, nil
+}
+
+// pythonLogicalLines returns the logical lines of a file, skipping blank and
+// comment-only lines
+func pythonLogicalLines(content string) ([]pythonLine, error) {
+	l := &pythonLexer{src: content, line: 1}
+
+	var lines []pythonLine
+	for l.pos < len(l.src) {
+		// Tabs advance to the next multiple of 8 columns and form feeds
+		// reset the column, as in the Python tokenizer
+		indent := 0
+		for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t' || l.src[l.pos] == '\f') {
+			switch l.src[l.pos] {
+			case '\t':
+				indent = (indent/8 + 1) * 8
+			case '\f':
+				indent = 0
+			default:
+				indent++
+			}
+			l.pos++
+		}
+
+		start := l.line
+		code, err := l.logicalLine()
+		if err != nil {
+			return nil, err
+		}
+		if code = strings.TrimSpace(code); code != "" {
+			lines = append(lines, pythonLine{start: start, end: l.line, indent: indent, code: code})
+		}
+
+		// Step over the newline that ended the logical line
+		if l.pos < len(l.src) {
+			l.pos++
+			l.line++
+		}
+	}
+
+	return lines, nil
+}
+
+// logicalLine reads up to the newline that ends the current logical line
+func (l *pythonLexer) logicalLine() (string, error) {
+	var code strings.Builder
+	depth := 0
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			if depth == 0 {
+				return code.String(), nil
+			}
+			code.WriteByte(' ')
+			l.pos++
+			l.line++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case c == '\\' && strings.HasPrefix(l.src[l.pos+1:], "\n"):
+			l.pos += 2
+			l.line++
+		case c == '\\' && strings.HasPrefix(l.src[l.pos+1:], "\r\n"):
+			l.pos += 3
+			l.line++
+		case c == '\'' || c == '"':
+			if err := l.skipString(false); err != nil {
+				return "", err
+			}
+			code.WriteString(`""`)
+		case isIdentStart(c):
+			word := l.word()
+			if l.pos < len(l.src) && (l.src[l.pos] == '\'' || l.src[l.pos] == '"') && isStringPrefix(word) {
+				if err := l.skipString(strings.ContainsAny(word, "fFtT")); err != nil {
+					return "", err
+				}
+				code.WriteString(`""`)
+				continue
+			}
+			code.WriteString(word)
+		default:
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth = max(0, depth-1)
+			}
+			code.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return code.String(), nil
+}
+
+// word reads an identifier
+func (l *pythonLexer) word() string {
+	start := l.pos
+	for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || (l.src[l.pos] >= '0' && l.src[l.pos] <= '9')) {
+		l.pos++
+	}
+	return l.src[start:l.pos]
+}
+
+// skipString reads a string literal starting at its opening quote. Replacement
+// fields of f-strings may hold nested strings, which are skipped too.
+func (l *pythonLexer) skipString(formatted bool) error {
+	quote := l.src[l.pos]
+	triple := strings.HasPrefix(l.src[l.pos:], strings.Repeat(string(quote), 3))
+	if triple {
+		l.pos += 3
+	} else {
+		l.pos++
+	}
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			// Even raw strings can't end at an escaped quote
+			if l.pos+1 < len(l.src) && l.src[l.pos+1] == '\n' {
+				l.line++
+			}
+			l.pos += 2
+		case c == '\n':
+			if !triple {
+				return errors.New("unterminated string")
+			}
+			l.line++
+			l.pos++
+		case c == quote && (!triple || strings.HasPrefix(l.src[l.pos:], strings.Repeat(string(quote), 3))):
+			if triple {
+				l.pos += 3
+			} else {
+				l.pos++
+			}
+			return nil
+		case formatted && c == '{':
+			if strings.HasPrefix(l.src[l.pos:], "{{") {
+				l.pos += 2
+				continue
+			}
+			if err := l.skipReplacementField(); err != nil {
+				return err
+			}
+		default:
+			l.pos++
+		}
+	}
+
+	return errors.New("unterminated string")
+}
+
+// skipReplacementField reads an f-string replacement field up to its closing brace
+func (l *pythonLexer) skipReplacementField() error {
+	depth := 0
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\'' || c == '"':
+			if err := l.skipString(false); err != nil {
+				return err
+			}
+			continue
+		case c == '\n':
+			l.line++
+		case c == '{' || c == '(' || c == '[':
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			if depth == 0 && c == '}' {
+				l.pos++
+				return nil
+			}
+		}
+		l.pos++
+	}
+	return errors.New("unterminated f-string")
+}
+
+// isStringPrefix reports whether a word can prefix a Python string literal
+func isStringPrefix(word string) bool {
+	switch strings.ToLower(word) {
+	case "r", "u", "b", "f", "t", "br", "rb", "fr", "rf", "tr", "rt":
+		return true
+	}
+	return false
+}
+
+// isIdentStart reports whether c can start an identifier. Bytes of non-ASCII
+// characters are treated as letters.
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
@@ -0,0 +1,122 @@
+package reviewer
+
+import (
+	"regexp"
+	"strings"
+
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+const (
+	// relocationWindow is how many lines a comment is moved at most to reach
+	// the nearest added line when it quotes no code
+	relocationWindow = 3
+	// minQuoteLength is the shortest quoted code matched against lines, so
+	// names like `x` don't match everywhere
+	minQuoteLength = 4
+)
+
+// inlineCode matches code quoted with backticks in a comment body
+var inlineCode = regexp.MustCompile("`([^`\n]+)`")
+
+// relocateComment finds the added line a comment on an unchanged line most
+// likely refers to. Lines quoting code from the comment win, anywhere in the
+// hunks around the comment's line; otherwise the nearest added line within
+// relocationWindow is used. ok is false if there is no such line.
+func relocateComment(patch *gh.Patch, comment types.FileComment) (line int, ok bool) {
+	quotes := quotedCode(comment.Body)
+
+	bestQuoted, bestNearest := 0, 0
+	for _, hunk := range patch.Hunks {
+		// Only hunks the comment's line is in or close to
+		r := hunk.NewRange()
+		if comment.Line < r.Start-relocationWindow || comment.Line > r.End+relocationWindow {
+			continue
+		}
+
+		for _, hunkLine := range hunk.Lines {
+			if hunkLine.Kind != gh.LineAdded {
+				continue
+			}
+			if matchesQuote(hunkLine.Text, quotes) && closer(hunkLine.NewLine, bestQuoted, comment.Line) {
+				bestQuoted = hunkLine.NewLine
+			}
+			if abs(hunkLine.NewLine-comment.Line) <= relocationWindow && closer(hunkLine.NewLine, bestNearest, comment.Line) {
+				bestNearest = hunkLine.NewLine
+			}
+		}
+	}
+
+	switch {
+	case bestQuoted != 0:
+		return bestQuoted, true
+	case bestNearest != 0:
+		return bestNearest, true
+	default:
+		return 0, false
+	}
+}
+
+// quotedCode returns the whitespace-normalized code quoted in a comment body,
+// inline or in fenced blocks
+func quotedCode(body string) []string {
+	var quotes []string
+	add := func(code string) {
+		if code = normalizeCode(code); len(code) >= minQuoteLength {
+			quotes = append(quotes, code)
+		}
+	}
+
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			add(line)
+			continue
+		}
+		for _, match := range inlineCode.FindAllStringSubmatch(line, -1) {
+			add(match[1])
+		}
+	}
+	return quotes
+}
+
+// matchesQuote reports whether a line contains any of the quoted code
+func matchesQuote(text string, quotes []string) bool {
+	text = normalizeCode(text)
+	for _, quote := range quotes {
+		if strings.Contains(text, quote) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeCode collapses runs of whitespace so indentation doesn't matter
+func normalizeCode(code string) string {
+	return strings.Join(strings.Fields(code), " ")
+}
+
+// closer reports whether line is closer to target than best, preferring the
+// earlier line on ties. A best of zero means none was found yet.
+func closer(line, best, target int) bool {
+	if best == 0 {
+		return true
+	}
+	if d, bestD := abs(line-target), abs(best-target); d != bestD {
+		return d < bestD
+	}
+	return line < best
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,53 @@
+package reviewer
+
+import (
+	"testing"
+
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// relocatePatch adds lines 11, 12 and 16, keeping the rest as context
+const relocatePatch = `@@ -10,4 +10,7 @@ func handler() {
+ 	id := r.PathValue("id")
++	user, err := store.Get(id)
++	log.Printf("fetched %s", id)
+ 	if err != nil {
+ 		return
+ 	}
++	render(w, user)
+ }`
+
+func TestRelocateComment(t *testing.T) {
+	patch, err := gh.ParsePatch(relocatePatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		line int
+		body string
+		want int
+		ok   bool
+	}{
+		{"quoted code", 13, "The error from `store.Get(id)` is ignored", 11, true},
+		{"quoted code in a fence", 14, "Nil user:\n```go\nrender(w,   user)\n```", 16, true},
+		{"nearest added line", 13, "This looks off", 12, true},
+		{"nearest added line after", 15, "This looks off", 16, true},
+		{"tie prefers the earlier line", 14, "This looks off", 12, true},
+		{"quote too short", 17, "Check `w`", 16, true},
+		{"too far away", 30, "This looks off", 0, false},
+		{"quote outside the hunks", 40, "`store.Get(id)`", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := types.FileComment{Path: "handler.go", Line: tt.line, Body: tt.body}
+			line, ok := relocateComment(patch, comment)
+			if line != tt.want || ok != tt.ok {
+				t.Errorf("relocateComment = %d, %v; want %d, %v", line, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/redact"
+)
+
+// maxConcurrentFetches bounds the number of in-flight file requests per review
+const maxConcurrentFetches = 8
+
+// errWithheld is returned when reading a file the redaction policy withholds
+var errWithheld = errors.New("content withheld by redaction policy")
+
+// prefetcher is implemented by revisions that benefit from reading files ahead of use
+type prefetcher interface {
+	Prefetch(ctx context.Context, paths []string)
+}
+
+// treeSource is implemented by forges that can list a commit's blobs and
+// read them by SHA through a cache, which GitHub does
+type treeSource interface {
+	GetTree(ctx context.Context, owner, repo, ref string) (blobs map[string]string, truncated bool, err error)
+	GetBlobContent(ctx context.Context, owner, repo, sha string) (string, error)
+}
+
+// forgeRevision reads files from a forge repository at a fixed ref. If the
+// forge is a treeSource, it resolves paths to blob SHAs from the commit tree
+// so reads go through the blob cache. Reads are cached for the duration of a
+// review.
+type forgeRevision struct {
+	forge forge.Forge
+	owner string
+	repo  string
+	ref   string
+
+	treeOnce  sync.Once
+	blobs     map[string]string
+	truncated bool
+
+	mu    sync.Mutex
+	files map[string]string
+}
+
+// newForgeRevision creates a revision reader for a repository ref
+func newForgeRevision(f forge.Forge, owner, repo, ref string) *forgeRevision {
+	return &forgeRevision{
+		forge: f,
+		owner: owner,
+		repo:  repo,
+		ref:   ref,
+		files: make(map[string]string),
+	}
+}
+
+// ReadFile returns the content of a file at the revision
+func (r *forgeRevision) ReadFile(ctx context.Context, filePath string) (string, error) {
+	r.mu.Lock()
+	content, ok := r.files[filePath]
+	r.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+
+	var err error
+	if sha, ok := r.blobSHA(ctx, filePath); ok {
+		content, err = r.forge.(treeSource).GetBlobContent(ctx, r.owner, r.repo, sha)
+	} else {
+		content, err = r.forge.ReadFile(ctx, r.owner, r.repo, filePath, r.ref)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.files[filePath] = content
+	r.mu.Unlock()
+	return content, nil
+}
+
+// ListDirectory returns the paths of the files in a directory at the revision
+func (r *forgeRevision) ListDirectory(ctx context.Context, dir string) ([]string, error) {
+	r.loadTree(ctx)
+	if r.blobs == nil || r.truncated {
+		return r.forge.ListDirectory(ctx, r.owner, r.repo, dir, r.ref)
+	}
+
+	var paths []string
+	for filePath := range r.blobs {
+		fileDir := path.Dir(filePath)
+		if fileDir == "." {
+			fileDir = ""
+		}
+		if fileDir == strings.TrimSuffix(dir, "/") {
+			paths = append(paths, filePath)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Prefetch reads files concurrently so later ReadFile calls are served from
+// memory. Errors are logged and surface again when the file is read.
+func (r *forgeRevision) Prefetch(ctx context.Context, paths []string) {
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+
+	for _, filePath := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, err := r.ReadFile(ctx, filePath); err != nil {
+				log.Printf("Error prefetching %s@%s: %v", filePath, r.ref, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// blobSHA returns the blob SHA of a path from the commit tree
+func (r *forgeRevision) blobSHA(ctx context.Context, filePath string) (string, bool) {
+	r.loadTree(ctx)
+	sha, ok := r.blobs[filePath]
+	return sha, ok
+}
+
+// loadTree fetches the commit tree once; without one, reads go through ReadFile
+func (r *forgeRevision) loadTree(ctx context.Context) {
+	r.treeOnce.Do(func() {
+		trees, ok := r.forge.(treeSource)
+		if !ok {
+			return
+		}
+
+		blobs, truncated, err := trees.GetTree(ctx, r.owner, r.repo, r.ref)
+		if err != nil {
+			log.Printf("Error getting tree for %s/%s@%s: %v", r.owner, r.repo, r.ref, err)
+			return
+		}
+		r.blobs = blobs
+		r.truncated = truncated
+	})
+}
+
+// withholdingRevision is a Revision that hides the files a redactor
+// withholds, so nothing read while building context can quote them
+type withholdingRevision struct {
+	Revision
+	redactor *redact.Redactor
+}
+
+// withhold wraps a revision to hide the files the redactor withholds. A nil
+// redactor withholds nothing.
+func withhold(revision Revision, redactor *redact.Redactor) Revision {
+	if redactor == nil {
+		return revision
+	}
+	return &withholdingRevision{Revision: revision, redactor: redactor}
+}
+
+// ReadFile returns the content of a file that is not withheld
+func (r *withholdingRevision) ReadFile(ctx context.Context, filePath string) (string, error) {
+	if kind, ok := r.redactor.Withhold(filePath); ok {
+		return "", fmt.Errorf("%s: %w (%s)", filePath, errWithheld, kind)
+	}
+	return r.Revision.ReadFile(ctx, filePath)
+}
+
+// ListDirectory returns the files in a directory that are not withheld
+func (r *withholdingRevision) ListDirectory(ctx context.Context, dir string) ([]string, error) {
+	paths, err := r.Revision.ListDirectory(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := paths[:0:0]
+	for _, filePath := range paths {
+		if _, ok := r.redactor.Withhold(filePath); !ok {
+			visible = append(visible, filePath)
+		}
+	}
+	return visible, nil
+}
+
+// Prefetch fetches the files that are not withheld, if the wrapped revision
+// prefetches at all
+func (r *withholdingRevision) Prefetch(ctx context.Context, paths []string) {
+	p, ok := r.Revision.(prefetcher)
+	if !ok {
+		return
+	}
+
+	var visible []string
+	for _, filePath := range paths {
+		if _, ok := r.redactor.Withhold(filePath); !ok {
+			visible = append(visible, filePath)
+		}
+	}
+	p.Prefetch(ctx, visible)
+}
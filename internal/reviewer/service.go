@@ -2,36 +2,107 @@ package reviewer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/analyzer"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/feedback"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
 	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/injection"
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/policy"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/prompt"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/redact"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/repoconfig"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/secrets"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/workspace"
 	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
 )
 
 // Service orchestrates the PR review process
 type Service struct {
-	githubClient   *gh.Client
+	forge          forge.Forge
 	llmClient      llm.Client
 	contextBuilder *ContextBuilder
-	reviewPoster   *gh.ReviewPoster
+	snapshots      *workspace.Manager
+	analyzers      *analyzer.Runner
+	postFindings   bool
+	redactPatterns []string
+	redactionAudit *redact.AuditLog
+	provider       llm.ProviderType
+	prompts        *prompt.Loader
+	timeout        time.Duration
+	feedback       *feedback.Store
 }
 
-// NewService creates a new reviewer service
-func NewService(githubClient *gh.Client, llmClient llm.Client) *Service {
+// NewService creates a new reviewer service for change requests on a forge
+func NewService(f forge.Forge, llmClient llm.Client) *Service {
 	return &Service{
-		githubClient:   githubClient,
+		forge:          f,
 		llmClient:      llmClient,
-		contextBuilder: NewContextBuilder(githubClient),
-		reviewPoster:   gh.NewReviewPoster(githubClient),
+		contextBuilder: NewContextBuilder(f),
+		redactionAudit: redact.NewAuditLog(""),
+		provider:       llm.ProviderOf(llmClient),
+		prompts:        prompt.NewLoader(""),
 	}
 }
 
-// ReviewPR performs a complete review of a pull request
-func (s *Service) ReviewPR(pr *github.PullRequest, repo *github.Repository) error {
-	ctx := context.Background()
+// SetPrompts sets the loader for prompt templates
+func (s *Service) SetPrompts(loader *prompt.Loader) {
+	s.prompts = loader
+}
+
+// SetSnapshots enables reading review context from repository snapshots
+func (s *Service) SetSnapshots(manager *workspace.Manager) {
+	s.snapshots = manager
+}
+
+// SetAnalyzers enables running static analyzers on the snapshot before the
+// LLM is called. If postFindings is set, findings are also posted as comments.
+func (s *Service) SetAnalyzers(runner *analyzer.Runner, postFindings bool) {
+	s.analyzers = runner
+	s.postFindings = postFindings
+}
+
+// SetRedaction configures the builtin redaction patterns and the audit log.
+// Confidential files are withheld unless the model client's provider is local.
+func (s *Service) SetRedaction(patterns []string, audit *redact.AuditLog) {
+	s.redactPatterns = patterns
+	s.redactionAudit = audit
+}
+
+// SetTimeout sets the deadline of each review. Rate limited GitHub requests
+// fail instead of waiting past it. Zero means no deadline.
+func (s *Service) SetTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// ReviewChangeRequest fetches a change request from the forge and reviews it
+func (s *Service) ReviewChangeRequest(ctx context.Context, owner, repo string, number int) error {
+	pr, repository, err := s.forge.GetChangeRequest(ctx, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to get change request: %w", err)
+	}
+	return s.ReviewPR(ctx, pr, repository)
+}
+
+// ReviewPR performs a complete review of a pull request, within the review
+// timeout if one is set
+func (s *Service) ReviewPR(ctx context.Context, pr *github.PullRequest, repo *github.Repository) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
 
 	owner := repo.GetOwner().GetLogin()
 	repoName := repo.GetName()
@@ -40,7 +111,7 @@ func (s *Service) ReviewPR(pr *github.PullRequest, repo *github.Repository) erro
 	log.Printf("Starting review for PR #%d in %s/%s", prNumber, owner, repoName)
 
 	// Get PR files
-	files, err := s.githubClient.GetPRFiles(ctx, owner, repoName, prNumber)
+	files, err := s.forge.ListFiles(ctx, owner, repoName, prNumber)
 	if err != nil {
 		return fmt.Errorf("failed to get PR files: %w", err)
 	}
@@ -50,39 +121,359 @@ func (s *Service) ReviewPR(pr *github.PullRequest, repo *github.Repository) erro
 		return nil
 	}
 
+	repoConfig := s.loadRepoConfig(ctx, owner, repoName, pr.GetBase().GetSHA())
+
+	snapshot := s.getSnapshot(ctx, owner, repoName, pr.GetHead().GetSHA())
+	defer s.snapshots.Release(snapshot)
+	var head Revision = newForgeRevision(s.forge, owner, repoName, pr.GetHead().GetSHA())
+	if snapshot != nil {
+		head = snapshot
+	}
+
+	// Masked and confidential files are left out while the context is built
+	redactor, redactionNote, err := s.newRedactor(repoConfig.Redaction)
+	if err != nil {
+		return fmt.Errorf("failed to redact context: %w", err)
+	}
+
 	// Build context for LLM
-	context, err := s.contextBuilder.BuildContext(ctx, repo, pr, files)
+	context, err := s.contextBuilder.BuildContext(ctx, repo, pr, files, snapshot, redactor)
 	if err != nil {
 		return fmt.Errorf("failed to build context: %w", err)
 	}
 
+	// Look for attempts to instruct the model in the untrusted content
+	markers := injection.Detect(context)
+	if len(markers) > 0 {
+		log.Printf("Detected %d possible prompt injection markers in PR #%d", len(markers), prNumber)
+	}
+
+	// Run static analyzers and include their findings
+	analysis := s.runAnalyzers(ctx, snapshot, files)
+	context += visibleFindings(analysis, redactor).Context()
+
+	// Detect secrets on added lines and keep them out of the LLM context
+	secretFindings := s.scanSecrets(ctx, repoConfig, files, head)
+	context = secrets.Redact(context, secretFindings)
+
+	// Apply the repository's redaction rules before the context leaves the server
+	context, err = s.redact(redactor, owner+"/"+repoName, prNumber, context)
+	if err != nil {
+		return fmt.Errorf("failed to redact context: %w", err)
+	}
+
 	// Get review from LLM
-	review, err := s.llmClient.ReviewCode(ctx, context)
+	review, err := s.generateReview(ctx, owner, repoName, pr, files, repoConfig, context)
 	if err != nil {
-		return fmt.Errorf("failed to get LLM review: %w", err)
+		return err
+	}
+
+	// Never approve a PR that tried to instruct the model
+	policy.RecordModelDecision(review)
+	injection.Guard(review, markers)
+
+	// Tell the repository its redaction patterns need fixing
+	if redactionNote != "" {
+		review.GeneralComments = append(review.GeneralComments, types.GeneralComment{
+			Body:     redactionNote,
+			Severity: types.SeverityWarning,
+		})
+	}
+
+	// Post analyzer findings alongside the model's comments
+	if s.postFindings {
+		review.FileComments = append(review.FileComments, analysis.FileComments()...)
 	}
 
+	// Secrets are always reported, whatever the model said
+	review.FileComments = append(review.FileComments, secrets.FileComments(secretFindings)...)
+
 	// Validate and enhance review
-	if err := s.validateReview(review, files); err != nil {
+	headSHA := pr.GetHead().GetSHA()
+	if err := s.validateReview(review, files, s.pathExists(ctx, owner, repoName, headSHA, snapshot)); err != nil {
 		log.Printf("Review validation warning: %v", err)
 		// Continue with potentially corrected review
 	}
+	for i, comment := range review.OutsideDiff {
+		review.OutsideDiff[i].URL = s.forge.LineURL(repo, headSHA, comment.Path, comment.Line)
+	}
+
+	// Leave out findings engineers rejected before, so they don't count
+	// towards the policy
+	fingerprintComments(ctx, review, files, head)
+	s.suppressRejected(owner, repoName, review)
+
+	// Gate the decision through the repository's policy
+	policy.Apply(repoConfig.Policy, review, policy.PullRequest{
+		Draft:        pr.GetDraft(),
+		LinesChanged: pr.GetAdditions() + pr.GetDeletions(),
+	})
 
-	// Post review to GitHub
-	if err := s.reviewPoster.PostReview(ctx, owner, repoName, prNumber, review, files); err != nil {
+	// Don't repeat findings from earlier reviews that are still open
+	s.skipPostedFindings(ctx, owner, repoName, prNumber, review, head)
+
+	// Post review to the forge
+	if err := s.forge.PostReview(ctx, owner, repoName, prNumber, review, files); err != nil {
 		return fmt.Errorf("failed to post review: %w", err)
 	}
+	s.recordPosted(owner, repoName, prNumber, review)
 
 	log.Printf("Successfully reviewed PR #%d: %s", prNumber, review.Decision)
 	return nil
 }
 
-// validateReview checks the review for common issues and filters invalid comments
-func (s *Service) validateReview(review *types.ReviewResponse, files []*github.CommitFile) error {
-	// Create file map for validation
-	fileMap := make(map[string]*github.CommitFile)
+// ReviewLocal reviews a change whose files are available locally, such as an
+// evaluation fixture, without posting it. It follows the same steps as
+// ReviewPR for everything that does not need a forge; name stands in for the
+// repository.
+func (s *Service) ReviewLocal(ctx context.Context, name string, pr *github.PullRequest, files []*github.CommitFile, head *workspace.Snapshot, base Revision) (*types.ReviewResponse, error) {
+	context, err := s.contextBuilder.BuildContextAt(ctx, pr, files, head, base, head, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build context: %w", err)
+	}
+	markers := injection.Detect(context)
+
+	review, err := s.generateReview(ctx, "local", name, pr, files, repoconfig.Default(), context)
+	if err != nil {
+		return nil, err
+	}
+
+	injection.Guard(review, markers)
+	if err := s.validateReview(review, files, s.pathExists(ctx, "local", name, "", head)); err != nil {
+		log.Printf("Review validation warning: %v", err)
+	}
+	return review, nil
+}
+
+// generateReview renders the prompt for the PR's main language and asks the
+// model for a review of the context
+func (s *Service) generateReview(ctx context.Context, owner, repoName string, pr *github.PullRequest, files []*github.CommitFile, repoConfig *repoconfig.Config, context string) (*types.ReviewResponse, error) {
+	language, languages := s.languages(files)
+	tmpl, err := s.loadPrompt(ctx, owner, repoName, pr.GetBase().GetSHA(), repoConfig, language)
+	if err != nil {
+		return nil, err
+	}
+	accepted, rejected := s.examples(owner, repoName)
+	llmPrompt, err := tmpl.Render(prompt.Data{
+		Repository: owner + "/" + repoName,
+		Author:     pr.GetUser().GetLogin(),
+		BaseBranch: pr.GetBase().GetRef(),
+		HeadBranch: pr.GetHead().GetRef(),
+		Draft:      pr.GetDraft(),
+		Language:   language,
+		Languages:  languages,
+		Focus:      repoConfig.Prompt.Focus,
+		Accepted:   accepted,
+		Rejected:   rejected,
+	}, context)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.llmClient.ReviewCode(ctx, llmPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LLM review: %w", err)
+	}
+	review.PromptVersion = tmpl.Version()
+	return review, nil
+}
+
+// loadRepoConfig reads the repository's reviewer configuration at ref,
+// falling back to the defaults if it is missing or invalid
+func (s *Service) loadRepoConfig(ctx context.Context, owner, repo, ref string) *repoconfig.Config {
+	data, err := s.forge.ReadFile(ctx, owner, repo, repoconfig.Path, ref)
+	if err != nil {
+		if !errors.Is(err, forge.ErrNotFound) {
+			log.Printf("Error reading %s: %v", repoconfig.Path, err)
+		}
+		return repoconfig.Default()
+	}
+
+	cfg, err := repoconfig.Parse([]byte(data))
+	if err != nil {
+		log.Printf("Ignoring repository configuration: %v", err)
+		return repoconfig.Default()
+	}
+	return cfg
+}
+
+// loadPrompt returns the prompt template for a language, using the
+// repository's template if it has one and it can be read and parsed
+func (s *Service) loadPrompt(ctx context.Context, owner, repo, ref string, repoConfig *repoconfig.Config, language string) (*prompt.Template, error) {
+	if path := repoConfig.Prompt.Template; path != "" {
+		source, err := s.forge.ReadFile(ctx, owner, repo, path, ref)
+		if err == nil {
+			var tmpl *prompt.Template
+			if tmpl, err = s.prompts.Load(language, path, source); err == nil {
+				return tmpl, nil
+			}
+		}
+		log.Printf("Ignoring repository prompt template %s: %v", path, err)
+	}
+
+	tmpl, err := s.prompts.Load(language, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// languages returns the language with the most changed lines and every
+// language the PR touches, sorted
+func (s *Service) languages(files []*github.CommitFile) (string, []string) {
+	changes := make(map[string]int)
 	for _, file := range files {
-		fileMap[file.GetFilename()] = file
+		language := s.contextBuilder.detectLanguage(file.GetFilename())
+		changes[language] += file.GetChanges()
+	}
+
+	var primary string
+	languages := make([]string, 0, len(changes))
+	for language, n := range changes {
+		languages = append(languages, language)
+		if primary == "" || n > changes[primary] || (n == changes[primary] && language < primary) {
+			primary = language
+		}
+	}
+	sort.Strings(languages)
+	return primary, languages
+}
+
+// scanSecrets runs the secret scanner over the lines added in each file. The
+// blocks of private keys are read from the head revision, or from the patch
+// if the file can't be read there.
+func (s *Service) scanSecrets(ctx context.Context, repoConfig *repoconfig.Config, files []*github.CommitFile, head Revision) []secrets.Finding {
+	scanner := secrets.NewScanner(repoConfig.Secrets)
+
+	var findings []secrets.Finding
+	for _, file := range files {
+		var lines []string
+		for _, added := range gh.GetAddedLines(file) {
+			for _, finding := range scanner.ScanLine(file.GetFilename(), added.Line, added.Text) {
+				if finding.PrivateKey() {
+					if lines == nil {
+						lines = headFileLines(ctx, file, head)
+					}
+					finding.Block = secrets.KeyBlock(lines, finding.Line)
+				}
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	if len(findings) > 0 {
+		log.Printf("Detected %d possible secrets", len(findings))
+	}
+	return findings
+}
+
+// headFileLines returns the lines of a changed file at the head revision.
+// Without a readable revision, the lines the patch shows are returned, up to
+// the first line it leaves out.
+func headFileLines(ctx context.Context, file *github.CommitFile, head Revision) []string {
+	if head != nil {
+		if content, err := head.ReadFile(ctx, file.GetFilename()); err == nil {
+			return strings.Split(content, "\n")
+		}
+	}
+
+	patch := gh.FilePatch(file)
+	lines := []string{}
+	for n := 1; ; n++ {
+		line, ok := patch.NewLine(n)
+		if !ok {
+			return lines
+		}
+		lines = append(lines, line.Text)
+	}
+}
+
+// newRedactor creates the redactor for a review. A repository pattern that
+// doesn't compile leaves out the repository's patterns rather than failing
+// the review, and the returned note says so; its masked and confidential
+// paths still apply.
+func (s *Service) newRedactor(cfg repoconfig.Redaction) (*redact.Redactor, string, error) {
+	redactor, err := redact.New(s.redactPatterns, cfg, s.provider.IsLocal())
+	if err == nil {
+		return redactor, "", nil
+	}
+
+	log.Printf("Ignoring repository redaction patterns: %v", err)
+	note := fmt.Sprintf("The repository's redaction patterns were ignored for this review, so only the server's patterns were applied: %v", err)
+	cfg.Patterns = nil
+	redactor, err = redact.New(s.redactPatterns, cfg, s.provider.IsLocal())
+	return redactor, note, err
+}
+
+// redact scrubs the context according to the redaction settings and records
+// what was removed, including the files withheld, in the audit log
+func (s *Service) redact(redactor *redact.Redactor, fullName string, prNumber int, context string) (string, error) {
+	context, report := redactor.Redact(context)
+	if err := s.redactionAudit.Record(fullName, prNumber, string(s.provider), report); err != nil {
+		// Never send content whose redaction could not be audited
+		return "", err
+	}
+	return context, nil
+}
+
+// getSnapshot returns a snapshot of the repository at ref, or nil if snapshots
+// are disabled or unavailable, in which case files are read from the API
+func (s *Service) getSnapshot(ctx context.Context, owner, repo, ref string) *workspace.Snapshot {
+	if s.snapshots == nil {
+		return nil
+	}
+
+	snapshot, err := s.snapshots.Get(ctx, owner, repo, ref)
+	if err != nil {
+		log.Printf("Falling back to API file access: %v", err)
+		return nil
+	}
+	return snapshot
+}
+
+// runAnalyzers runs the configured analyzers over the changed lines in the snapshot
+func (s *Service) runAnalyzers(ctx context.Context, snapshot *workspace.Snapshot, files []*github.CommitFile) *analyzer.Report {
+	if s.analyzers == nil {
+		return &analyzer.Report{}
+	}
+	if snapshot == nil {
+		return &analyzer.Report{Notes: []string{"static analyzers were skipped because no repository snapshot is available"}}
+	}
+
+	changedLines := make(map[string][]int)
+	for _, file := range files {
+		if file.GetStatus() == "removed" {
+			continue
+		}
+		if lines := gh.GetChangedLines(file); len(lines) > 0 {
+			changedLines[file.GetFilename()] = lines
+		}
+	}
+
+	return s.analyzers.Run(ctx, snapshot.Root, changedLines)
+}
+
+// visibleFindings returns the part of an analyzer report that may be sent to
+// the model, leaving out findings in withheld files
+func visibleFindings(report *analyzer.Report, redactor *redact.Redactor) *analyzer.Report {
+	visible := &analyzer.Report{Notes: report.Notes}
+	for _, finding := range report.Findings {
+		if _, withheld := redactor.Withhold(finding.Path); !withheld {
+			visible.Findings = append(visible.Findings, finding)
+		}
+	}
+	return visible
+}
+
+// validateReview checks the review for common issues and filters invalid
+// comments. Comments a few lines off a change are relocated to it, others on
+// lines outside the diff are moved to OutsideDiff, and comments are only
+// dropped if exists reports their path is not in the repository.
+func (s *Service) validateReview(review *types.ReviewResponse, files []*github.CommitFile, exists func(path string) bool) error {
+	// Parse each patch once for validation
+	patches := make(map[string]*gh.Patch)
+	for _, file := range files {
+		patches[file.GetFilename()] = gh.FilePatch(file)
 	}
 
 	// Filter out invalid file comments
@@ -91,16 +482,29 @@ func (s *Service) validateReview(review *types.ReviewResponse, files []*github.C
 
 	for _, comment := range review.FileComments {
 		// Check if file exists in PR
-		file, exists := fileMap[comment.Path]
-		if !exists {
-			warnings = append(warnings, fmt.Sprintf("comment references non-existent file: %s", comment.Path))
+		patch, inPR := patches[comment.Path]
+		if !inPR {
+			if !exists(comment.Path) {
+				warnings = append(warnings, fmt.Sprintf("comment references non-existent file: %s", comment.Path))
+				continue
+			}
+			review.OutsideDiff = append(review.OutsideDiff, comment)
 			continue
 		}
 
-		// Check if line is in the diff
-		if !gh.IsLineInDiff(file, comment.Line) {
-			warnings = append(warnings, fmt.Sprintf("comment references line not in diff: %s:%d", comment.Path, comment.Line))
-			continue
+		// Move near misses to the changed line they refer to, and put other
+		// comments on unchanged lines in the review body
+		if !patch.IsAdded(comment.Line) {
+			line, ok := relocateComment(patch, comment)
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("comment references line not in diff: %s:%d", comment.Path, comment.Line))
+				review.OutsideDiff = append(review.OutsideDiff, comment)
+				continue
+			}
+
+			warnings = append(warnings, fmt.Sprintf("moved comment on %s:%d to line %d", comment.Path, comment.Line, line))
+			comment.OriginalLine = comment.Line
+			comment.Line = line
 		}
 
 		validComments = append(validComments, comment)
@@ -116,6 +520,45 @@ func (s *Service) validateReview(review *types.ReviewResponse, files []*github.C
 	return nil
 }
 
+// pathExists returns a function reporting whether a path exists in the
+// repository at ref. The snapshot is checked when there is one, otherwise the
+// forge's listing of the path's directory, fetched once per directory. Paths
+// that can't be checked are assumed to exist.
+func (s *Service) pathExists(ctx context.Context, owner, repo, ref string, snapshot *workspace.Snapshot) func(name string) bool {
+	listings := make(map[string]map[string]bool)
+	return func(name string) bool {
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return false
+		}
+		if snapshot != nil {
+			_, err := os.Stat(snapshot.Path(name))
+			return err == nil
+		}
+		if s.forge == nil {
+			return true
+		}
+
+		dir := path.Dir(name)
+		if dir == "." {
+			dir = ""
+		}
+		listing, ok := listings[dir]
+		if !ok {
+			paths, err := s.forge.ListDirectory(ctx, owner, repo, dir, ref)
+			if err != nil && !errors.Is(err, forge.ErrNotFound) {
+				log.Printf("Failed to check whether %s exists: %v", name, err)
+				return true
+			}
+			listing = make(map[string]bool, len(paths))
+			for _, p := range paths {
+				listing[p] = true
+			}
+			listings[dir] = listing
+		}
+		return listing[name]
+	}
+}
+
 // GetReviewStats returns statistics about the review
 func (s *Service) GetReviewStats(review *types.ReviewResponse) ReviewStats {
 	stats := ReviewStats{
@@ -0,0 +1,102 @@
+package reviewer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/github/githubtest"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/repoconfig"
+)
+
+// listingForge serves directory listings of a fixed set of files and fails
+// every other call
+type listingForge struct {
+	forge.Forge
+	dirs     map[string][]string
+	listings int
+}
+
+func (f *listingForge) ListDirectory(ctx context.Context, owner, repo, dir, ref string) ([]string, error) {
+	f.listings++
+	paths, ok := f.dirs[dir]
+	if !ok {
+		return nil, forge.ErrNotFound
+	}
+	return paths, nil
+}
+
+func TestPathExists(t *testing.T) {
+	f := &listingForge{dirs: map[string][]string{
+		"":    {"go.mod"},
+		"cmd": {"cmd/main.go", "cmd/flags.go"},
+	}}
+	exists := NewService(f, nil).pathExists(context.Background(), "octo", "demo", "head", nil)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"go.mod", true},
+		{"cmd/main.go", true},
+		{"cmd/flags.go", true},
+		{"cmd/missing.go", false},
+		{"gone/main.go", false},
+		{"../outside.go", false},
+	}
+	for _, tt := range tests {
+		if got := exists(tt.path); got != tt.want {
+			t.Errorf("exists(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if f.listings != 3 {
+		t.Errorf("listed directories %d times, want 3", f.listings)
+	}
+
+	// Without a forge, paths can't be checked
+	if !NewService(nil, nil).pathExists(context.Background(), "octo", "demo", "head", nil)("cmd/main.go") {
+		t.Error("unchecked path reported missing")
+	}
+}
+
+func TestReviewPRUsesCallerContext(t *testing.T) {
+	server := githubtest.NewServer()
+	defer server.Close()
+	server.AddPullRequest(&githubtest.PullRequest{Owner: "octo", Repo: "demo", Number: 7})
+
+	// A review the caller has given up on stops before calling the forge
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	pr := &github.PullRequest{Number: github.Ptr(7)}
+	repo := &github.Repository{Name: github.Ptr("demo"), Owner: &github.User{Login: github.Ptr("octo")}}
+	if err := NewService(gh.NewForge(server.Client()), nil).ReviewPR(ctx, pr, repo); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want the caller's context canceled", err)
+	}
+}
+
+func TestNewRedactorIgnoresInvalidPatterns(t *testing.T) {
+	s := NewService(nil, nil)
+	redactor, note, err := s.newRedactor(repoconfig.Redaction{
+		MaskPaths: []string{"secrets/*"},
+		Patterns:  map[string]string{"ticket": "("},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(note, "ticket") {
+		t.Errorf("note %q doesn't name the invalid pattern", note)
+	}
+
+	// The repository's masked paths still apply
+	if _, withheld := redactor.Withhold("secrets/key.txt"); !withheld {
+		t.Error("masked path was not withheld")
+	}
+
+	if _, note, err := s.newRedactor(repoconfig.Redaction{Patterns: map[string]string{"ticket": `TICKET-\d+`}}); err != nil || note != "" {
+		t.Errorf("valid patterns got note %q, error %v", note, err)
+	}
+}
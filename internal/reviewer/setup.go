@@ -0,0 +1,136 @@
+package reviewer
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/analyzer"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/config"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/feedback"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitea"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitlab"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/prompt"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/redact"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/repoconfig"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/workspace"
+)
+
+// NewServiceFromConfig creates a reviewer service for change requests on the
+// named forge, and the forge client it uses, from application configuration
+func NewServiceFromConfig(cfg *config.Config, name string) (*Service, forge.Forge, error) {
+	f, err := newForge(cfg, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	llmClient, err := newLLMClient(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	service := NewService(f, llmClient)
+	service.SetPrompts(prompt.NewLoader(cfg.PromptDir))
+
+	// Snapshots need a forge that serves repository tarballs. Each forge
+	// caches them in its own directory, since a manager only tracks and
+	// evicts the snapshots it downloaded.
+	source, ok := f.(workspace.TarballSource)
+	if cfg.SnapshotMode == config.SnapshotTarball && ok {
+		dir := cfg.SnapshotDir
+		if dir != "" {
+			dir = filepath.Join(dir, name)
+		}
+		snapshots, err := workspace.NewManager(source, workspace.Config{
+			Dir:        dir,
+			MaxBytes:   cfg.SnapshotMaxBytes,
+			MaxEntries: cfg.SnapshotMaxEntries,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		service.SetSnapshots(snapshots)
+	}
+
+	if len(cfg.Analyzers) > 0 {
+		runner, err := analyzer.NewRunner(cfg.Analyzers)
+		if err != nil {
+			return nil, nil, err
+		}
+		service.SetAnalyzers(runner, cfg.PostAnalyzerFindings)
+	}
+
+	// Reject unknown builtin patterns at startup rather than on every review
+	if _, err := redact.New(cfg.RedactPatterns, repoconfig.Redaction{}, false); err != nil {
+		return nil, nil, err
+	}
+	service.SetRedaction(cfg.RedactPatterns, redact.NewAuditLog(cfg.RedactionAuditLog))
+	service.SetTimeout(cfg.ReviewTimeout)
+
+	// Each forge keeps its own feedback, as repository names can collide
+	if cfg.FeedbackDir != "" {
+		store, err := feedback.NewStore(filepath.Join(cfg.FeedbackDir, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		service.SetFeedback(store)
+	}
+
+	return service, f, nil
+}
+
+// newForge creates the client for a forge
+func newForge(cfg *config.Config, name string) (forge.Forge, error) {
+	switch name {
+	case forge.GitHub:
+		// The blob cache is shared by every review the process runs
+		blobCache, err := gh.NewBlobCache(cfg.BlobCacheDir, cfg.BlobCacheBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create blob cache: %w", err)
+		}
+
+		githubClient, err := gh.NewClientWithOptions(cfg.GitHubToken, gh.Options{
+			BaseURL:   cfg.GitHubAPIURL,
+			UploadURL: cfg.GitHubUploadURL,
+			CABundle:  cfg.GitHubCABundle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		githubClient.SetBlobCache(blobCache)
+		return gh.NewForge(githubClient), nil
+	case forge.GitLab:
+		gitlabClient, err := gitlab.NewClient(cfg.GitLabURL, cfg.GitLabToken)
+		if err != nil {
+			return nil, err
+		}
+		return gitlab.NewForge(gitlabClient), nil
+	case forge.Gitea:
+		giteaClient, err := gitea.NewClient(cfg.GiteaURL, cfg.GiteaToken)
+		if err != nil {
+			return nil, err
+		}
+		return gitea.NewForge(giteaClient), nil
+	default:
+		return nil, fmt.Errorf("unknown forge: %s", name)
+	}
+}
+
+// newLLMClient creates the model client, recording or replaying its reviews
+// if configured
+func newLLMClient(cfg *config.Config) (llm.Client, error) {
+	if cfg.LLMReplayDir != "" {
+		return llm.NewReplayClient(cfg.LLMReplayDir), nil
+	}
+
+	var client llm.Client = llm.NewOllamaClient(cfg.OllamaURL, cfg.OllamaModel)
+	if cfg.LLMRecordDir != "" {
+		recorder, err := llm.NewRecordingClient(client, cfg.LLMRecordDir)
+		if err != nil {
+			return nil, err
+		}
+		client = recorder
+	}
+	return client, nil
+}
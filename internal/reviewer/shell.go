@@ -0,0 +1,33 @@
+package reviewer
+
+import (
+	"strings"
+
+	githubpkg "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// parseShell outlines a shell script. Functions are its declarations and
+// source commands its imports; all functions of a script are public.
+func parseShell(content string) (*outline, error) {
+	script, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(content), "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &outline{}
+	syntax.Walk(script, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.FuncDecl:
+			result.decls = append(result.decls, githubpkg.LineRange{Start: int(node.Pos().Line()), End: int(node.End().Line())})
+			result.public = append(result.public, node.Name.Value)
+		case *syntax.CallExpr:
+			if len(node.Args) > 1 && (node.Args[0].Lit() == "source" || node.Args[0].Lit() == ".") {
+				result.imports = append(result.imports, githubpkg.LineRange{Start: int(node.Pos().Line()), End: int(node.End().Line())})
+			}
+		}
+		return true
+	})
+
+	return result, nil
+}
@@ -0,0 +1,222 @@
+package secrets
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/repoconfig"
+	"github.com/lehigh-university-libraries/mountain-hawk/pkg/types"
+)
+
+// minEntropy is the Shannon entropy, in bits per character, above which a
+// value assigned to a secret-looking name is reported
+const minEntropy = 3.5
+
+// Placeholder replaces detected secrets in the context sent to the LLM
+const Placeholder = "[REDACTED SECRET]"
+
+// rule is a pattern for one kind of secret. If the pattern has a group
+// named "secret", only that part of the match is the secret value.
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	entropy bool
+}
+
+// rules are the built-in secret patterns
+var rules = []rule{
+	{
+		name:    "AWS access key ID",
+		pattern: regexp.MustCompile(`\b(?P<secret>(AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`),
+	},
+	{
+		name:    "AWS secret access key",
+		pattern: regexp.MustCompile(`(?i)aws.{0,20}(secret|private).{0,20}['"]?\s*[:=]\s*['"]?(?P<secret>[A-Za-z0-9/+=]{40})\b`),
+	},
+	{
+		name:    "GitHub token",
+		pattern: regexp.MustCompile(`\b(?P<secret>(ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`),
+	},
+	{
+		name:    privateKeyRule,
+		pattern: regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`),
+	},
+	{
+		name:    "high-entropy secret assignment",
+		pattern: regexp.MustCompile(`(?i)(password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret|credential)s?\w*['"]?\s*[:=]+\s*['"]?(?P<secret>[^\s'"]{12,})`),
+		entropy: true,
+	},
+}
+
+// privateKeyRule names the rule matching private key headers
+const privateKeyRule = "private key"
+
+// privateKeyEnd matches the line ending a private key block
+var privateKeyEnd = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY( BLOCK)?-----`)
+
+// Finding is a secret detected on an added line
+type Finding struct {
+	Path  string
+	Line  int
+	Rule  string
+	Value string
+
+	// Block holds the lines following a private key's header, through its
+	// END line, which are redacted along with the header
+	Block []string
+}
+
+// PrivateKey reports whether the finding is a private key header, whose
+// Block should be set with KeyBlock
+func (f Finding) PrivateKey() bool {
+	return f.Rule == privateKeyRule
+}
+
+// KeyBlock returns the lines of a file following a private key header on
+// the 1-based line header, through the END line of the key. A key without
+// an END line runs to the end of the file.
+func KeyBlock(lines []string, header int) []string {
+	var block []string
+	for i := header; i >= 1 && i < len(lines); i++ {
+		block = append(block, lines[i])
+		if privateKeyEnd.MatchString(lines[i]) {
+			break
+		}
+	}
+	return block
+}
+
+// Scanner detects secrets in added lines
+type Scanner struct {
+	allowlist   []*regexp.Regexp
+	ignorePaths []string
+}
+
+// NewScanner creates a scanner using a repository's secrets configuration.
+// Invalid allowlist patterns are skipped, so a typo can't disable scanning.
+func NewScanner(cfg repoconfig.Secrets) *Scanner {
+	s := &Scanner{ignorePaths: cfg.IgnorePaths}
+	for _, pattern := range cfg.Allowlist {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("Skipping invalid secrets allowlist pattern %q: %v", pattern, err)
+			continue
+		}
+		s.allowlist = append(s.allowlist, re)
+	}
+	return s
+}
+
+// ScanLine returns the secrets found in a single added line
+func (s *Scanner) ScanLine(path string, line int, text string) []Finding {
+	if repoconfig.MatchPath(s.ignorePaths, path) {
+		return nil
+	}
+
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		for _, match := range r.pattern.FindAllStringSubmatch(text, -1) {
+			value := match[0]
+			if i := r.pattern.SubexpIndex("secret"); i > 0 && match[i] != "" {
+				value = match[i]
+			}
+
+			// The more specific rules come first
+			if seen[value] {
+				continue
+			}
+
+			if r.entropy && (entropy(value) < minEntropy || looksLikeReference(value)) {
+				continue
+			}
+			if s.allowed(value) {
+				continue
+			}
+
+			seen[value] = true
+			findings = append(findings, Finding{Path: path, Line: line, Rule: r.name, Value: value})
+		}
+	}
+	return findings
+}
+
+// allowed reports whether a value matches the allowlist
+func (s *Scanner) allowed(value string) bool {
+	for _, re := range s.allowlist {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces every detected secret value in text with the placeholder.
+// The lines of a private key block are replaced wherever they end a line of
+// text, whether it quotes them in a diff, numbered context or an outline.
+func Redact(text string, findings []Finding) string {
+	for _, finding := range findings {
+		text = strings.ReplaceAll(text, finding.Value, Placeholder)
+		for _, line := range finding.Block {
+			if line = strings.TrimSpace(line); line != "" {
+				text = regexp.MustCompile(`(?m)`+regexp.QuoteMeta(line)+`[ \t\r]*$`).ReplaceAllString(text, Placeholder)
+			}
+		}
+	}
+	return text
+}
+
+// FileComments converts findings into error-level security comments. The
+// secret itself is masked so the comment does not leak it again.
+func FileComments(findings []Finding) []types.FileComment {
+	comments := make([]types.FileComment, 0, len(findings))
+	for _, finding := range findings {
+		comments = append(comments, types.FileComment{
+			Path: finding.Path,
+			Line: finding.Line,
+			Body: fmt.Sprintf("Possible %s committed (`%s`). Treat it as compromised: revoke or rotate it and remove it from the branch history.",
+				finding.Rule, mask(finding.Value)),
+			Severity: types.SeverityError,
+			Type:     types.TypeSecurity,
+		})
+	}
+	return comments
+}
+
+// mask keeps only the first few characters of a secret
+func mask(value string) string {
+	if len(value) <= 4 {
+		return "****"
+	}
+	return value[:4] + strings.Repeat("*", 8)
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, ch := range s {
+		counts[ch]++
+	}
+
+	var bits float64
+	n := float64(len([]rune(s)))
+	for _, count := range counts {
+		p := float64(count) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// looksLikeReference reports whether a value reads the secret from elsewhere
+// rather than containing it, e.g. an environment variable or function call
+func looksLikeReference(value string) bool {
+	return strings.HasPrefix(value, "$") ||
+		strings.HasPrefix(value, "%") ||
+		strings.Contains(value, "(") ||
+		strings.Contains(value, "{{") ||
+		strings.HasPrefix(value, "os.") ||
+		strings.HasPrefix(value, "process.env")
+}
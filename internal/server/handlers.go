@@ -1,11 +1,14 @@
 package server
 
 import (
-	"io"
+	"context"
 	"log"
 	"net/http"
 
 	"github.com/google/go-github/v74/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitea"
+	gh "github.com/lehigh-university-libraries/mountain-hawk/internal/github"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/gitlab"
 )
 
 // handleWebhook processes GitHub webhook events
@@ -15,16 +18,16 @@ func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Read request body
-	payload, err := io.ReadAll(r.Body)
+	// Read and authenticate the request body
+	payload, err := gh.ReadWebhookPayload(r, []byte(s.config.WebhookSecret), s.config.GitHubHost())
 	if err != nil {
-		log.Printf("Failed to read webhook body: %v", err)
-		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		log.Printf("Rejected webhook: %v", err)
+		http.Error(w, "Invalid webhook signature", http.StatusUnauthorized)
 		return
 	}
 
 	// Parse webhook event
-	event, err := github.ParseWebHook(github.WebHookType(r), payload)
+	event, err := gh.ParseWebhookEvent(r, payload)
 	if err != nil {
 		log.Printf("Failed to parse webhook: %v", err)
 		http.Error(w, "Failed to parse webhook", http.StatusBadRequest)
@@ -35,6 +38,8 @@ func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	switch e := event.(type) {
 	case *github.PullRequestEvent:
 		s.handlePullRequestEvent(e)
+	case *github.PullRequestReviewThreadEvent:
+		s.handleReviewThreadEvent(e)
 	default:
 		log.Printf("Unhandled event type: %T", event)
 	}
@@ -59,12 +64,116 @@ func (s *Server) handlePullRequestEvent(event *github.PullRequestEvent) {
 
 	// Process review asynchronously to avoid webhook timeouts
 	go func() {
-		if err := s.reviewService.ReviewPR(pr, repo); err != nil {
+		if err := s.reviewService.ReviewPR(context.Background(), pr, repo); err != nil {
 			log.Printf("Failed to review PR #%d: %v", pr.GetNumber(), err)
 		}
 	}()
 }
 
+// handleReviewThreadEvent records resolving a review thread as feedback on
+// the finding that started it
+func (s *Server) handleReviewThreadEvent(event *github.PullRequestReviewThreadEvent) {
+	action := event.GetAction()
+	if action != "resolved" && action != "unresolved" {
+		log.Printf("Ignoring review thread action: %s", action)
+		return
+	}
+
+	thread := event.GetThread()
+	if thread == nil || len(thread.Comments) == 0 {
+		return
+	}
+
+	repo := event.GetRepo()
+	user := event.GetSender().GetLogin()
+	if err := s.reviewService.RecordThreadResolution(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), user, thread.Comments[0].GetBody(), action == "resolved"); err != nil {
+		log.Printf("Failed to record review thread feedback on PR #%d: %v", event.GetPullRequest().GetNumber(), err)
+	}
+}
+
+// handleGitLabWebhook processes GitLab merge request webhook events
+func (s *Server) handleGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Read and authenticate the request body
+	payload, err := gitlab.ReadWebhookPayload(r, s.config.GitLabWebhookSecret)
+	if err != nil {
+		log.Printf("Rejected GitLab webhook: %v", err)
+		http.Error(w, "Invalid webhook token", http.StatusUnauthorized)
+		return
+	}
+
+	hook, err := gitlab.ParseMergeRequestHook(r, payload)
+	if err != nil {
+		log.Printf("Unhandled GitLab webhook: %v", err)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !hook.ShouldReview() {
+		log.Printf("Ignoring MR action: %s", hook.ObjectAttributes.Action)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	owner, repo := hook.Repository()
+	iid := hook.ObjectAttributes.IID
+	log.Printf("Processing MR !%d: %s", iid, hook.ObjectAttributes.Title)
+
+	// Process review asynchronously to avoid webhook timeouts
+	go func() {
+		if err := s.gitlabService.ReviewChangeRequest(context.Background(), owner, repo, iid); err != nil {
+			log.Printf("Failed to review MR !%d in %s/%s: %v", iid, owner, repo, err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleGiteaWebhook processes Gitea and Forgejo pull request webhook events
+func (s *Server) handleGiteaWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Read and authenticate the request body
+	payload, err := gitea.ReadWebhookPayload(r, []byte(s.config.GiteaWebhookSecret))
+	if err != nil {
+		log.Printf("Rejected Gitea webhook: %v", err)
+		http.Error(w, "Invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+
+	hook, err := gitea.ParsePullRequestHook(r, payload)
+	if err != nil {
+		log.Printf("Unhandled Gitea webhook: %v", err)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !hook.ShouldReview() {
+		log.Printf("Ignoring PR action: %s", hook.Action)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	owner, repo := hook.Repository.Owner.Login, hook.Repository.Name
+	log.Printf("Processing PR #%d: %s", hook.Number, hook.PullRequest.Title)
+
+	// Process review asynchronously to avoid webhook timeouts
+	go func() {
+		if err := s.giteaService.ReviewChangeRequest(context.Background(), owner, repo, hook.Number); err != nil {
+			log.Printf("Failed to review PR #%d in %s/%s: %v", hook.Number, owner, repo, err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // handleHealth provides a health check endpoint
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -1,11 +1,13 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/config"
-	"github.com/lehigh-university-libraries/mountain-hawk/internal/github"
-	"github.com/lehigh-university-libraries/mountain-hawk/internal/llm"
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/forge"
 	"github.com/lehigh-university-libraries/mountain-hawk/internal/reviewer"
 )
 
@@ -13,36 +15,80 @@ import (
 type Server struct {
 	config        *config.Config
 	reviewService *reviewer.Service
+	gitlabService *reviewer.Service
+	giteaService  *reviewer.Service
 	mux           *http.ServeMux
 }
 
 // New creates a new server with all dependencies initialized
-func New(cfg *config.Config) *Server {
-	// Initialize dependencies
-	githubClient := github.NewClient(cfg.GitHubToken)
-	llmClient := llm.NewOllamaClient(cfg.OllamaURL, cfg.OllamaModel)
-	reviewService := reviewer.NewService(githubClient, llmClient)
-
+func New(cfg *config.Config) (*Server, error) {
 	// Create server
 	s := &Server{
-		config:        cfg,
-		reviewService: reviewService,
-		mux:           http.NewServeMux(),
+		config: cfg,
+		mux:    http.NewServeMux(),
+	}
+
+	// Initialize a review service for each configured forge
+	var err error
+	if cfg.GitHubToken != "" {
+		if s.reviewService, _, err = reviewer.NewServiceFromConfig(cfg, forge.GitHub); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.GitLabToken != "" {
+		if s.gitlabService, _, err = reviewer.NewServiceFromConfig(cfg, forge.GitLab); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.GiteaToken != "" {
+		if s.giteaService, _, err = reviewer.NewServiceFromConfig(cfg, forge.Gitea); err != nil {
+			return nil, err
+		}
 	}
 
 	// Setup routes
 	s.setupRoutes()
 
-	return s
+	return s, nil
 }
 
 // setupRoutes configures all HTTP routes
 func (s *Server) setupRoutes() {
-	s.mux.HandleFunc("/webhook", s.handleWebhook)
+	if s.reviewService != nil {
+		s.mux.HandleFunc("/webhook", s.handleWebhook)
+	}
+	if s.gitlabService != nil {
+		s.mux.HandleFunc("/webhook/gitlab", s.handleGitLabWebhook)
+	}
+	if s.giteaService != nil {
+		s.mux.HandleFunc("/webhook/gitea", s.handleGiteaWebhook)
+	}
 	s.mux.HandleFunc("/health", s.handleHealth)
 }
 
-// ListenAndServe starts the HTTP server
-func (s *Server) ListenAndServe() error {
-	return http.ListenAndServe(":"+s.config.Port, s.mux)
+// ListenAndServe starts the HTTP server and polls for feedback on posted
+// findings, until ctx is done or the server fails
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Reactions on posted findings don't trigger webhooks
+	for _, service := range []*reviewer.Service{s.reviewService, s.gitlabService, s.giteaService} {
+		if service != nil {
+			go service.PollFeedback(ctx, s.config.FeedbackPollInterval)
+		}
+	}
+
+	srv := &http.Server{Addr: ":" + s.config.Port, Handler: s.mux}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Printf("Failed to shut down the server: %v", err)
+		}
+	}()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
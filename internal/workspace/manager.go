@@ -0,0 +1,296 @@
+package workspace
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTooLarge is returned when a repository snapshot exceeds the size cap
+var ErrTooLarge = errors.New("repository snapshot exceeds size limit")
+
+// TarballSource downloads gzipped tarballs of a repository at a ref
+type TarballSource interface {
+	DownloadTarball(ctx context.Context, owner, repo, ref string) (io.ReadCloser, error)
+}
+
+// Config holds snapshot manager configuration
+type Config struct {
+	// Dir is a persistent cache directory. When empty, each snapshot is
+	// extracted into a temporary directory and removed on Release.
+	Dir string
+
+	// MaxBytes caps the extracted size of a single snapshot
+	MaxBytes int64
+
+	// MaxEntries is the number of snapshots kept in a persistent cache
+	MaxEntries int
+}
+
+// Manager downloads repository snapshots and caches them on disk
+type Manager struct {
+	source TarballSource
+	config Config
+
+	mu      sync.Mutex
+	loading map[string]*sync.Mutex
+	inUse   map[string]int
+}
+
+// NewManager creates a snapshot manager
+func NewManager(source TarballSource, config Config) (*Manager, error) {
+	if config.Dir != "" {
+		if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+	}
+
+	return &Manager{
+		source:  source,
+		config:  config,
+		loading: make(map[string]*sync.Mutex),
+		inUse:   make(map[string]int),
+	}, nil
+}
+
+// Get returns a snapshot of the repository at ref, downloading it if it is
+// not cached. Callers must Release the snapshot when done with it.
+func (m *Manager) Get(ctx context.Context, owner, repo, ref string) (*Snapshot, error) {
+	snapshot := &Snapshot{Owner: owner, Repo: repo, Ref: ref}
+	key := snapshot.key()
+
+	// Serialize downloads of the same commit without blocking other commits
+	m.mu.Lock()
+	lock, ok := m.loading[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.loading[key] = lock
+	}
+	m.inUse[key]++
+	m.mu.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	root, err := m.load(ctx, snapshot)
+	if err != nil {
+		m.release(key)
+		return nil, err
+	}
+	snapshot.Root = root
+
+	if m.config.Dir != "" {
+		m.evict()
+	}
+
+	return snapshot, nil
+}
+
+// Release marks a snapshot as no longer used, removing it if it is not cached
+func (m *Manager) Release(snapshot *Snapshot) {
+	if snapshot == nil {
+		return
+	}
+
+	m.release(snapshot.key())
+
+	if m.config.Dir == "" {
+		if err := os.RemoveAll(snapshot.Root); err != nil {
+			log.Printf("Failed to remove snapshot %s: %v", snapshot.Root, err)
+		}
+	}
+}
+
+// release decrements the use count of a snapshot
+func (m *Manager) release(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inUse[key]--
+	if m.inUse[key] <= 0 {
+		delete(m.inUse, key)
+		delete(m.loading, key)
+	}
+}
+
+// load returns the directory holding the snapshot, extracting it if needed
+func (m *Manager) load(ctx context.Context, snapshot *Snapshot) (string, error) {
+	if m.config.Dir == "" {
+		tmp, err := os.MkdirTemp("", "mountain-hawk-snapshot-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		if err := m.download(ctx, snapshot, tmp); err != nil {
+			os.RemoveAll(tmp)
+			return "", err
+		}
+		return tmp, nil
+	}
+
+	root := filepath.Join(m.config.Dir, filepath.FromSlash(snapshot.key()))
+	if _, err := os.Stat(root); err == nil {
+		// Record the access for eviction
+		now := time.Now()
+		os.Chtimes(root, now, now)
+		return root, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(root), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(root), ".download-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := m.download(ctx, snapshot, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, root); err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("failed to store snapshot: %w", err)
+	}
+
+	return root, nil
+}
+
+// download fetches the tarball for a snapshot and extracts it into dir
+func (m *Manager) download(ctx context.Context, snapshot *Snapshot, dir string) error {
+	body, err := m.source.DownloadTarball(ctx, snapshot.Owner, snapshot.Repo, snapshot.Ref)
+	if err != nil {
+		return fmt.Errorf("failed to download snapshot: %w", err)
+	}
+	defer body.Close()
+
+	if err := extract(body, dir, m.config.MaxBytes); err != nil {
+		return fmt.Errorf("failed to extract snapshot of %s/%s@%s: %w", snapshot.Owner, snapshot.Repo, snapshot.Ref, err)
+	}
+	return nil
+}
+
+// evict removes the least recently used cached snapshots beyond MaxEntries,
+// skipping snapshots that are in use
+func (m *Manager) evict() {
+	if m.config.MaxEntries <= 0 {
+		return
+	}
+
+	roots, err := filepath.Glob(filepath.Join(m.config.Dir, "*", "*", "*"))
+	if err != nil {
+		return
+	}
+
+	type cached struct {
+		root    string
+		key     string
+		modTime time.Time
+	}
+
+	var snapshots []cached
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil || !info.IsDir() || strings.HasPrefix(filepath.Base(root), ".") {
+			continue
+		}
+		rel, _ := filepath.Rel(m.config.Dir, root)
+		snapshots = append(snapshots, cached{root: root, key: filepath.ToSlash(rel), modTime: info.ModTime()})
+	}
+
+	if len(snapshots) <= m.config.MaxEntries {
+		return
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].modTime.After(snapshots[j].modTime)
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, snapshot := range snapshots[m.config.MaxEntries:] {
+		if m.inUse[snapshot.key] > 0 {
+			continue
+		}
+		if err := os.RemoveAll(snapshot.root); err != nil {
+			log.Printf("Failed to evict snapshot %s: %v", snapshot.root, err)
+		}
+	}
+}
+
+// extract unpacks a GitHub tarball into dir, dropping the top-level
+// "owner-repo-sha/" directory and enforcing the size cap
+func extract(r io.Reader, dir string, maxBytes int64) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Strip the leading directory that wraps every GitHub tarball
+		_, rel, found := strings.Cut(header.Name, "/")
+		if !found || rel == "" {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			total += header.Size
+			if maxBytes > 0 && total > maxBytes {
+				return ErrTooLarge
+			}
+			if err := writeFile(target, tr, header.Size); err != nil {
+				return err
+			}
+		default:
+			// Symlinks and other entries could point outside the workspace
+		}
+	}
+}
+
+// writeFile writes size bytes from r to path
+func writeFile(path string, r io.Reader, size int64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(f, r, size); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,217 @@
+package workspace_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lehigh-university-libraries/mountain-hawk/internal/workspace"
+)
+
+// entry is a file in a fake tarball, below its wrapping directory
+type entry struct {
+	name     string
+	body     string
+	typeflag byte
+	linkname string
+}
+
+// tarballs serves the same gzipped tarball for every repository, wrapped in
+// a top-level directory as GitHub does, and counts downloads
+type tarballs struct {
+	entries   []entry
+	downloads int
+}
+
+func (s *tarballs) DownloadTarball(ctx context.Context, owner, repo, ref string) (io.ReadCloser, error) {
+	s.downloads++
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range s.entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		header := &tar.Header{Name: "repo-" + ref + "/" + e.name, Typeflag: typeflag, Linkname: e.linkname, Mode: 0o644}
+		if typeflag == tar.TypeReg {
+			header.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
+
+func TestEvictSkipsSubgroupSnapshotInUse(t *testing.T) {
+	source := &tarballs{entries: []entry{{name: "main.go", body: "package main\n"}}}
+	manager, err := workspace.NewManager(source, workspace.Config{Dir: t.TempDir(), MaxEntries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// A GitLab project in a subgroup is held while another snapshot is
+	// cached, which evicts the least recently used one
+	held, err := manager.Get(ctx, "group/sub", "app", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Release(held)
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(held.Root, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := manager.Get(ctx, "octo", "demo", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.Release(other)
+
+	if _, err := held.ReadFile(ctx, "main.go"); err != nil {
+		t.Errorf("snapshot in use was evicted: %v", err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []entry
+		maxBytes int64
+		wantErr  error
+		invalid  bool
+	}{
+		{
+			name:    "path traversal",
+			entries: []entry{{name: "../escape.go", body: "package escape\n"}},
+			invalid: true,
+		},
+		{
+			name:     "oversized archive",
+			entries:  []entry{{name: "a.txt", body: "12345"}, {name: "b.txt", body: "67890"}},
+			maxBytes: 8,
+			wantErr:  workspace.ErrTooLarge,
+		},
+		{
+			name: "links are skipped",
+			entries: []entry{
+				{name: "main.go", body: "package main\n"},
+				{name: "passwd", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+				{name: "hosts", typeflag: tar.TypeLink, linkname: "../../etc/hosts"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			manager, err := workspace.NewManager(&tarballs{entries: tt.entries}, workspace.Config{Dir: filepath.Join(dir, "cache"), MaxBytes: tt.maxBytes})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			snapshot, err := manager.Get(context.Background(), "octo", "demo", "head")
+			switch {
+			case tt.invalid:
+				if err == nil || !strings.Contains(err.Error(), "invalid path in archive") {
+					t.Errorf("got error %v, want an invalid path", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got error %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatal(err)
+			}
+			if err != nil {
+				// Nothing is left behind outside the cache, or in it
+				if _, statErr := os.Stat(filepath.Join(dir, "escape.go")); statErr == nil {
+					t.Error("archive wrote outside the snapshot")
+				}
+				if paths, _ := filepath.Glob(filepath.Join(dir, "cache", "*", "*", "*")); len(paths) != 0 {
+					t.Errorf("failed extraction left %v", paths)
+				}
+				return
+			}
+			defer manager.Release(snapshot)
+
+			paths, err := snapshot.Walk(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(paths) != 1 || paths[0] != "main.go" {
+				t.Errorf("got files %v, want only main.go", paths)
+			}
+		})
+	}
+}
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	source := &tarballs{entries: []entry{{name: "main.go", body: "package main\n"}}}
+	manager, err := workspace.NewManager(source, workspace.Config{Dir: t.TempDir(), MaxEntries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Cache a, b and c in that order, holding a
+	get := func(ref string, age time.Duration) *workspace.Snapshot {
+		t.Helper()
+		snapshot, err := manager.Get(ctx, "octo", "demo", ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		when := time.Now().Add(-age)
+		if err := os.Chtimes(snapshot.Root, when, when); err != nil {
+			t.Fatal(err)
+		}
+		return snapshot
+	}
+	held := get("a", 3*time.Hour)
+	defer manager.Release(held)
+	manager.Release(get("b", 2*time.Hour))
+	manager.Release(get("c", time.Hour))
+
+	// Caching d evicts the oldest snapshots beyond the two newest, except a
+	manager.Release(get("d", 0))
+
+	// Getting a snapshot again makes it the most recently used, so the
+	// snapshots are checked from the newest
+	downloads := source.downloads
+	for _, tt := range []struct {
+		ref    string
+		cached bool
+	}{{"d", true}, {"a", true}, {"b", false}} {
+		snapshot, err := manager.Get(ctx, "octo", "demo", tt.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manager.Release(snapshot)
+
+		downloaded := source.downloads > downloads
+		downloads = source.downloads
+		if downloaded == tt.cached {
+			t.Errorf("%s: downloaded again = %v, want cached = %v", tt.ref, downloaded, tt.cached)
+		}
+	}
+}
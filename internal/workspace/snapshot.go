@@ -0,0 +1,92 @@
+package workspace
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Snapshot is a repository extracted at a single commit
+type Snapshot struct {
+	Owner string
+	Repo  string
+	Ref   string
+
+	// Root is the directory the repository was extracted into
+	Root string
+}
+
+// Path returns the absolute path of a repository-relative file
+func (s *Snapshot) Path(rel string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(rel))
+}
+
+// ReadFile returns the content of a file in the snapshot
+func (s *Snapshot) ReadFile(ctx context.Context, rel string) (string, error) {
+	data, err := os.ReadFile(s.Path(rel))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ListDirectory returns the repository-relative paths of the files in a directory
+func (s *Snapshot) ListDirectory(ctx context.Context, dir string) ([]string, error) {
+	entries, err := os.ReadDir(s.Path(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			paths = append(paths, path.Join(dir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// Walk returns the repository-relative paths of every file in the snapshot
+// accepted by keep, in lexical order
+func (s *Snapshot) Walk(keep func(rel string) bool) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(s.Root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if keep == nil || keep(rel) {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// key identifies the snapshot in the manager's cache
+func (s *Snapshot) key() string {
+	return snapshotKey(s.Owner, s.Repo, s.Ref)
+}
+
+// snapshotKey builds the cache key for a repository commit, which is also
+// the slash-separated path of its directory in a persistent cache. GitLab
+// owners are group paths, which are escaped to a single directory.
+func snapshotKey(owner, repo, ref string) string {
+	return path.Join(url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref))
+}
@@ -36,6 +36,23 @@ type ReviewResponse struct {
 	GeneralComments   []GeneralComment `json:"general_comments"`
 	FileComments      []FileComment    `json:"file_comments"`
 	Summary           string           `json:"summary,omitempty"`
+
+	// Policy records how policy rules changed the model's decision
+	Policy *PolicyOutcome `json:"-"`
+
+	// PromptVersion names the prompt template and hash the review came from
+	PromptVersion string `json:"-"`
+
+	// OutsideDiff holds file comments on lines the change doesn't touch,
+	// which are posted in the review body instead of inline
+	OutsideDiff []FileComment `json:"-"`
+}
+
+// PolicyOutcome records the model's original decision and the policy rules
+// that changed it, in the order they applied
+type PolicyOutcome struct {
+	ModelDecision ReviewDecision
+	Rules         []string
 }
 
 // GeneralComment represents overall feedback about the PR
@@ -51,6 +68,16 @@ type FileComment struct {
 	Body     string      `json:"body"`
 	Severity Severity    `json:"severity"`
 	Type     CommentType `json:"type"`
+
+	// URL links the commented line at the head commit
+	URL string `json:"-"`
+
+	// OriginalLine is the line the model commented on, when the comment was
+	// moved to a nearby changed line
+	OriginalLine int `json:"-"`
+
+	// Fingerprint identifies the finding across reviews of the same PR
+	Fingerprint string `json:"-"`
 }
 
 // IsBlockingDecision returns true if the decision blocks the PR
@@ -87,6 +114,12 @@ func (r *ReviewResponse) HasBlockingIssues() bool {
 		}
 	}
 
+	for _, comment := range r.OutsideDiff {
+		if comment.Severity.IsError() {
+			return true
+		}
+	}
+
 	return false
 }
 
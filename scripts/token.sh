@@ -5,9 +5,13 @@ set -eou pipefail
 if [[ $# -ne 3 ]]; then
   echo "Usage: $0 <APP_ID> <INSTALL_ID> <PRIVATE_KEY_FILE_PATH>"
   echo "e.g. ./ci/fetch-app-token.sh 123 456 /path/to/priv.pem"
+  echo "Set GITHUB_API_URL to use GitHub Enterprise Server, e.g. https://github.example.edu/api/v3"
   exit 1
 fi
 
+API_URL="${GITHUB_API_URL:-https://api.github.com}"
+API_URL="${API_URL%/}"
+
 APP_ID="$1"
 INSTALL_ID="$2"
 PRIVATE_KEY_FILE="$3"
@@ -49,7 +53,7 @@ JWT_SIGNATURE=$(echo -n "${JWT_HEADER}.${JWT_PAYLOAD}" \
 JWT="${JWT_HEADER}.${JWT_PAYLOAD}.${JWT_SIGNATURE}"
 
 RESPONSE=$(curl -s -X POST \
-  "https://api.github.com/app/installations/${INSTALL_ID}/access_tokens" \
+  "${API_URL}/app/installations/${INSTALL_ID}/access_tokens" \
   -H "Authorization: Bearer $JWT" \
   -H "Accept: application/vnd.github+json")
 
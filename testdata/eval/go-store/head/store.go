@@ -0,0 +1,17 @@
+package store
+
+import (
+	"database/sql"
+	"os"
+)
+
+// Save writes data to path
+func Save(path string, data []byte) error {
+	os.WriteFile(path, data, 0o644)
+	return nil
+}
+
+// Find looks up a record by name
+func Find(db *sql.DB, name string) (*sql.Rows, error) {
+	return db.Query("SELECT * FROM records WHERE name = '" + name + "'")
+}